@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN manager_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    ADD CONSTRAINT chk_users_manager_not_self CHECK (manager_id <> id);
+
+CREATE INDEX idx_users_manager_id ON users(manager_id);
+
+COMMIT;
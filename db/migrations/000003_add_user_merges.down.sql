@@ -0,0 +1,10 @@
+BEGIN;
+
+DROP TABLE IF EXISTS user_history;
+
+DROP INDEX IF EXISTS idx_users_merged_into;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS merged_into;
+
+COMMIT;
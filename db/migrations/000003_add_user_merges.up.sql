@@ -0,0 +1,18 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN merged_into UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_users_merged_into ON users(merged_into);
+
+CREATE TABLE user_history (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    action TEXT NOT NULL,
+    details JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_user_history_user_id ON user_history(user_id);
+
+COMMIT;
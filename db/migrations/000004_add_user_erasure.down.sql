@@ -0,0 +1,8 @@
+BEGIN;
+
+DROP TABLE IF EXISTS user_events;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS erased_at;
+
+COMMIT;
@@ -0,0 +1,17 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN erased_at TIMESTAMP;
+
+CREATE TABLE user_events (
+    id BIGSERIAL PRIMARY KEY,
+    user_id UUID NOT NULL,
+    event_type TEXT NOT NULL,
+    payload JSONB NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX idx_user_events_unpublished ON user_events(id) WHERE published_at IS NULL;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TABLE user_events
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN published_at TYPE TIMESTAMP;
+
+ALTER TABLE user_history
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMP,
+    ALTER COLUMN created_at SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN created_at DROP NOT NULL,
+    ALTER COLUMN updated_at TYPE TIMESTAMP,
+    ALTER COLUMN updated_at SET DEFAULT CURRENT_TIMESTAMP,
+    ALTER COLUMN updated_at DROP NOT NULL,
+    ALTER COLUMN erased_at TYPE TIMESTAMP;
+
+COMMIT;
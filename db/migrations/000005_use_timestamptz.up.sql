@@ -0,0 +1,24 @@
+BEGIN;
+
+-- Existing values were written in the application server's local time and are
+-- interpreted in this session's TimeZone; SET TIME ZONE accordingly before running
+-- this migration if the database runs in a different zone.
+ALTER TABLE users
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at SET DEFAULT NOW(),
+    ALTER COLUMN created_at SET NOT NULL,
+    ALTER COLUMN updated_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN updated_at SET DEFAULT NOW(),
+    ALTER COLUMN updated_at SET NOT NULL,
+    ALTER COLUMN erased_at TYPE TIMESTAMPTZ;
+
+ALTER TABLE user_history
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at SET DEFAULT NOW();
+
+ALTER TABLE user_events
+    ALTER COLUMN created_at TYPE TIMESTAMPTZ,
+    ALTER COLUMN created_at SET DEFAULT NOW(),
+    ALTER COLUMN published_at TYPE TIMESTAMPTZ;
+
+COMMIT;
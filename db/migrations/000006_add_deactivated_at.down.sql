@@ -0,0 +1,11 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_users_track_deactivation ON users;
+DROP FUNCTION IF EXISTS users_track_deactivation();
+
+DROP INDEX IF EXISTS idx_users_deactivated_at;
+
+ALTER TABLE users
+    DROP COLUMN IF EXISTS deactivated_at;
+
+COMMIT;
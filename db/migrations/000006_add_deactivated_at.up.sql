@@ -0,0 +1,25 @@
+BEGIN;
+
+ALTER TABLE users
+    ADD COLUMN deactivated_at TIMESTAMPTZ;
+
+-- The exact time existing users were deactivated is unknown; their last update is the best estimate
+UPDATE users SET deactivated_at = updated_at WHERE is_active = FALSE;
+
+CREATE INDEX idx_users_deactivated_at ON users(deactivated_at) WHERE deactivated_at IS NOT NULL;
+
+-- Keep deactivated_at in step with is_active on every write path
+CREATE FUNCTION users_track_deactivation() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' OR NEW.is_active IS DISTINCT FROM OLD.is_active THEN
+        NEW.deactivated_at := CASE WHEN NEW.is_active = FALSE THEN NOW() END;
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_users_track_deactivation
+    BEFORE INSERT OR UPDATE OF is_active ON users
+    FOR EACH ROW EXECUTE FUNCTION users_track_deactivation();
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Supports the case-insensitive exact lookup by email
+CREATE INDEX idx_users_email_lower ON users(LOWER(email));
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP TRIGGER IF EXISTS trg_users_touch_updated_at ON users;
+DROP FUNCTION IF EXISTS users_touch_updated_at();
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- updated_at is the row version entity tags are derived from, so every change to a user has
+-- to move it, including ones the application does not write itself such as ON DELETE SET NULL
+CREATE FUNCTION users_touch_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.updated_at IS NOT DISTINCT FROM OLD.updated_at THEN
+        NEW.updated_at := NOW();
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_users_touch_updated_at
+    BEFORE UPDATE ON users
+    FOR EACH ROW EXECUTE FUNCTION users_touch_updated_at();
+
+COMMIT;
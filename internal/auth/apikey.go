@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+)
+
+// API keys look like gcu_<12 hex characters>_<43 base64url characters>. The part up to the
+// second underscore is the prefix, which is stored in the clear to find the key; the rest is a
+// 256-bit secret, of which only a hash is stored.
+const (
+	apiKeyTag          = "gcu_"
+	apiKeyPrefixLength = len(apiKeyTag) + 12
+	apiKeySecretBytes  = 32
+)
+
+// lastUsedResolution is how stale an API key's last use may get before it is written again,
+// which spares a database write on most requests
+const lastUsedResolution = time.Minute
+
+// APIKeyStore finds API keys by prefix and records their use
+type APIKeyStore interface {
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// GenerateAPIKey creates a new key, returning it with the prefix and hash to store
+func GenerateAPIKey() (key, prefix string, hash []byte, err error) {
+	id := make([]byte, (apiKeyPrefixLength-len(apiKeyTag))/2)
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", nil, fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	prefix = apiKeyTag + hex.EncodeToString(id)
+	key = prefix + "_" + base64.RawURLEncoding.EncodeToString(secret)
+	return key, prefix, hashAPIKey(key), nil
+}
+
+// hashAPIKey hashes a key for storage. The secret has 256 bits of entropy, so a fast hash cannot
+// be brute-forced and no password hash is needed.
+func hashAPIKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+// APIKeyAuthenticator authenticates the ApiKey scheme against the stored keys. The principal's
+// subject is the key's service and its scopes are the key's scopes.
+type APIKeyAuthenticator struct {
+	store APIKeyStore
+	now   func() time.Time
+}
+
+// NewAPIKeyAuthenticator creates an authenticator for the keys in store
+func NewAPIKeyAuthenticator(store APIKeyStore) *APIKeyAuthenticator {
+	return &APIKeyAuthenticator{store: store, now: time.Now}
+}
+
+// Scheme implements Authenticator
+func (a *APIKeyAuthenticator) Scheme() string {
+	return "ApiKey"
+}
+
+// Authenticate checks that the key exists, matches its hash, and is neither revoked nor expired
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, key string) (*Principal, error) {
+	if len(key) <= apiKeyPrefixLength || !strings.HasPrefix(key, apiKeyTag) || key[apiKeyPrefixLength] != '_' {
+		return nil, fmt.Errorf("%w: malformed api key", ErrInvalidCredentials)
+	}
+	prefix := key[:apiKeyPrefixLength]
+
+	stored, err := a.store.GetAPIKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if strings.Contains(err.Error(), "api key not found") {
+			return nil, fmt.Errorf("%w: unknown api key %s", ErrInvalidCredentials, prefix)
+		}
+		return nil, err
+	}
+
+	now := a.now()
+	switch {
+	case subtle.ConstantTimeCompare(hashAPIKey(key), stored.KeyHash) != 1:
+		return nil, fmt.Errorf("%w: wrong secret for api key %s", ErrInvalidCredentials, prefix)
+	case stored.RevokedAt != nil:
+		return nil, fmt.Errorf("%w: api key %s was revoked", ErrInvalidCredentials, prefix)
+	case stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt):
+		return nil, fmt.Errorf("%w: api key %s expired", ErrInvalidCredentials, prefix)
+	}
+
+	if stored.LastUsedAt == nil || now.Sub(*stored.LastUsedAt) >= lastUsedResolution {
+		// Failing to record the use must not fail the request
+		if err := a.store.TouchAPIKey(ctx, stored.ID, now); err != nil {
+			log.Printf("Error recording use of api key %s: %v", prefix, err)
+		}
+	}
+
+	principal := &Principal{Subject: stored.Service, Scopes: stored.Scopes, Service: true}
+	if stored.ExpiresAt != nil {
+		principal.ExpiresAt = *stored.ExpiresAt
+	}
+	return principal, nil
+}
@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAPIKeyStore holds keys by prefix and records touches
+type memoryAPIKeyStore struct {
+	keys    map[string]*models.APIKey
+	touched []uuid.UUID
+	err     error
+}
+
+func (s *memoryAPIKeyStore) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	key, ok := s.keys[prefix]
+	if !ok {
+		return nil, fmt.Errorf("api key not found")
+	}
+	return key, nil
+}
+
+func (s *memoryAPIKeyStore) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	s.touched = append(s.touched, id)
+	return nil
+}
+
+// storeKey generates a key and stores it, returning the secret key
+func storeKey(t *testing.T, store *memoryAPIKeyStore, update func(*models.APIKey)) string {
+	key, prefix, hash, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	stored := &models.APIKey{ID: uuid.New(), Service: "erp-sync", Prefix: prefix, KeyHash: hash, Scopes: []string{"users:read"}}
+	if update != nil {
+		update(stored)
+	}
+	store.keys[prefix] = stored
+	return key
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	assert.Regexp(t, `^gcu_[0-9a-f]{12}_[A-Za-z0-9_-]{43}$`, key)
+	assert.Equal(t, key[:len(prefix)], prefix)
+	assert.Len(t, hash, 32)
+	assert.NotContains(t, string(hash), key[len(prefix)+1:])
+
+	other, _, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key, other)
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	store := &memoryAPIKeyStore{keys: map[string]*models.APIKey{}}
+	authenticator := NewAPIKeyAuthenticator(store)
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	authenticator.now = func() time.Time { return now }
+
+	key := storeKey(t, store, func(k *models.APIKey) {
+		expiresAt := now.Add(time.Hour)
+		k.ExpiresAt = &expiresAt
+	})
+
+	principal, err := authenticator.Authenticate(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "erp-sync", principal.Subject)
+	assert.Empty(t, principal.Issuer)
+	assert.True(t, principal.Service)
+	assert.Equal(t, []string{"users:read"}, principal.Scopes)
+	assert.Equal(t, now.Add(time.Hour), principal.ExpiresAt)
+	assert.Len(t, store.touched, 1)
+}
+
+func TestAPIKeyAuthenticator_LastUsed(t *testing.T) {
+	store := &memoryAPIKeyStore{keys: map[string]*models.APIKey{}}
+	authenticator := NewAPIKeyAuthenticator(store)
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	authenticator.now = func() time.Time { return now }
+
+	lastUsedAt := now.Add(-30 * time.Second)
+	key := storeKey(t, store, func(k *models.APIKey) { k.LastUsedAt = &lastUsedAt })
+
+	_, err := authenticator.Authenticate(context.Background(), key)
+	require.NoError(t, err)
+	assert.Empty(t, store.touched, "a recent use is not written again")
+
+	now = now.Add(time.Minute)
+	_, err = authenticator.Authenticate(context.Background(), key)
+	require.NoError(t, err)
+	assert.Len(t, store.touched, 1)
+}
+
+func TestAPIKeyAuthenticator_Rejects(t *testing.T) {
+	store := &memoryAPIKeyStore{keys: map[string]*models.APIKey{}}
+	authenticator := NewAPIKeyAuthenticator(store)
+	now := time.Now()
+
+	valid := storeKey(t, store, nil)
+	revoked := storeKey(t, store, func(k *models.APIKey) { k.RevokedAt = &now })
+	expired := storeKey(t, store, func(k *models.APIKey) { k.ExpiresAt = &now })
+	unknown, _, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		key  string
+	}{
+		{"malformed", "not-a-key"},
+		{"prefix only", valid[:apiKeyPrefixLength]},
+		{"wrong secret", valid[:apiKeyPrefixLength+1] + "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+		{"unknown", unknown},
+		{"revoked", revoked},
+		{"expired", expired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := authenticator.Authenticate(context.Background(), tt.key)
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+	assert.Empty(t, store.touched)
+}
+
+func TestAPIKeyAuthenticator_StoreError(t *testing.T) {
+	store := &memoryAPIKeyStore{err: errors.New("connection refused")}
+	key, _, _, err := GenerateAPIKey()
+	require.NoError(t, err)
+
+	_, err = NewAPIKeyAuthenticator(store).Authenticate(context.Background(), key)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrInvalidCredentials, "store failures are not the caller's fault")
+}
@@ -0,0 +1,86 @@
+// Package auth identifies the callers of the API.
+//
+// An Authenticator verifies the credentials of one Authorization header scheme and returns the
+// Principal they belong to. Middleware stores the principal in the request context, where
+// handlers read it with FromContext.
+package auth
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCredentials is wrapped by the errors of credentials that are malformed, expired or
+// otherwise not accepted, as opposed to failures to check them
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+// Scopes granted to API keys. Users are authorized by their role instead.
+const (
+	ScopeAPIKeysManage = "api-keys:manage" // Create, list and revoke API keys
+	ScopeUsersRead     = "users:read"      // Read any user
+	ScopeUsersWrite    = "users:write"     // Create, change and remove any user
+)
+
+// Authenticator verifies the credentials of one Authorization scheme
+type Authenticator interface {
+	// Scheme is the Authorization scheme the credentials are sent with, e.g. Bearer
+	Scheme() string
+	// Authenticate returns the principal the credentials belong to
+	Authenticate(ctx context.Context, credentials string) (*Principal, error)
+}
+
+// Principal is an authenticated caller
+type Principal struct {
+	Subject   string    // The sub claim of a token, or the service of an API key
+	Issuer    string    // The iss claim of a token; empty for API keys
+	Scopes    []string  // Scopes granted to the caller
+	ExpiresAt time.Time // When the credentials expire; zero if they do not
+	Service   bool      // Whether the subject is a service rather than the ID of a user
+}
+
+// HasScope reports whether the principal was granted scope
+func (p *Principal) HasScope(scope string) bool {
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Key identifies the principal for caches and stored responses: its kind, issuer, subject and
+// scopes, which are what a service is authorized by. Each part is prefixed with its length, so no
+// two principals share a key whatever characters their claims contain.
+func (p *Principal) Key() string {
+	kind := "user"
+	if p.Service {
+		kind = "service"
+	}
+	scopes := append([]string(nil), p.Scopes...)
+	sort.Strings(scopes)
+
+	var key strings.Builder
+	for _, part := range append([]string{kind, p.Issuer, p.Subject}, scopes...) {
+		key.WriteString(strconv.Itoa(len(part)))
+		key.WriteByte(':')
+		key.WriteString(part)
+	}
+	return key.String()
+}
+
+type principalKey struct{}
+
+// NewContext returns a copy of ctx carrying principal
+func NewContext(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal of an authenticated request
+func FromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestPrincipal_Key tests that principals only share a key when they are authorized alike
+func TestPrincipal_Key(t *testing.T) {
+	principals := map[string]*Principal{
+		"user":                   {Subject: "erp-sync", Issuer: testIssuer},
+		"user of another issuer": {Subject: "erp-sync", Issuer: "https://other.example.com/"},
+		"service":                {Subject: "erp-sync", Service: true},
+		"service with a scope":   {Subject: "erp-sync", Scopes: []string{ScopeUsersRead}, Service: true},
+		"subject with a colon":   {Subject: "1:erp-sync", Issuer: testIssuer[2:]},
+	}
+
+	seen := map[string]string{}
+	for name, principal := range principals {
+		key := principal.Key()
+		assert.NotContains(t, seen, key, "%s has the key of %s", name, seen[key])
+		seen[key] = name
+	}
+
+	a := &Principal{Subject: "erp-sync", Scopes: []string{ScopeUsersRead, ScopeUsersWrite}, Service: true}
+	b := &Principal{Subject: "erp-sync", Scopes: []string{ScopeUsersWrite, ScopeUsersRead}, Service: true}
+	assert.Equal(t, a.Key(), b.Key(), "the order of scopes does not matter")
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of a KeySet's refresh policy
+const (
+	defaultRefreshInterval    = 15 * time.Minute
+	defaultMinRefreshInterval = time.Minute
+)
+
+// maxJWKSSize bounds the size of a key set document
+const maxJWKSSize = 1 << 20
+
+// minRSABits is the smallest RSA modulus accepted
+const minRSABits = 2048
+
+// publicKey is a verification key together with the only algorithm it may be used with
+type publicKey struct {
+	alg string
+	key crypto.PublicKey
+}
+
+// KeySet holds the signing keys of a token issuer, loaded from a JWKS document in a local file
+// or at an HTTPS URL. The keys are reloaded every refresh interval, and also when a token names
+// a key the set does not contain, as happens after the issuer rotates its keys; such unscheduled
+// reloads happen at most once per minimum refresh interval. When a reload fails the previous keys
+// stay in use.
+type KeySet struct {
+	source             string
+	httpClient         *http.Client
+	refreshInterval    time.Duration
+	minRefreshInterval time.Duration
+	now                func() time.Time
+
+	mu        sync.RWMutex
+	keys      map[string]*publicKey // Keyed by kid
+	fetchedAt time.Time
+
+	refreshMu   sync.Mutex
+	attemptedAt time.Time
+}
+
+// KeySetOption configures a KeySet
+type KeySetOption func(*KeySet)
+
+// WithRefreshInterval sets how often the keys are reloaded
+func WithRefreshInterval(interval time.Duration) KeySetOption {
+	return func(s *KeySet) {
+		s.refreshInterval = interval
+	}
+}
+
+// WithMinRefreshInterval sets the minimum time between reloads triggered by unknown keys
+func WithMinRefreshInterval(interval time.Duration) KeySetOption {
+	return func(s *KeySet) {
+		s.minRefreshInterval = interval
+	}
+}
+
+// WithHTTPClient sets the HTTP client a key set URL is fetched with
+func WithHTTPClient(httpClient *http.Client) KeySetOption {
+	return func(s *KeySet) {
+		s.httpClient = httpClient
+	}
+}
+
+// NewKeySet loads the key set at source, a file path or an http(s) URL
+func NewKeySet(ctx context.Context, source string, opts ...KeySetOption) (*KeySet, error) {
+	s := &KeySet{
+		source:             source,
+		httpClient:         &http.Client{Timeout: 10 * time.Second},
+		refreshInterval:    defaultRefreshInterval,
+		minRefreshInterval: defaultMinRefreshInterval,
+		now:                time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	keys, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.keys, s.fetchedAt, s.attemptedAt = keys, s.now(), s.now()
+	return s, nil
+}
+
+// lookup returns the key with the given kid, reloading the set if it is due or does not contain
+// the key. A token without a kid can only be verified by a set with a single key.
+func (s *KeySet) lookup(ctx context.Context, kid string) (*publicKey, error) {
+	s.mu.RLock()
+	key, ok := s.find(kid)
+	stale := s.now().Sub(s.fetchedAt) >= s.refreshInterval
+	s.mu.RUnlock()
+	if ok && !stale {
+		return key, nil
+	}
+
+	s.refresh(ctx)
+
+	s.mu.RLock()
+	key, ok = s.find(kid)
+	s.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (s *KeySet) find(kid string) (*publicKey, bool) {
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// refresh reloads the keys unless that was attempted within the minimum refresh interval, which
+// also stops concurrent lookups of an unknown key from reloading the set once each
+func (s *KeySet) refresh(ctx context.Context) {
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	if s.now().Sub(s.attemptedAt) < s.minRefreshInterval {
+		return
+	}
+	s.attemptedAt = s.now()
+
+	keys, err := s.load(ctx)
+	if err != nil {
+		log.Printf("Warning: failed to reload JWKS, keeping the previous keys: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.keys, s.fetchedAt = keys, s.now()
+	s.mu.Unlock()
+}
+
+// load reads and parses the key set document
+func (s *KeySet) load(ctx context.Context) (map[string]*publicKey, error) {
+	var data []byte
+	var err error
+	if strings.HasPrefix(s.source, "https://") || strings.HasPrefix(s.source, "http://") {
+		data, err = s.fetch(ctx)
+	} else {
+		data, err = os.ReadFile(s.source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWKS: %w", err)
+	}
+	return parseJWKS(data)
+}
+
+func (s *KeySet) fetch(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.source, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxJWKSSize))
+}
+
+// jwk is a JSON Web Key (RFC 7517) with the members of RSA, EC and OKP public keys
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS parses the signature keys of a JWKS document. Keys of other types, curves or
+// algorithms than RS256, ES256 and EdDSA are skipped, as are encryption keys.
+func parseJWKS(data []byte) (map[string]*publicKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid JWKS: %w", err)
+	}
+
+	keys := make(map[string]*publicKey)
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid JWKS key %q: %w", k.Kid, err)
+		}
+		if key == nil || (k.Alg != "" && k.Alg != key.alg) {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("invalid JWKS: no RS256, ES256 or EdDSA signature keys")
+	}
+	return keys, nil
+}
+
+// publicKey decodes the key, returning nil for keys of unsupported types
+func (k *jwk) publicKey() (*publicKey, error) {
+	switch {
+	case k.Kty == "RSA":
+		n, err := decodeBase64URL(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64URL(k.E)
+		if err != nil {
+			return nil, err
+		}
+		key := &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if key.N.BitLen() < minRSABits {
+			return nil, fmt.Errorf("RSA key is shorter than %d bits", minRSABits)
+		}
+		if key.E < 3 || key.E%2 == 0 {
+			return nil, fmt.Errorf("invalid RSA exponent")
+		}
+		return &publicKey{alg: "RS256", key: key}, nil
+
+	case k.Kty == "EC" && k.Crv == "P-256":
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64URL(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid P-256 coordinates")
+		}
+		// Rejects points that are not on the curve
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return nil, fmt.Errorf("invalid P-256 point: %w", err)
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		return &publicKey{alg: "ES256", key: key}, nil
+
+	case k.Kty == "OKP" && k.Crv == "Ed25519":
+		x, err := decodeBase64URL(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 key size")
+		}
+		return &publicKey{alg: "EdDSA", key: ed25519.PublicKey(x)}, nil
+	}
+	return nil, nil
+}
+
+func decodeBase64URL(s string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil || len(data) == 0 {
+		return nil, fmt.Errorf("invalid base64url value")
+	}
+	return data, nil
+}
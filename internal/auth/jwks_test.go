@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksServer serves the current key set document and counts the requests for it
+type jwksServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	document []byte
+	status   int
+	requests atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys ...*signingKey) *jwksServer {
+	s := &jwksServer{document: jwksDocument(t, keys...), status: http.StatusOK}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests.Add(1)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		w.WriteHeader(s.status)
+		w.Write(s.document)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) set(status int, document []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.document = status, document
+}
+
+func TestNewKeySet_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"missing file", filepath.Join(dir, "missing.json")},
+		{"invalid JSON", write("invalid.json", "{")},
+		{"no keys", write("empty.json", `{"keys": []}`)},
+		{"only unsupported keys", write("unsupported.json", `{"keys": [
+			{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+			{"kty": "EC", "kid": "p384", "crv": "P-384", "x": "AA", "y": "AA"}
+		]}`)},
+		{"short RSA key", write("short.json", `{"keys": [{"kty": "RSA", "kid": "short", "n": "AQAB", "e": "AQAB"}]}`)},
+		{"point not on curve", write("point.json", `{"keys": [{"kty": "EC", "kid": "ec", "crv": "P-256",
+			"x": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE", "y": "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"}]}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeySet(context.Background(), tt.source)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestNewKeySet_SkipsUnsupportedKeys(t *testing.T) {
+	key := newEd25519Key(t, "ed")
+	jwk := key.jwk()
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"keys": [
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+		{"kty": "OKP", "kid": "enc", "use": "enc", "crv": "Ed25519", "x": "`+jwk["x"]+`"},
+		{"kty": "OKP", "kid": "ed", "crv": "Ed25519", "x": "`+jwk["x"]+`"}
+	]}`), 0o600))
+
+	keySet, err := NewKeySet(context.Background(), path)
+	require.NoError(t, err)
+	assert.Len(t, keySet.keys, 1)
+	assert.Contains(t, keySet.keys, "ed")
+}
+
+func TestKeySet_URL(t *testing.T) {
+	key := newRSAKey(t, "rsa")
+	server := newJWKSServer(t, key)
+
+	keySet, err := NewKeySet(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	verifier := NewJWTVerifier(keySet, testIssuer, testAudience)
+	_, err = verifier.Authenticate(context.Background(), key.sign(t, validClaims()))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), server.requests.Load(), "known keys are served from the cache")
+}
+
+func TestKeySet_Rotation(t *testing.T) {
+	oldKey, newKey := newECKey(t, "old"), newECKey(t, "new")
+	server := newJWKSServer(t, oldKey)
+
+	keySet, err := NewKeySet(context.Background(), server.URL, WithMinRefreshInterval(0))
+	require.NoError(t, err)
+	verifier := NewJWTVerifier(keySet, testIssuer, testAudience)
+
+	server.set(http.StatusOK, jwksDocument(t, newKey))
+
+	// A token signed with a key the set does not know yet triggers a reload
+	_, err = verifier.Authenticate(context.Background(), newKey.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load())
+
+	_, err = verifier.Authenticate(context.Background(), oldKey.sign(t, validClaims()))
+	assert.ErrorIs(t, err, ErrInvalidCredentials, "the retired key is dropped")
+}
+
+func TestKeySet_UnknownKidThrottled(t *testing.T) {
+	key := newECKey(t, "ec")
+	server := newJWKSServer(t, key)
+
+	keySet, err := NewKeySet(context.Background(), server.URL)
+	require.NoError(t, err)
+	verifier := NewJWTVerifier(keySet, testIssuer, testAudience)
+
+	unknown := newECKey(t, "unknown")
+	for range 5 {
+		_, err = verifier.Authenticate(context.Background(), unknown.sign(t, validClaims()))
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+	assert.Equal(t, int32(1), server.requests.Load(), "unknown kids do not reload within the minimum interval")
+}
+
+func TestKeySet_ScheduledRefresh(t *testing.T) {
+	key := newECKey(t, "ec")
+	server := newJWKSServer(t, key)
+
+	keySet, err := NewKeySet(context.Background(), server.URL, WithRefreshInterval(time.Hour))
+	require.NoError(t, err)
+	now := time.Now()
+	keySet.now = func() time.Time { return now }
+	verifier := NewJWTVerifier(keySet, testIssuer, testAudience)
+
+	now = now.Add(2 * time.Hour)
+	_, err = verifier.Authenticate(context.Background(), key.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), server.requests.Load(), "a stale set is reloaded")
+
+	// A failed reload keeps the previous keys
+	server.set(http.StatusInternalServerError, nil)
+	now = now.Add(2 * time.Hour)
+	_, err = verifier.Authenticate(context.Background(), key.sign(t, validClaims()))
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), server.requests.Load())
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew is the tolerance for differences between the issuer's clock and ours
+const clockSkew = 30 * time.Second
+
+// signingMethods are the accepted token algorithms; keys are bound to one of them
+var signingMethods = []string{"RS256", "ES256", "EdDSA"}
+
+// tokenClaims are the claims read from access tokens
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	Scope string `json:"scope"` // Space-separated, as in RFC 8693
+}
+
+// JWTVerifier authenticates Bearer tokens signed by an issuer's keys
+type JWTVerifier struct {
+	keys   *KeySet
+	parser *jwt.Parser
+}
+
+// NewJWTVerifier creates a verifier accepting tokens from issuer for audience, signed with a key in keys
+func NewJWTVerifier(keys *KeySet, issuer, audience string) *JWTVerifier {
+	return &JWTVerifier{
+		keys: keys,
+		parser: jwt.NewParser(
+			jwt.WithValidMethods(signingMethods),
+			jwt.WithIssuer(issuer),
+			jwt.WithAudience(audience),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(clockSkew),
+		),
+	}
+}
+
+// Scheme implements Authenticator
+func (v *JWTVerifier) Scheme() string {
+	return "Bearer"
+}
+
+// Authenticate verifies a token's signature, issuer, audience and expiry
+func (v *JWTVerifier) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	var claims tokenClaims
+	_, err := v.parser.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, err := v.keys.lookup(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		// A key may only verify the algorithm it was published for
+		if t.Method.Alg() != key.alg {
+			return nil, fmt.Errorf("key %q is not an %s key", kid, t.Method.Alg())
+		}
+		return key.key, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCredentials, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredentials)
+	}
+
+	return &Principal{
+		Subject:   claims.Subject,
+		Issuer:    claims.Issuer,
+		Scopes:    strings.Fields(claims.Scope),
+		ExpiresAt: claims.ExpiresAt.Time,
+	}, nil
+}
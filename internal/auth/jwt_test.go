@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testIssuer   = "https://issuer.example.com/"
+	testAudience = "user-service"
+)
+
+// signingKey is a private key with its JWK representation
+type signingKey struct {
+	kid    string
+	method jwt.SigningMethod
+	key    crypto.Signer
+}
+
+func newRSAKey(t *testing.T, kid string) *signingKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodRS256, key: key}
+}
+
+func newECKey(t *testing.T, kid string) *signingKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodES256, key: key}
+}
+
+func newEd25519Key(t *testing.T, kid string) *signingKey {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, key: key}
+}
+
+func (k *signingKey) jwk() map[string]string {
+	enc := base64.RawURLEncoding.EncodeToString
+	switch pub := k.key.Public().(type) {
+	case *rsa.PublicKey:
+		return map[string]string{"kty": "RSA", "kid": k.kid, "use": "sig", "alg": "RS256",
+			"n": enc(pub.N.Bytes()), "e": enc(big.NewInt(int64(pub.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return map[string]string{"kty": "EC", "kid": k.kid, "crv": "P-256",
+			"x": enc(pub.X.FillBytes(make([]byte, 32))), "y": enc(pub.Y.FillBytes(make([]byte, 32)))}
+	case ed25519.PublicKey:
+		return map[string]string{"kty": "OKP", "kid": k.kid, "crv": "Ed25519", "x": enc(pub)}
+	}
+	panic("unsupported key")
+}
+
+func (k *signingKey) sign(t *testing.T, claims jwt.Claims) string {
+	token := jwt.NewWithClaims(k.method, claims)
+	if k.kid != "" {
+		token.Header["kid"] = k.kid
+	}
+	signed, err := token.SignedString(k.key)
+	require.NoError(t, err)
+	return signed
+}
+
+func jwksDocument(t *testing.T, keys ...*signingKey) []byte {
+	jwks := map[string][]map[string]string{"keys": {}}
+	for _, key := range keys {
+		jwks["keys"] = append(jwks["keys"], key.jwk())
+	}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+	return data
+}
+
+func writeJWKS(t *testing.T, keys ...*signingKey) string {
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(path, jwksDocument(t, keys...), 0o600))
+	return path
+}
+
+func validClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "alice",
+		"scope": "users:read users:write",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func newTestVerifier(t *testing.T, keys ...*signingKey) *JWTVerifier {
+	keySet, err := NewKeySet(context.Background(), writeJWKS(t, keys...))
+	require.NoError(t, err)
+	return NewJWTVerifier(keySet, testIssuer, testAudience)
+}
+
+func TestJWTVerifier_Algorithms(t *testing.T) {
+	keys := []*signingKey{newRSAKey(t, "rsa"), newECKey(t, "ec"), newEd25519Key(t, "ed")}
+	verifier := newTestVerifier(t, keys...)
+
+	for _, key := range keys {
+		t.Run(key.method.Alg(), func(t *testing.T) {
+			principal, err := verifier.Authenticate(context.Background(), key.sign(t, validClaims()))
+			require.NoError(t, err)
+			assert.Equal(t, "alice", principal.Subject)
+			assert.Equal(t, testIssuer, principal.Issuer)
+			assert.Equal(t, []string{"users:read", "users:write"}, principal.Scopes)
+			assert.True(t, principal.HasScope("users:write"))
+			assert.WithinDuration(t, time.Now().Add(time.Hour), principal.ExpiresAt, time.Minute)
+		})
+	}
+}
+
+func TestJWTVerifier_Rejects(t *testing.T) {
+	key := newECKey(t, "ec")
+	other := newECKey(t, "ec")
+	verifier := newTestVerifier(t, key)
+
+	with := func(name string, value interface{}) jwt.MapClaims {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"wrong issuer", key.sign(t, with("iss", "https://other.example.com/"))},
+		{"wrong audience", key.sign(t, with("aud", "other-service"))},
+		{"expired", key.sign(t, with("exp", time.Now().Add(-time.Hour).Unix()))},
+		{"no expiry", key.sign(t, with("exp", nil))},
+		{"not yet valid", key.sign(t, with("nbf", time.Now().Add(time.Hour).Unix()))},
+		{"no subject", key.sign(t, with("sub", nil))},
+		{"wrong key", other.sign(t, validClaims())},
+		{"unknown kid", (&signingKey{kid: "unknown", method: key.method, key: key.key}).sign(t, validClaims())},
+		{"HS256", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+			token.Header["kid"] = "ec"
+			signed, err := token.SignedString([]byte("secret"))
+			require.NoError(t, err)
+			return signed
+		}()},
+		{"malformed", "not-a-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := verifier.Authenticate(context.Background(), tt.token)
+			assert.ErrorIs(t, err, ErrInvalidCredentials)
+		})
+	}
+}
+
+func TestJWTVerifier_AlgorithmBoundToKey(t *testing.T) {
+	rsaKey := newRSAKey(t, "rsa")
+	verifier := newTestVerifier(t, rsaKey)
+
+	// PS256 verifies with the same RSA public key, but the key was published for RS256 only
+	token := jwt.NewWithClaims(jwt.SigningMethodPS256, validClaims())
+	token.Header["kid"] = "rsa"
+	signed, err := token.SignedString(rsaKey.key)
+	require.NoError(t, err)
+
+	_, err = verifier.Authenticate(context.Background(), signed)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestJWTVerifier_NoKid(t *testing.T) {
+	key := newEd25519Key(t, "ed")
+	token := (&signingKey{method: key.method, key: key.key}).sign(t, validClaims())
+
+	_, err := newTestVerifier(t, key).Authenticate(context.Background(), token)
+	assert.NoError(t, err, "a single key verifies tokens without a kid")
+
+	_, err = newTestVerifier(t, key, newEd25519Key(t, "other")).Authenticate(context.Background(), token)
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+}
+
+func TestPrincipalContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	principal := &Principal{Subject: "alice"}
+	got, ok := FromContext(NewContext(context.Background(), principal))
+	assert.True(t, ok)
+	assert.Same(t, principal, got)
+}
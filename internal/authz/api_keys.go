@@ -0,0 +1,62 @@
+package authz
+
+import (
+	"context"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/google/uuid"
+)
+
+// apiKeyRepository authorizes the management of API keys before passing it on. Callers are
+// resolved to users through the unwrapped user repository.
+type apiKeyRepository struct {
+	keys  repository.APIKeyRepository
+	users repository.UserRepository
+}
+
+// NewAPIKeyRepository wraps keys so that creating, listing and revoking keys is authorized for the
+// principal in the context. Looking keys up and recording their use authenticate callers, so they
+// are not restricted.
+func NewAPIKeyRepository(keys repository.APIKeyRepository, users repository.UserRepository) repository.APIKeyRepository {
+	return &apiKeyRepository{keys: keys, users: users}
+}
+
+// authorize checks that the caller may manage API keys
+func (r *apiKeyRepository) authorize(ctx context.Context) error {
+	caller, err := resolveCaller(ctx, r.users)
+	if err != nil || caller == nil {
+		return err
+	}
+	return Authorize(caller, Request{Action: ManageAPIKeys})
+}
+
+func (r *apiKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	if err := r.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return r.keys.CreateAPIKey(ctx, key)
+}
+
+func (r *apiKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return r.keys.GetAPIKeyByPrefix(ctx, prefix)
+}
+
+func (r *apiKeyRepository) ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error) {
+	if err := r.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return r.keys.ListAPIKeys(ctx, req)
+}
+
+func (r *apiKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	if err := r.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return r.keys.RevokeAPIKey(ctx, id)
+}
+
+func (r *apiKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return r.keys.TouchAPIKey(ctx, id, usedAt)
+}
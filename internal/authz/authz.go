@@ -0,0 +1,103 @@
+// Package authz decides what callers may do with users.
+//
+// Users are authorized by the role on their own record: admins manage everyone, staff read users
+// and update users other than admins, and suppliers only read and update their own record, without
+// changing its role or activity. Only admins manage API keys. Services calling with API keys are
+// authorized by the users:read, users:write and api-keys:manage scopes of their key.
+//
+// The policy is enforced by the UserRepository returned by NewUserRepository, which every API
+// reads and writes users through, so that REST, GraphQL and gRPC requests are held to the same
+// rules, and by the APIKeyRepository returned by NewAPIKeyRepository. Denials wrap ErrForbidden.
+package authz
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+)
+
+// ErrForbidden is wrapped by the errors of actions the caller is not allowed to take
+var ErrForbidden = errors.New("forbidden")
+
+// Action is something a caller does with users
+type Action string
+
+const (
+	ReadUser    Action = "read user"    // Read the target user
+	ListUsers   Action = "list users"   // Read users not known in advance: lists, statistics, hierarchies
+	CreateUser  Action = "create user"  // Create a user with the given role
+	UpdateUser  Action = "update user"  // Change the target user, possibly its role and activity
+	ManageUsers Action = "manage users" // Delete, merge, erase and import users
+
+	ManageAPIKeys Action = "manage api keys" // Create, list and revoke API keys
+)
+
+// Caller is who a request acts for: a user or a service
+type Caller struct {
+	User      *models.User    // The caller's user record; nil for services
+	Principal *auth.Principal // The authenticated principal
+}
+
+// Request is an action a caller asks to take
+type Request struct {
+	Action Action
+	Target *models.User // The user read or updated; only its ID is set for reads
+	Role   *string      // The role a user is created with or updated to, if any
+	Active *bool        // The activity a user is updated to, if any
+}
+
+// Authorize returns an error wrapping ErrForbidden unless caller may take the action in req
+func Authorize(caller *Caller, req Request) error {
+	if !allowed(caller, req) {
+		return fmt.Errorf("%w: %s may not %s", ErrForbidden, caller, req.Action)
+	}
+	return nil
+}
+
+func allowed(caller *Caller, req Request) bool {
+	if caller.User == nil {
+		switch req.Action {
+		case ReadUser, ListUsers:
+			return caller.Principal.HasScope(auth.ScopeUsersRead)
+		case ManageAPIKeys:
+			return caller.Principal.HasScope(auth.ScopeAPIKeysManage)
+		default:
+			return caller.Principal.HasScope(auth.ScopeUsersWrite)
+		}
+	}
+
+	switch caller.User.Role {
+	case models.RoleAdmin:
+		return true
+	case models.RoleStaff:
+		switch req.Action {
+		case ReadUser, ListUsers:
+			return true
+		case UpdateUser:
+			// Staff cannot touch admins, nor make anyone one
+			return req.Target.Role != models.RoleAdmin && (req.Role == nil || *req.Role != models.RoleAdmin)
+		}
+	case models.RoleSupplier:
+		if req.Target == nil || req.Target.ID != caller.User.ID {
+			return false
+		}
+		switch req.Action {
+		case ReadUser:
+			return true
+		case UpdateUser:
+			// Setting the current values is not a change
+			return (req.Role == nil || *req.Role == req.Target.Role) && (req.Active == nil || *req.Active == req.Target.IsActive)
+		}
+	}
+	return false
+}
+
+// String names the caller in errors and logs
+func (c *Caller) String() string {
+	if c.User == nil {
+		return "service " + c.Principal.Subject
+	}
+	return c.User.Role + " " + c.User.ID.String()
+}
@@ -0,0 +1,330 @@
+package authz
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testUser(role string) *models.User {
+	return &models.User{ID: uuid.New(), Email: role + "@example.com", FullName: role, Role: role, IsActive: true}
+}
+
+func userCaller(user *models.User) *Caller {
+	return &Caller{User: user, Principal: &auth.Principal{Subject: user.ID.String()}}
+}
+
+func serviceCaller(scopes ...string) *Caller {
+	return &Caller{Principal: &auth.Principal{Subject: "erp-sync", Scopes: scopes, Service: true}}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+// TestAuthorize tests the policy for every kind of caller and action
+func TestAuthorize(t *testing.T) {
+	admin, staff, supplier := testUser(models.RoleAdmin), testUser(models.RoleStaff), testUser(models.RoleSupplier)
+	otherStaff, otherSupplier := testUser(models.RoleStaff), testUser(models.RoleSupplier)
+
+	callers := map[string]*Caller{
+		"admin":         userCaller(admin),
+		"staff":         userCaller(staff),
+		"supplier":      userCaller(supplier),
+		"service read":  serviceCaller(auth.ScopeUsersRead),
+		"service write": serviceCaller(auth.ScopeUsersWrite),
+		"service keys":  serviceCaller(auth.ScopeAPIKeysManage),
+	}
+
+	tests := []struct {
+		name    string
+		req     Request
+		allowed []string
+	}{
+		{"list users", Request{Action: ListUsers}, []string{"admin", "staff", "service read"}},
+		{"read other user", Request{Action: ReadUser, Target: &models.User{ID: otherSupplier.ID}}, []string{"admin", "staff", "service read"}},
+		{"read own record", Request{Action: ReadUser, Target: &models.User{ID: supplier.ID}}, []string{"admin", "staff", "supplier", "service read"}},
+		{"create supplier", Request{Action: CreateUser, Role: stringPtr(models.RoleSupplier)}, []string{"admin", "service write"}},
+		{"manage users", Request{Action: ManageUsers}, []string{"admin", "service write"}},
+		{"manage api keys", Request{Action: ManageAPIKeys}, []string{"admin", "service keys"}},
+		{"update admin", Request{Action: UpdateUser, Target: admin}, []string{"admin", "service write"}},
+		{"update other staff", Request{Action: UpdateUser, Target: otherStaff, Active: boolPtr(false)}, []string{"admin", "staff", "service write"}},
+		{"update other supplier", Request{Action: UpdateUser, Target: otherSupplier}, []string{"admin", "staff", "service write"}},
+		{"promote to admin", Request{Action: UpdateUser, Target: otherSupplier, Role: stringPtr(models.RoleAdmin)}, []string{"admin", "service write"}},
+		{"update own record", Request{Action: UpdateUser, Target: supplier}, []string{"admin", "staff", "supplier", "service write"}},
+		{"keep own role and activity", Request{Action: UpdateUser, Target: supplier, Role: stringPtr(models.RoleSupplier), Active: boolPtr(true)}, []string{"admin", "staff", "supplier", "service write"}},
+		{"change own role", Request{Action: UpdateUser, Target: supplier, Role: stringPtr(models.RoleStaff)}, []string{"admin", "staff", "service write"}},
+		{"deactivate own record", Request{Action: UpdateUser, Target: supplier, Active: boolPtr(false)}, []string{"admin", "staff", "service write"}},
+	}
+
+	for _, tt := range tests {
+		for name, caller := range callers {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				err := Authorize(caller, tt.req)
+				if contains(tt.allowed, name) {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, ErrForbidden)
+				}
+			})
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeUserRepository stores users in memory. Methods the tests do not need are left to the
+// embedded nil interface.
+type fakeUserRepository struct {
+	repository.UserRepository
+	users   map[uuid.UUID]*models.User
+	err     error
+	updated []uuid.UUID
+}
+
+func newFakeUserRepository(users ...*models.User) *fakeUserRepository {
+	r := &fakeUserRepository{users: make(map[uuid.UUID]*models.User)}
+	for _, user := range users {
+		r.users[user.ID] = user
+	}
+	return r
+}
+
+func (r *fakeUserRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	user, ok := r.users[id]
+	if !ok {
+		return nil, fmt.Errorf("user not found")
+	}
+	return user, nil
+}
+
+func (r *fakeUserRepository) GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, strings.TrimSpace(email)) {
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *fakeUserRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error) {
+	var users []models.User
+	for _, id := range ids {
+		if user, ok := r.users[id]; ok {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (r *fakeUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
+	r.updated = append(r.updated, id)
+	return r.users[id], nil
+}
+
+func (r *fakeUserRepository) ReplaceUser(ctx context.Context, id uuid.UUID, replacement *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error) {
+	r.updated = append(r.updated, id)
+	return r.users[id], nil
+}
+
+func (r *fakeUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	return &models.GetUsersResponse{}, nil
+}
+
+func contextFor(subject string, service bool) context.Context {
+	return auth.NewContext(context.Background(), &auth.Principal{Subject: subject, Service: service})
+}
+
+// TestUserRepository_Caller tests how principals are resolved to callers
+func TestUserRepository_Caller(t *testing.T) {
+	admin := testUser(models.RoleAdmin)
+	inactive := testUser(models.RoleAdmin)
+	inactive.IsActive = false
+	erasedAt := time.Now()
+	erased := testUser(models.RoleAdmin)
+	erased.ErasedAt = &erasedAt
+	users := NewUserRepository(newFakeUserRepository(admin, inactive, erased))
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{"no principal", context.Background(), nil},
+		{"active user", contextFor(admin.ID.String(), false), nil},
+		{"inactive user", contextFor(inactive.ID.String(), false), ErrForbidden},
+		{"erased user", contextFor(erased.ID.String(), false), ErrForbidden},
+		{"unknown user", contextFor(uuid.NewString(), false), ErrForbidden},
+		{"subject is not a user ID", contextFor("alice", false), ErrForbidden},
+		{"service without scope", contextFor("erp-sync", true), ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := users.GetAllUsers(tt.ctx, nil, nil, nil, nil)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestUserRepository_CallerLookupFails tests that failing to resolve the caller is not a denial
+func TestUserRepository_CallerLookupFails(t *testing.T) {
+	fake := newFakeUserRepository()
+	fake.err = errors.New("connection refused")
+
+	_, err := NewUserRepository(fake).GetAllUsers(contextFor(uuid.NewString(), false), nil, nil, nil, nil)
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, ErrForbidden)
+}
+
+// TestUserRepository_Updates tests that updates are checked against the target's stored record
+func TestUserRepository_Updates(t *testing.T) {
+	admin, staff, supplier := testUser(models.RoleAdmin), testUser(models.RoleStaff), testUser(models.RoleSupplier)
+	fake := newFakeUserRepository(admin, staff, supplier)
+	users := NewUserRepository(fake)
+	staffCtx := contextFor(staff.ID.String(), false)
+	supplierCtx := contextFor(supplier.ID.String(), false)
+
+	_, err := users.UpdateUser(staffCtx, admin.ID, &models.UpdateUserRequest{FullName: stringPtr("Renamed")})
+	assert.ErrorIs(t, err, ErrForbidden, "staff cannot update admins")
+
+	_, err = users.UpdateUser(staffCtx, supplier.ID, &models.UpdateUserRequest{FullName: stringPtr("Renamed")})
+	assert.NoError(t, err)
+
+	_, err = users.UpdateUser(staffCtx, uuid.New(), &models.UpdateUserRequest{FullName: stringPtr("Renamed")})
+	assert.ErrorContains(t, err, "user not found", "staff learn that the target does not exist")
+
+	_, err = users.UpdateUser(supplierCtx, staff.ID, &models.UpdateUserRequest{FullName: stringPtr("Renamed")})
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	unchanged := &models.ReplaceUserRequest{Email: supplier.Email, FullName: "Renamed", Role: models.RoleSupplier, IsActive: boolPtr(true)}
+	_, err = users.ReplaceUser(supplierCtx, supplier.ID, unchanged, nil)
+	assert.NoError(t, err)
+
+	deactivated := &models.ReplaceUserRequest{Email: supplier.Email, FullName: "Renamed", Role: models.RoleSupplier, IsActive: boolPtr(false)}
+	_, err = users.ReplaceUser(supplierCtx, supplier.ID, deactivated, nil)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	assert.Equal(t, []uuid.UUID{supplier.ID, supplier.ID}, fake.updated)
+}
+
+// TestUserRepository_GetUserByEmail tests that suppliers can only look up their own email
+func TestUserRepository_GetUserByEmail(t *testing.T) {
+	staff, supplier := testUser(models.RoleStaff), testUser(models.RoleSupplier)
+	users := NewUserRepository(newFakeUserRepository(staff, supplier))
+	supplierCtx := contextFor(supplier.ID.String(), false)
+
+	found, err := users.GetUserByEmail(supplierCtx, " SUPPLIER@example.com", nil)
+	require.NoError(t, err, "emails match ignoring case and surrounding space")
+	assert.Equal(t, supplier.ID, found.ID)
+
+	_, err = users.GetUserByEmail(supplierCtx, staff.Email, nil)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	_, err = users.GetUserByEmail(contextFor(staff.ID.String(), false), supplier.Email, nil)
+	assert.NoError(t, err)
+}
+
+// TestUserRepository_GetUsersByIDs tests that users the caller may not read are left out of a
+// batch instead of failing it
+func TestUserRepository_GetUsersByIDs(t *testing.T) {
+	staff, supplier := testUser(models.RoleStaff), testUser(models.RoleSupplier)
+	users := NewUserRepository(newFakeUserRepository(staff, supplier))
+	supplierCtx := contextFor(supplier.ID.String(), false)
+
+	found, err := users.GetUsersByIDs(supplierCtx, []uuid.UUID{staff.ID, supplier.ID}, nil)
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, supplier.ID, found[0].ID)
+
+	_, err = users.GetUsersByIDs(supplierCtx, []uuid.UUID{staff.ID}, nil)
+	assert.ErrorIs(t, err, ErrForbidden, "a batch the caller may read nothing of is denied")
+
+	found, err = users.GetUsersByIDs(contextFor(staff.ID.String(), false), []uuid.UUID{staff.ID, supplier.ID}, nil)
+	require.NoError(t, err)
+	assert.Len(t, found, 2)
+}
+
+// fakeAPIKeyRepository records the keys it lists. Methods the tests do not need are left to the
+// embedded nil interface.
+type fakeAPIKeyRepository struct {
+	repository.APIKeyRepository
+	listed int
+}
+
+func (r *fakeAPIKeyRepository) ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error) {
+	r.listed++
+	return nil, nil
+}
+
+func (r *fakeAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return &models.APIKey{Prefix: prefix}, nil
+}
+
+// TestAPIKeyRepository tests that only admins and services with the api-keys:manage scope manage
+// keys, whatever scopes a user's token carries
+func TestAPIKeyRepository(t *testing.T) {
+	admin, staff := testUser(models.RoleAdmin), testUser(models.RoleStaff)
+	fake := &fakeAPIKeyRepository{}
+	keys := NewAPIKeyRepository(fake, newFakeUserRepository(admin, staff))
+
+	scoped := func(subject string, service bool) context.Context {
+		return auth.NewContext(context.Background(), &auth.Principal{Subject: subject, Scopes: []string{auth.ScopeAPIKeysManage}, Service: service})
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		wantErr error
+	}{
+		{"no principal", context.Background(), nil},
+		{"admin", contextFor(admin.ID.String(), false), nil},
+		{"staff with the scope", scoped(staff.ID.String(), false), ErrForbidden},
+		{"service with the scope", scoped("ops", true), nil},
+		{"service without the scope", contextFor("erp-sync", true), ErrForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := keys.ListAPIKeys(tt.ctx, &models.ListAPIKeysRequest{})
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+	assert.Equal(t, 3, fake.listed)
+
+	key, err := keys.GetAPIKeyByPrefix(contextFor("erp-sync", true), "gcu_0123456789ab")
+	require.NoError(t, err, "keys are looked up to authenticate callers")
+	assert.Equal(t, "gcu_0123456789ab", key.Prefix)
+}
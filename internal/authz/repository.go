@@ -0,0 +1,230 @@
+package authz
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/google/uuid"
+)
+
+// userRepository authorizes every call before passing it on. It implements each method rather
+// than embedding the repository, so that a method added to the interface cannot skip the policy.
+type userRepository struct {
+	users repository.UserRepository
+}
+
+// NewUserRepository wraps users so that every call is authorized for the principal in its
+// context. Calls without a principal, made when authentication is disabled, are not restricted.
+func NewUserRepository(users repository.UserRepository) repository.UserRepository {
+	return &userRepository{users: users}
+}
+
+func (r *userRepository) caller(ctx context.Context) (*Caller, error) {
+	return resolveCaller(ctx, r.users)
+}
+
+// resolveCaller resolves the principal in ctx to the caller, or nil if there is none. Principals
+// that are not services must be the ID of an active user, which has neither been merged nor erased.
+func resolveCaller(ctx context.Context, users repository.UserRepository) (*Caller, error) {
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		return nil, nil
+	}
+	if principal.Service {
+		return &Caller{Principal: principal}, nil
+	}
+
+	id, err := uuid.Parse(principal.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject %q is not a user ID", ErrForbidden, principal.Subject)
+	}
+	user, err := users.GetUserByID(ctx, id, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			return nil, fmt.Errorf("%w: subject %s is not a user", ErrForbidden, id)
+		}
+		return nil, fmt.Errorf("failed to resolve caller: %w", err)
+	}
+	if !user.IsActive || user.MergedInto != nil || user.ErasedAt != nil {
+		return nil, fmt.Errorf("%w: user %s is not active", ErrForbidden, id)
+	}
+	return &Caller{User: user, Principal: principal}, nil
+}
+
+// authorize checks req for the caller. Updates are checked against the target's stored record,
+// which is only loaded when the decision depends on it.
+func (r *userRepository) authorize(ctx context.Context, req Request) error {
+	caller, err := r.caller(ctx)
+	if err != nil || caller == nil {
+		return err
+	}
+
+	if req.Action == UpdateUser && caller.User != nil {
+		switch {
+		case req.Target.ID == caller.User.ID:
+			req.Target = caller.User
+		case caller.User.Role == models.RoleStaff:
+			if req.Target, err = r.users.GetUserByID(ctx, req.Target.ID, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return Authorize(caller, req)
+}
+
+func (r *userRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	if err := r.authorize(ctx, Request{Action: CreateUser, Role: &user.Role}); err != nil {
+		return nil, err
+	}
+	return r.users.CreateUser(ctx, user)
+}
+
+func (r *userRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	if err := r.authorize(ctx, Request{Action: ReadUser, Target: &models.User{ID: id}}); err != nil {
+		return nil, err
+	}
+	return r.users.GetUserByID(ctx, id, fields)
+}
+
+// GetUserByEmail lets callers look themselves up by email; other users are only found by
+// callers who may read anyone
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error) {
+	caller, err := r.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller != nil {
+		target := &models.User{}
+		if caller.User != nil && strings.EqualFold(strings.TrimSpace(email), caller.User.Email) {
+			target = caller.User
+		}
+		if err := Authorize(caller, Request{Action: ReadUser, Target: target}); err != nil {
+			return nil, err
+		}
+	}
+	return r.users.GetUserByEmail(ctx, email, fields)
+}
+
+// GetUsersByIDs reads the users the caller may read and leaves the others out, as if they did not
+// exist, so that one forbidden ID does not fail the batch. Batches of only forbidden IDs are denied.
+func (r *userRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error) {
+	caller, err := r.caller(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if caller != nil {
+		allowed := make([]uuid.UUID, 0, len(ids))
+		var denied error
+		for _, id := range ids {
+			if err := Authorize(caller, Request{Action: ReadUser, Target: &models.User{ID: id}}); err != nil {
+				denied = err
+				continue
+			}
+			allowed = append(allowed, id)
+		}
+		if len(allowed) == 0 && denied != nil {
+			return nil, denied
+		}
+		ids = allowed
+	}
+	return r.users.GetUsersByIDs(ctx, ids, fields)
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
+	req := Request{Action: UpdateUser, Target: &models.User{ID: id}, Role: updates.Role, Active: updates.IsActive}
+	if err := r.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	return r.users.UpdateUser(ctx, id, updates)
+}
+
+func (r *userRepository) ReplaceUser(ctx context.Context, id uuid.UUID, replacement *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error) {
+	req := Request{Action: UpdateUser, Target: &models.User{ID: id}, Role: &replacement.Role, Active: replacement.IsActive}
+	if err := r.authorize(ctx, req); err != nil {
+		return nil, err
+	}
+	return r.users.ReplaceUser(ctx, id, replacement, unmodifiedSince)
+}
+
+func (r *userRepository) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	if err := r.authorize(ctx, Request{Action: ManageUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.DeleteUser(ctx, id)
+}
+
+func (r *userRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	if err := r.authorize(ctx, Request{Action: ListUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.GetAllUsers(ctx, filters, sort, pagination, fields)
+}
+
+func (r *userRepository) GetDirectReports(ctx context.Context, managerID uuid.UUID) ([]models.User, error) {
+	if err := r.authorize(ctx, Request{Action: ListUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.GetDirectReports(ctx, managerID)
+}
+
+func (r *userRepository) GetSubordinates(ctx context.Context, managerID uuid.UUID, maxDepth int) ([]models.UserHierarchyEntry, error) {
+	if err := r.authorize(ctx, Request{Action: ListUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.GetSubordinates(ctx, managerID, maxDepth)
+}
+
+func (r *userRepository) GetManagementChain(ctx context.Context, id uuid.UUID) ([]models.UserHierarchyEntry, error) {
+	if err := r.authorize(ctx, Request{Action: ListUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.GetManagementChain(ctx, id)
+}
+
+func (r *userRepository) MergeUsers(ctx context.Context, sourceID uuid.UUID, req *models.MergeUsersRequest) (*models.MergeUsersResponse, error) {
+	if err := r.authorize(ctx, Request{Action: ManageUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.MergeUsers(ctx, sourceID, req)
+}
+
+func (r *userRepository) EraseUser(ctx context.Context, id uuid.UUID) (*models.ErasureResult, error) {
+	if err := r.authorize(ctx, Request{Action: ManageUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.EraseUser(ctx, id)
+}
+
+// ExportUserData is a read of the user, so suppliers can export their own data
+func (r *userRepository) ExportUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error) {
+	if err := r.authorize(ctx, Request{Action: ReadUser, Target: &models.User{ID: id}}); err != nil {
+		return nil, err
+	}
+	return r.users.ExportUserData(ctx, id)
+}
+
+func (r *userRepository) StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error {
+	if err := r.authorize(ctx, Request{Action: ListUsers}); err != nil {
+		return err
+	}
+	return r.users.StreamUsers(ctx, filters, sort, fn)
+}
+
+func (r *userRepository) ImportUsers(ctx context.Context, rows []models.ImportRow, upsert bool, dryRun bool) (*models.ImportResult, error) {
+	if err := r.authorize(ctx, Request{Action: ManageUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.ImportUsers(ctx, rows, upsert, dryRun)
+}
+
+func (r *userRepository) GetUserStats(ctx context.Context, filters *models.FilterParams, params *models.StatsParams) (*models.UserStats, error) {
+	if err := r.authorize(ctx, Request{Action: ListUsers}); err != nil {
+		return nil, err
+	}
+	return r.users.GetUserStats(ctx, filters, params)
+}
@@ -4,13 +4,16 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config holds all application configurations
 type Config struct {
-	Port string
+	Port     string
+	GRPCPort string
 
 	DBHost     string
 	DBPort     string
@@ -18,6 +21,18 @@ type Config struct {
 	DBPassword string
 	DBName     string
 	DBSSLMode  string
+
+	ErasureReceiptSecret string
+
+	StatsCacheTTL time.Duration
+
+	IdempotencyKeyTTL time.Duration
+
+	AuthDisabled            bool
+	AuthJWKS                string // Path or URL of the token issuer's key set
+	AuthIssuer              string
+	AuthAudience            string
+	AuthJWKSRefreshInterval time.Duration
 }
 
 // LoadConfig loads environment variables into the Config struct
@@ -29,12 +44,57 @@ func LoadConfig() (*Config, error) {
 
 	cfg := &Config{
 		Port:       getEnv("PORT", "3000"),
+		GRPCPort:   getEnv("GRPC_PORT", "9090"),
 		DBHost:     getEnv("DB_HOST", "localhost"),
 		DBPort:     getEnv("DB_PORT", "5432"),
 		DBUser:     getEnv("DB_USER", "postgre"),
 		DBPassword: getEnv("DB_PASSWORD", "postgre"),
 		DBName:     getEnv("DB_NAME", "user-database"),
 		DBSSLMode:  getEnv("DB_SSLMODE", "disable"),
+
+		ErasureReceiptSecret: getEnv("ERASURE_RECEIPT_SECRET", ""),
+
+		AuthJWKS:     getEnv("AUTH_JWKS", ""),
+		AuthIssuer:   getEnv("AUTH_ISSUER", ""),
+		AuthAudience: getEnv("AUTH_AUDIENCE", ""),
+	}
+
+	cfg.StatsCacheTTL, err = time.ParseDuration(getEnv("STATS_CACHE_TTL", "1m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid STATS_CACHE_TTL: %w", err)
+	}
+	// Zero disables the cache
+	if cfg.StatsCacheTTL < 0 {
+		return nil, fmt.Errorf("invalid STATS_CACHE_TTL: must not be negative")
+	}
+
+	cfg.IdempotencyKeyTTL, err = time.ParseDuration(getEnv("IDEMPOTENCY_KEY_TTL", "24h"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_KEY_TTL: %w", err)
+	}
+	if cfg.IdempotencyKeyTTL <= 0 {
+		return nil, fmt.Errorf("invalid IDEMPOTENCY_KEY_TTL: must be greater than zero")
+	}
+
+	cfg.AuthDisabled, err = strconv.ParseBool(getEnv("AUTH_DISABLED", "false"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_DISABLED: %w", err)
+	}
+
+	cfg.AuthJWKSRefreshInterval, err = time.ParseDuration(getEnv("AUTH_JWKS_REFRESH_INTERVAL", "15m"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid AUTH_JWKS_REFRESH_INTERVAL: %w", err)
+	}
+
+	if !cfg.AuthDisabled {
+		switch "" {
+		case cfg.AuthJWKS:
+			return nil, fmt.Errorf("AUTH_JWKS is required unless AUTH_DISABLED is set")
+		case cfg.AuthIssuer:
+			return nil, fmt.Errorf("AUTH_ISSUER is required unless AUTH_DISABLED is set")
+		case cfg.AuthAudience:
+			return nil, fmt.Errorf("AUTH_AUDIENCE is required unless AUTH_DISABLED is set")
+		}
 	}
 
 	return cfg, nil
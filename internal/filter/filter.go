@@ -0,0 +1,202 @@
+// Package filter implements an RSQL/FIQL-style filter expression language.
+//
+// An expression such as role==supplier;(email=like=*@acme.com,created_at=ge=2026-01-01)
+// is parsed into an AST, type-checked against a Schema, and then either compiled into
+// parameterised SQL or evaluated directly against in-memory records.
+package filter
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Comparison operators
+const (
+	OpEqual        = "=="
+	OpNotEqual     = "!="
+	OpLess         = "=lt="
+	OpLessEqual    = "=le="
+	OpGreater      = "=gt="
+	OpGreaterEqual = "=ge="
+	OpIn           = "=in="
+	OpOut          = "=out="
+	OpLike         = "=like="
+	OpIsNull       = "=isnull="
+)
+
+// Node is a node of a parsed filter expression
+type Node interface {
+	node()
+}
+
+// And matches when every operand matches
+type And struct {
+	Operands []Node `json:"and"`
+}
+
+// Or matches when at least one operand matches
+type Or struct {
+	Operands []Node `json:"or"`
+}
+
+// Comparison compares a field against one or more arguments
+type Comparison struct {
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Args     []string `json:"args"`
+}
+
+func (And) node()        {}
+func (Or) node()         {}
+func (Comparison) node() {}
+
+// Type is the type of a filterable field
+type Type int
+
+// Field types
+const (
+	String Type = iota
+	UUID
+	Bool
+	Time
+)
+
+// Field describes a filterable field
+type Field struct {
+	Type     Type
+	Nullable bool
+}
+
+// Schema maps field names to their description. Field names double as column names in SQL.
+type Schema map[string]Field
+
+// Check verifies that every comparison in the expression refers to a known field
+// with an operator and arguments valid for its type
+func Check(node Node, schema Schema) error {
+	switch n := node.(type) {
+	case And:
+		for _, operand := range n.Operands {
+			if err := Check(operand, schema); err != nil {
+				return err
+			}
+		}
+	case Or:
+		for _, operand := range n.Operands {
+			if err := Check(operand, schema); err != nil {
+				return err
+			}
+		}
+	case Comparison:
+		_, _, err := schema.values(n)
+		return err
+	default:
+		return fmt.Errorf("invalid filter: unexpected node %T", node)
+	}
+	return nil
+}
+
+// values type-checks a comparison and converts its arguments to typed values
+func (s Schema) values(c Comparison) (Field, []interface{}, error) {
+	field, ok := s[c.Field]
+	if !ok {
+		return field, nil, fmt.Errorf("invalid filter: unknown field %q", c.Field)
+	}
+
+	switch c.Operator {
+	case OpEqual, OpNotEqual:
+	case OpLess, OpLessEqual, OpGreater, OpGreaterEqual:
+		if field.Type == Bool {
+			return field, nil, fmt.Errorf("invalid filter: %s cannot be used with %s", c.Operator, c.Field)
+		}
+	case OpLike:
+		if field.Type != String {
+			return field, nil, fmt.Errorf("invalid filter: %s can only be used with text fields, not %s", c.Operator, c.Field)
+		}
+	case OpIsNull:
+		if !field.Nullable {
+			return field, nil, fmt.Errorf("invalid filter: %s is never null", c.Field)
+		}
+	case OpIn, OpOut:
+	default:
+		return field, nil, fmt.Errorf("invalid filter: unknown operator %q", c.Operator)
+	}
+
+	if c.Operator == OpIn || c.Operator == OpOut {
+		if len(c.Args) == 0 {
+			return field, nil, fmt.Errorf("invalid filter: %s on %s needs at least one value", c.Operator, c.Field)
+		}
+	} else if len(c.Args) != 1 {
+		return field, nil, fmt.Errorf("invalid filter: %s on %s takes exactly one value", c.Operator, c.Field)
+	}
+
+	valueType := field.Type
+	if c.Operator == OpIsNull {
+		valueType = Bool
+	}
+
+	values := make([]interface{}, len(c.Args))
+	for i, arg := range c.Args {
+		value, err := convert(valueType, arg)
+		if err != nil {
+			return field, nil, fmt.Errorf("invalid filter: invalid value %q for %s: %w", arg, c.Field, err)
+		}
+		values[i] = value
+	}
+
+	return field, values, nil
+}
+
+// convert parses an argument into a value of the given type
+func convert(t Type, arg string) (interface{}, error) {
+	switch t {
+	case UUID:
+		return uuid.Parse(arg)
+	case Bool:
+		return strconv.ParseBool(arg)
+	case Time:
+		if parsed, err := time.Parse(time.RFC3339, arg); err == nil {
+			return parsed, nil
+		}
+		parsed, err := time.Parse("2006-01-02", arg)
+		if err != nil {
+			return nil, fmt.Errorf("expected YYYY-MM-DD or RFC 3339 timestamp")
+		}
+		return parsed, nil
+	}
+	return arg, nil
+}
+
+// InLocation returns a copy of the expression in which plain YYYY-MM-DD dates compared
+// against time fields are replaced by RFC 3339 timestamps at midnight in loc
+func InLocation(node Node, schema Schema, loc *time.Location) Node {
+	switch n := node.(type) {
+	case And:
+		operands := make([]Node, len(n.Operands))
+		for i, operand := range n.Operands {
+			operands[i] = InLocation(operand, schema, loc)
+		}
+		return And{Operands: operands}
+	case Or:
+		operands := make([]Node, len(n.Operands))
+		for i, operand := range n.Operands {
+			operands[i] = InLocation(operand, schema, loc)
+		}
+		return Or{Operands: operands}
+	case Comparison:
+		if schema[n.Field].Type != Time || n.Operator == OpIsNull {
+			return n
+		}
+		args := make([]string, len(n.Args))
+		for i, arg := range n.Args {
+			args[i] = arg
+			if date, err := time.ParseInLocation("2006-01-02", arg, loc); err == nil {
+				args[i] = date.Format(time.RFC3339)
+			}
+		}
+		return Comparison{Field: n.Field, Operator: n.Operator, Args: args}
+	}
+	return node
+}
@@ -0,0 +1,248 @@
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSchema = Schema{
+	"id":         {Type: UUID},
+	"email":      {Type: String},
+	"phone":      {Type: String, Nullable: true},
+	"role":       {Type: String},
+	"is_active":  {Type: Bool},
+	"manager_id": {Type: UUID, Nullable: true},
+	"created_at": {Type: Time},
+}
+
+// TestParse tests that expressions are parsed into the expected AST
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected Node
+	}{
+		{
+			name:     "Comparison",
+			input:    "role==supplier",
+			expected: Comparison{Field: "role", Operator: OpEqual, Args: []string{"supplier"}},
+		},
+		{
+			name:  "AndBindsTighterThanOr",
+			input: "role==admin;is_active==true,role==staff",
+			expected: Or{Operands: []Node{
+				And{Operands: []Node{
+					Comparison{Field: "role", Operator: OpEqual, Args: []string{"admin"}},
+					Comparison{Field: "is_active", Operator: OpEqual, Args: []string{"true"}},
+				}},
+				Comparison{Field: "role", Operator: OpEqual, Args: []string{"staff"}},
+			}},
+		},
+		{
+			name:  "Grouping",
+			input: "role==supplier;(email=like=*@acme.com,created_at=ge=2026-01-01)",
+			expected: And{Operands: []Node{
+				Comparison{Field: "role", Operator: OpEqual, Args: []string{"supplier"}},
+				Or{Operands: []Node{
+					Comparison{Field: "email", Operator: OpLike, Args: []string{"*@acme.com"}},
+					Comparison{Field: "created_at", Operator: OpGreaterEqual, Args: []string{"2026-01-01"}},
+				}},
+			}},
+		},
+		{
+			name:     "ValueList",
+			input:    "role=out=(admin, staff)",
+			expected: Comparison{Field: "role", Operator: OpOut, Args: []string{"admin", "staff"}},
+		},
+		{
+			name:     "QuotedValue",
+			input:    `email=="o'neil@example.com";phone!='+62 (21) \'x\''`,
+			expected: And{Operands: []Node{Comparison{Field: "email", Operator: OpEqual, Args: []string{"o'neil@example.com"}}, Comparison{Field: "phone", Operator: OpNotEqual, Args: []string{"+62 (21) 'x'"}}}},
+		},
+		{
+			name:     "ShortOperator",
+			input:    "created_at<=2026-01-01T00:00:00Z",
+			expected: Comparison{Field: "created_at", Operator: OpLessEqual, Args: []string{"2026-01-01T00:00:00Z"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, node)
+		})
+	}
+}
+
+// TestParse_Errors tests that malformed expressions are rejected
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Empty", ""},
+		{"MissingOperator", "role"},
+		{"MissingValue", "role=="},
+		{"UnclosedGroup", "(role==admin"},
+		{"UnclosedList", "role=in=(admin,staff"},
+		{"UnterminatedQuote", "email=='abc"},
+		{"TrailingInput", "role==admin)"},
+		{"DanglingSeparator", "role==admin;"},
+		{"TooDeep", "((((((((((((((((((((((((((((((((((role==admin))))))))))))))))))))))))))))))))))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "invalid filter")
+		})
+	}
+}
+
+// TestCheck_Errors tests that expressions are type-checked against the schema
+func TestCheck_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		errMsg string
+	}{
+		{"UnknownField", "password==x", `unknown field "password"`},
+		{"UnknownOperator", "role=regex=.*", `unknown operator "=regex="`},
+		{"LikeOnUUID", "id=like=abc*", "can only be used with text fields"},
+		{"OrderingOnBool", "is_active=gt=false", "cannot be used with is_active"},
+		{"IsNullOnRequiredField", "email=isnull=true", "email is never null"},
+		{"InvalidUUID", "manager_id==nope", `invalid value "nope" for manager_id`},
+		{"InvalidTime", "created_at=ge=yesterday", `invalid value "yesterday" for created_at`},
+		{"ListForSingleValue", "role==(admin,staff)", "takes exactly one value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			require.NoError(t, err)
+
+			err = Check(node, testSchema)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+// TestToSQL tests compilation into parameterised SQL
+func TestToSQL(t *testing.T) {
+	managerID := uuid.New()
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		input        string
+		argCount     int
+		expectedSQL  string
+		expectedArgs []interface{}
+	}{
+		{
+			name:         "Grouping",
+			input:        "role==supplier;(email=like=*@acme.com,created_at=ge=2026-01-01)",
+			argCount:     2,
+			expectedSQL:  "(role = $3 AND (email ILIKE $4 OR created_at >= $5))",
+			expectedArgs: []interface{}{"supplier", "%@acme.com", from},
+		},
+		{
+			name:         "LikeEscapesMetacharacters",
+			input:        `email=like=100%_off*`,
+			expectedSQL:  "email ILIKE $1",
+			expectedArgs: []interface{}{`100\%\_off%`},
+		},
+		{
+			name:         "NegationKeepsNulls",
+			input:        "manager_id!=" + managerID.String() + ";phone=out=(1,2);role!=admin",
+			expectedSQL:  "((manager_id IS NULL OR manager_id <> $1) AND (phone IS NULL OR phone NOT IN ($2, $3)) AND role <> $4)",
+			expectedArgs: []interface{}{managerID, "1", "2", "admin"},
+		},
+		{
+			name:         "IsNull",
+			input:        "phone=isnull=true,manager_id=isnull=false",
+			expectedSQL:  "(phone IS NULL OR manager_id IS NOT NULL)",
+			expectedArgs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			require.NoError(t, err)
+
+			sql, args, err := ToSQL(node, testSchema, tt.argCount)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedSQL, sql)
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+// TestMatch tests in-memory evaluation of expressions
+func TestMatch(t *testing.T) {
+	managerID := uuid.New()
+	phone := "555-0100"
+	record := map[string]interface{}{
+		"id":         uuid.New(),
+		"email":      "Buyer@Acme.com",
+		"phone":      &phone,
+		"role":       "supplier",
+		"is_active":  true,
+		"manager_id": (*uuid.UUID)(nil),
+		"created_at": time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	lookup := func(field string) interface{} { return record[field] }
+
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"role==supplier;(email=like=*@acme.com,created_at=ge=2026-01-01)", true},
+		{"role==supplier;email=like=*@example.com", false},
+		{"email=like=buyer*", true},
+		{"role=in=(admin,staff)", false},
+		{"role=out=(admin,staff)", true},
+		{"created_at=lt=2026-03-01T12:00:00Z", false},
+		{"created_at=le=2026-03-01T12:00:00Z", true},
+		{"manager_id=isnull=true", true},
+		{"manager_id==" + managerID.String(), false},
+		{"manager_id!=" + managerID.String(), true},
+		{"phone==555-0100;is_active==true", true},
+		{"phone=gt=999", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			require.NoError(t, err)
+
+			matched, err := Match(node, testSchema, lookup)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matched)
+		})
+	}
+}
+
+// TestInLocation tests that plain dates on time fields are resolved in the given time zone
+func TestInLocation(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+
+	node, err := Parse("created_at=ge=2026-01-01;created_at=lt=2026-01-02T00:00:00Z;role==2026-01-01")
+	require.NoError(t, err)
+
+	expected := And{Operands: []Node{
+		Comparison{Field: "created_at", Operator: OpGreaterEqual, Args: []string{"2026-01-01T00:00:00+07:00"}},
+		Comparison{Field: "created_at", Operator: OpLess, Args: []string{"2026-01-02T00:00:00Z"}},
+		Comparison{Field: "role", Operator: OpEqual, Args: []string{"2026-01-01"}},
+	}}
+	assert.Equal(t, expected, InLocation(node, testSchema, jakarta))
+}
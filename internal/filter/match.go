@@ -0,0 +1,148 @@
+package filter
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Lookup returns the value of a field of the record being matched. Pointer values
+// are dereferenced, and nil pointers or nil count as NULL.
+type Lookup func(field string) interface{}
+
+// Match evaluates an expression against a single record, with the same semantics as
+// the SQL produced by ToSQL. It lets repositories that are not backed by SQL filter records.
+func Match(node Node, schema Schema, lookup Lookup) (bool, error) {
+	switch n := node.(type) {
+	case And:
+		for _, operand := range n.Operands {
+			matched, err := Match(operand, schema, lookup)
+			if err != nil || !matched {
+				return false, err
+			}
+		}
+		return true, nil
+	case Or:
+		for _, operand := range n.Operands {
+			matched, err := Match(operand, schema, lookup)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	case Comparison:
+		return matchComparison(n, schema, lookup)
+	}
+	return false, fmt.Errorf("invalid filter: unexpected node %T", node)
+}
+
+func matchComparison(n Comparison, schema Schema, lookup Lookup) (bool, error) {
+	_, values, err := schema.values(n)
+	if err != nil {
+		return false, err
+	}
+
+	value := deref(lookup(n.Field))
+	isNull := value == nil
+
+	switch n.Operator {
+	case OpIsNull:
+		return isNull == values[0].(bool), nil
+	case OpNotEqual:
+		return isNull || compare(value, values[0]) != 0, nil
+	case OpOut:
+		return isNull || !containsValue(value, values), nil
+	}
+
+	// Every other comparison with NULL is false, as in SQL
+	if isNull {
+		return false, nil
+	}
+
+	switch n.Operator {
+	case OpEqual:
+		return compare(value, values[0]) == 0, nil
+	case OpLess:
+		return compare(value, values[0]) < 0, nil
+	case OpLessEqual:
+		return compare(value, values[0]) <= 0, nil
+	case OpGreater:
+		return compare(value, values[0]) > 0, nil
+	case OpGreaterEqual:
+		return compare(value, values[0]) >= 0, nil
+	case OpIn:
+		return containsValue(value, values), nil
+	case OpLike:
+		text, _ := value.(string)
+		return wildcardMatch(strings.ToLower(text), strings.ToLower(values[0].(string))), nil
+	}
+	return false, fmt.Errorf("invalid filter: unknown operator %q", n.Operator)
+}
+
+// deref follows pointers, returning nil for nil pointers
+func deref(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	for v.IsValid() && v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	return v.Interface()
+}
+
+// compare orders two values of the same field type, returning -1, 0 or 1
+func compare(a, b interface{}) int {
+	switch x := a.(type) {
+	case string:
+		return strings.Compare(x, b.(string))
+	case uuid.UUID:
+		return strings.Compare(x.String(), b.(uuid.UUID).String())
+	case bool:
+		if x == b.(bool) {
+			return 0
+		}
+		if !x {
+			return -1
+		}
+		return 1
+	case time.Time:
+		return x.Compare(b.(time.Time))
+	}
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func containsValue(value interface{}, values []interface{}) bool {
+	for _, candidate := range values {
+		if compare(value, candidate) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch reports whether text matches pattern, where "*" matches any run of characters
+func wildcardMatch(text, pattern string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return text == pattern
+	}
+	if !strings.HasPrefix(text, parts[0]) {
+		return false
+	}
+	text = text[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(text, part)
+		if i < 0 {
+			return false
+		}
+		text = text[i+len(part):]
+	}
+	return strings.HasSuffix(text, parts[len(parts)-1])
+}
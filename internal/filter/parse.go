@@ -0,0 +1,247 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits on the size of expressions accepted by Parse
+const (
+	maxExpressionLength = 4096
+	maxNestingDepth     = 32
+)
+
+// operatorAliases maps the short comparison forms to their FIQL operators
+var operatorAliases = map[string]string{
+	"<":  OpLess,
+	"<=": OpLessEqual,
+	">":  OpGreater,
+	">=": OpGreaterEqual,
+}
+
+// Parse parses a filter expression. ";" binds tighter than ",", and parentheses group.
+//
+//	or         = and { "," and }
+//	and        = term { ";" term }
+//	term       = "(" or ")" | comparison
+//	comparison = field operator ( value | "(" value { "," value } ")" )
+func Parse(input string) (Node, error) {
+	if len(input) > maxExpressionLength {
+		return nil, fmt.Errorf("invalid filter: expression longer than %d characters", maxExpressionLength)
+	}
+
+	p := &parser{input: input}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.pos < len(p.input) {
+		return nil, p.errorf("unexpected %q", p.input[p.pos])
+	}
+
+	return node, nil
+}
+
+// parser is a recursive descent parser over a filter expression
+type parser struct {
+	input string
+	pos   int
+	depth int
+}
+
+func (p *parser) parseOr() (Node, error) {
+	var operands []Node
+	for {
+		operand, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		if !p.consume(',') {
+			break
+		}
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return Or{Operands: operands}, nil
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	var operands []Node
+	for {
+		operand, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, operand)
+		if !p.consume(';') {
+			break
+		}
+	}
+	if len(operands) == 1 {
+		return operands[0], nil
+	}
+	return And{Operands: operands}, nil
+}
+
+func (p *parser) parseTerm() (Node, error) {
+	if !p.consume('(') {
+		return p.parseComparison()
+	}
+
+	p.depth++
+	if p.depth > maxNestingDepth {
+		return nil, p.errorf("groups nested deeper than %d levels", maxNestingDepth)
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if !p.consume(')') {
+		return nil, p.errorf("expected \")\"")
+	}
+	p.depth--
+
+	return node, nil
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	p.skipSpace()
+	start := p.pos
+	for p.pos < len(p.input) && isFieldChar(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return nil, p.errorf("expected field name")
+	}
+	field := p.input[start:p.pos]
+
+	operator, err := p.parseOperator()
+	if err != nil {
+		return nil, err
+	}
+
+	var args []string
+	if p.consume('(') {
+		for {
+			arg, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if !p.consume(',') {
+				break
+			}
+		}
+		if !p.consume(')') {
+			return nil, p.errorf("expected \")\" after value list")
+		}
+	} else {
+		arg, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	return Comparison{Field: field, Operator: operator, Args: args}, nil
+}
+
+func (p *parser) parseOperator() (string, error) {
+	p.skipSpace()
+	rest := p.input[p.pos:]
+
+	switch {
+	case strings.HasPrefix(rest, "=="), strings.HasPrefix(rest, "!="):
+		p.pos += 2
+		return rest[:2], nil
+	case strings.HasPrefix(rest, "<="), strings.HasPrefix(rest, ">="):
+		p.pos += 2
+		return operatorAliases[rest[:2]], nil
+	case strings.HasPrefix(rest, "<"), strings.HasPrefix(rest, ">"):
+		p.pos++
+		return operatorAliases[rest[:1]], nil
+	case strings.HasPrefix(rest, "="):
+		end := strings.IndexByte(rest[1:], '=')
+		if end > 0 {
+			operator := rest[:end+2]
+			p.pos += len(operator)
+			return operator, nil
+		}
+	}
+
+	return "", p.errorf("expected operator")
+}
+
+func (p *parser) parseValue() (string, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return "", p.errorf("expected value")
+	}
+
+	quote := p.input[p.pos]
+	if quote == '\'' || quote == '"' {
+		p.pos++
+		var value strings.Builder
+		for p.pos < len(p.input) {
+			c := p.input[p.pos]
+			p.pos++
+			switch {
+			case c == '\\' && p.pos < len(p.input):
+				value.WriteByte(p.input[p.pos])
+				p.pos++
+			case c == quote:
+				return value.String(), nil
+			default:
+				value.WriteByte(c)
+			}
+		}
+		return "", p.errorf("unterminated quoted value")
+	}
+
+	start := p.pos
+	for p.pos < len(p.input) && !isReserved(p.input[p.pos]) {
+		p.pos++
+	}
+	if p.pos == start {
+		return "", p.errorf("expected value")
+	}
+	return p.input[start:p.pos], nil
+}
+
+// consume skips spaces and then the given character, reporting whether it was present
+func (p *parser) consume(c byte) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("invalid filter at position %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// isFieldChar reports whether c may appear in a field name
+func isFieldChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isReserved reports whether c ends an unquoted value
+func isReserved(c byte) bool {
+	switch c {
+	case '"', '\'', '(', ')', ';', ',', '=', '!', '<', '>', ' ':
+		return true
+	}
+	return false
+}
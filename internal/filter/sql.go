@@ -0,0 +1,105 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sqlOperators maps the single-value comparison operators to SQL
+var sqlOperators = map[string]string{
+	OpEqual:        "=",
+	OpNotEqual:     "<>",
+	OpLess:         "<",
+	OpLessEqual:    "<=",
+	OpGreater:      ">",
+	OpGreaterEqual: ">=",
+}
+
+// likeEscaper escapes LIKE metacharacters so only "*" acts as a wildcard
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`)
+
+// ToSQL compiles an expression into a SQL condition, numbering its placeholders after argCount.
+// It returns the condition and the arguments it adds. Field names are used as column names.
+func ToSQL(node Node, schema Schema, argCount int) (string, []interface{}, error) {
+	c := &sqlCompiler{schema: schema, argCount: argCount}
+	clause, err := c.compile(node)
+	if err != nil {
+		return "", nil, err
+	}
+	return clause, c.args, nil
+}
+
+// sqlCompiler accumulates placeholder arguments while compiling an expression
+type sqlCompiler struct {
+	schema   Schema
+	argCount int
+	args     []interface{}
+}
+
+func (c *sqlCompiler) compile(node Node) (string, error) {
+	switch n := node.(type) {
+	case And:
+		return c.join(n.Operands, " AND ")
+	case Or:
+		return c.join(n.Operands, " OR ")
+	case Comparison:
+		return c.comparison(n)
+	}
+	return "", fmt.Errorf("invalid filter: unexpected node %T", node)
+}
+
+func (c *sqlCompiler) join(operands []Node, separator string) (string, error) {
+	parts := make([]string, len(operands))
+	for i, operand := range operands {
+		part, err := c.compile(operand)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = part
+	}
+	return "(" + strings.Join(parts, separator) + ")", nil
+}
+
+func (c *sqlCompiler) comparison(n Comparison) (string, error) {
+	field, values, err := c.schema.values(n)
+	if err != nil {
+		return "", err
+	}
+	column := n.Field
+
+	var clause string
+	switch n.Operator {
+	case OpIsNull:
+		if values[0].(bool) {
+			return column + " IS NULL", nil
+		}
+		return column + " IS NOT NULL", nil
+	case OpLike:
+		clause = column + " ILIKE " + c.placeholder(likeEscaper.Replace(values[0].(string)))
+	case OpIn, OpOut:
+		placeholders := make([]string, len(values))
+		for i, value := range values {
+			placeholders[i] = c.placeholder(value)
+		}
+		operator := " IN "
+		if n.Operator == OpOut {
+			operator = " NOT IN "
+		}
+		clause = column + operator + "(" + strings.Join(placeholders, ", ") + ")"
+	default:
+		clause = column + " " + sqlOperators[n.Operator] + " " + c.placeholder(values[0])
+	}
+
+	// NULL is not equal to any excluded value, so negated matches keep it
+	if field.Nullable && (n.Operator == OpNotEqual || n.Operator == OpOut) {
+		clause = "(" + column + " IS NULL OR " + clause + ")"
+	}
+
+	return clause, nil
+}
+
+// placeholder adds an argument and returns its placeholder
+func (c *sqlCompiler) placeholder(value interface{}) string {
+	c.args = append(c.args, value)
+	return fmt.Sprintf("$%d", c.argCount+len(c.args))
+}
@@ -0,0 +1,112 @@
+package graphqlserver
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/go-playground/validator/v10"
+)
+
+// resolverError is an error with the stable code of the equivalent REST problem document, and the
+// failed rules of validation errors, reported in the GraphQL error's extensions
+type resolverError struct {
+	code    string
+	message string
+	errors  []models.FieldError
+}
+
+func newError(code, message string) *resolverError {
+	return &resolverError{code: code, message: message}
+}
+
+func (e *resolverError) Error() string {
+	return e.message
+}
+
+// Extensions implements the extension point of graphql-go's errors
+func (e *resolverError) Extensions() map[string]interface{} {
+	extensions := map[string]interface{}{"code": e.code}
+	if len(e.errors) > 0 {
+		extensions["errors"] = e.errors
+	}
+	return extensions
+}
+
+// validationError reports every failed validation rule, naming fields as in the GraphQL schema
+func validationError(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return newError(models.CodeInvalidRequest, err.Error())
+	}
+
+	fieldErrors := make([]models.FieldError, 0, len(validationErrors))
+	messages := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		field := camelCase(fe.Field())
+		message := field + " failed the " + fe.Tag() + " rule"
+		fieldErrors = append(fieldErrors, models.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message,
+		})
+		messages = append(messages, message)
+	}
+
+	return &resolverError{
+		code:    models.CodeValidationFailed,
+		message: strings.Join(messages, "; "),
+		errors:  fieldErrors,
+	}
+}
+
+// camelCase turns a JSON field name such as full_name into its GraphQL name, fullName
+func camelCase(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if r := []rune(parts[i]); len(r) > 0 {
+			r[0] = unicode.ToUpper(r[0])
+			parts[i] = string(r)
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// updateError maps an error from updating or replacing a user, as the REST API does
+func updateError(err error) error {
+	errMsg := err.Error()
+	switch {
+	case strings.Contains(errMsg, "manager not found"):
+		return newError(models.CodeManagerNotFound, "manager not found")
+	case strings.Contains(errMsg, "would create a cycle"):
+		return newError(models.CodeManagerCycle, "manager assignment would create a cycle")
+	case strings.Contains(errMsg, "modified concurrently"):
+		return newError(models.CodeConcurrentModification, "user was modified concurrently, retry the request")
+	case strings.Contains(errMsg, "no fields to update"):
+		return newError(models.CodeInvalidRequest, "no fields to update")
+	case strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists"):
+		return newError(models.CodeEmailConflict, "email already exists")
+	case strings.Contains(errMsg, "already been merged"):
+		return newError(models.CodeUserMerged, "user has already been merged")
+	case strings.Contains(errMsg, "already been erased"):
+		return newError(models.CodeUserAlreadyErased, "user has already been erased")
+	default:
+		return repositoryError(err, "failed to update user")
+	}
+}
+
+// repositoryError maps an error of the user repository that the resolver does not map itself, as
+// writeRepositoryError does for REST. Other errors are reported with the fallback message.
+func repositoryError(err error, fallback string) error {
+	switch {
+	case errors.Is(err, authz.ErrForbidden):
+		return newError(models.CodeForbidden, "insufficient permissions")
+	case strings.Contains(err.Error(), "user not found"):
+		return newError(models.CodeUserNotFound, "user not found")
+	default:
+		return newError(models.CodeInternal, fallback)
+	}
+}
@@ -0,0 +1,483 @@
+package graphqlserver
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUserRepository mocks the repository methods the resolvers use; the embedded interface
+// panics if any other method is called
+type mockUserRepository struct {
+	mock.Mock
+	repository.UserRepository
+}
+
+func (m *mockUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error) {
+	args := m.Called(ctx, email, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error) {
+	args := m.Called(ctx, ids, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
+	args := m.Called(ctx, id, updates)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	args := m.Called(ctx, filters, sort, pagination, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GetUsersResponse), args.Error(1)
+}
+
+func (m *mockUserRepository) MergeUsers(ctx context.Context, sourceID uuid.UUID, req *models.MergeUsersRequest) (*models.MergeUsersResponse, error) {
+	args := m.Called(ctx, sourceID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MergeUsersResponse), args.Error(1)
+}
+
+func (m *mockUserRepository) EraseUser(ctx context.Context, id uuid.UUID) (*models.ErasureResult, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ErasureResult), args.Error(1)
+}
+
+func (m *mockUserRepository) StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error {
+	args := m.Called(ctx, filters, sort, fn)
+	if users, ok := args.Get(0).([]models.User); ok {
+		for i := range users {
+			if err := fn(&users[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// graphqlResponse is a decoded GraphQL response
+type graphqlResponse struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Path       []interface{}          `json:"path"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// execute posts a query to the handler and decodes the response
+func execute(t *testing.T, h *Handler, query string, variables map[string]interface{}) graphqlResponse {
+	t.Helper()
+	body, err := json.Marshal(graphqlRequest{Query: query, Variables: variables})
+	require.NoError(t, err)
+
+	req, _ := http.NewRequest(http.MethodPost, "/graphql", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response graphqlResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func testUser(name string, managerID *uuid.UUID) models.User {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return models.User{
+		ID:        uuid.New(),
+		Email:     name + "@example.com",
+		FullName:  name,
+		Role:      "staff",
+		IsActive:  true,
+		ManagerID: managerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// TestUsers_BatchesManagers tests that the managers of a page are loaded with one query, and
+// that managers on the page itself are not fetched again
+func TestUsers_BatchesManagers(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	boss := testUser("boss", nil)
+	outsider := testUser("outsider", nil)
+	users := []models.User{
+		boss,
+		testUser("alice", &boss.ID),
+		testUser("bob", &outsider.ID),
+		testUser("carol", &outsider.ID),
+	}
+
+	mockRepo.On("GetAllUsers", mock.Anything, &models.FilterParams{}, &models.SortParams{Field: "created_at", Order: "asc"},
+		&models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}, []string(nil)).
+		Return(&models.GetUsersResponse{Data: users, Pagination: models.PaginationMetadata{Total: 4}}, nil)
+	mockRepo.On("GetUsersByIDs", mock.Anything, []uuid.UUID{outsider.ID}, []string(nil)).
+		Return([]models.User{outsider}, nil).Once()
+
+	response := execute(t, h, `{ users { totalCount edges { node { fullName manager { fullName } } } } }`, nil)
+	require.Empty(t, response.Errors)
+
+	var data struct {
+		Users struct {
+			TotalCount int
+			Edges      []struct {
+				Node struct {
+					FullName string
+					Manager  *struct{ FullName string }
+				}
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	assert.Equal(t, 4, data.Users.TotalCount)
+	require.Len(t, data.Users.Edges, 4)
+	assert.Nil(t, data.Users.Edges[0].Node.Manager)
+	assert.Equal(t, "boss", data.Users.Edges[1].Node.Manager.FullName)
+	assert.Equal(t, "outsider", data.Users.Edges[2].Node.Manager.FullName)
+	assert.Equal(t, "outsider", data.Users.Edges[3].Node.Manager.FullName)
+	mockRepo.AssertNumberOfCalls(t, "GetUsersByIDs", 1)
+}
+
+// TestUsers_BatchesDirectReports tests that the direct reports of a page are loaded with one query
+func TestUsers_BatchesDirectReports(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	first, second := testUser("first", nil), testUser("second", nil)
+	reports := []models.User{testUser("a", &first.ID), testUser("b", &second.ID), testUser("c", &first.ID)}
+
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&models.GetUsersResponse{Data: []models.User{first, second}, Pagination: models.PaginationMetadata{Total: 2}}, nil)
+	mockRepo.On("StreamUsers", mock.Anything, mock.MatchedBy(func(filters *models.FilterParams) bool {
+		return len(filters.Conditions) == 1 && filters.Conditions[0].Field == "manager_id" &&
+			assert.ElementsMatch(t, []string{first.ID.String(), second.ID.String()}, filters.Conditions[0].Values)
+	}), mock.Anything, mock.Anything).Return(reports, nil).Once()
+
+	response := execute(t, h, `{ users { edges { node { directReports { fullName } } } } }`, nil)
+	require.Empty(t, response.Errors)
+
+	var data struct {
+		Users struct {
+			Edges []struct {
+				Node struct {
+					DirectReports []struct{ FullName string }
+				}
+			}
+		}
+	}
+	require.NoError(t, json.Unmarshal(response.Data, &data))
+	require.Len(t, data.Users.Edges, 2)
+	assert.Len(t, data.Users.Edges[0].Node.DirectReports, 2)
+	assert.Len(t, data.Users.Edges[1].Node.DirectReports, 1)
+	mockRepo.AssertNumberOfCalls(t, "StreamUsers", 1)
+}
+
+// TestUsers_CursorPagination tests that the endCursor of a page requests the page after it
+func TestUsers_CursorPagination(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything,
+		&models.PaginationParams{Page: 1, PageSize: 2, Offset: 0}, mock.Anything).
+		Return(&models.GetUsersResponse{Data: []models.User{testUser("a", nil), testUser("b", nil)}, Pagination: models.PaginationMetadata{Total: 3}}, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything,
+		&models.PaginationParams{Page: 2, PageSize: 2, Offset: 2}, mock.Anything).
+		Return(&models.GetUsersResponse{Data: []models.User{testUser("c", nil)}, Pagination: models.PaginationMetadata{Total: 3}}, nil)
+
+	query := `query($after: String) { users(first: 2, after: $after) { pageInfo { hasNextPage hasPreviousPage endCursor } } }`
+	type page struct {
+		Users struct {
+			PageInfo struct {
+				HasNextPage     bool
+				HasPreviousPage bool
+				EndCursor       string
+			}
+		}
+	}
+
+	var first page
+	response := execute(t, h, query, nil)
+	require.Empty(t, response.Errors)
+	require.NoError(t, json.Unmarshal(response.Data, &first))
+	assert.True(t, first.Users.PageInfo.HasNextPage)
+	assert.False(t, first.Users.PageInfo.HasPreviousPage)
+
+	var second page
+	response = execute(t, h, query, map[string]interface{}{"after": first.Users.PageInfo.EndCursor})
+	require.Empty(t, response.Errors)
+	require.NoError(t, json.Unmarshal(response.Data, &second))
+	assert.False(t, second.Users.PageInfo.HasNextPage)
+	assert.True(t, second.Users.PageInfo.HasPreviousPage)
+}
+
+// TestUsers_FilterAndSort tests that filter and sort arguments are converted to repository parameters
+func TestUsers_FilterAndSort(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	mockRepo.On("GetAllUsers", mock.Anything, mock.MatchedBy(func(filters *models.FilterParams) bool {
+		return *filters.IsActive && len(filters.Conditions) == 1 &&
+			filters.Conditions[0].Field == "role" && filters.Conditions[0].Values[0] == "supplier" &&
+			filters.Expression != nil
+	}), &models.SortParams{Fields: []models.SortField{{Field: "manager_id", Order: "desc", Nulls: "last"}}},
+		mock.Anything, mock.Anything).
+		Return(&models.GetUsersResponse{}, nil)
+
+	response := execute(t, h, `{ users(filter: {role: [SUPPLIER], isActive: true, expression: "created_at=ge=2026-01-01"},
+		sort: [{field: MANAGER_ID, direction: DESC, nulls: LAST}]) { totalCount } }`, nil)
+	require.Empty(t, response.Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUsers_InvalidArguments tests that invalid arguments are reported with the REST API's codes
+func TestUsers_InvalidArguments(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"first out of range", `{ users(first: 101) { totalCount } }`},
+		{"malformed cursor", `{ users(after: "bogus") { totalCount } }`},
+		{"malformed manager ID", `{ users(filter: {managerId: "bogus"}) { totalCount } }`},
+		{"empty created range", `{ users(filter: {createdFrom: "2026-02-01T00:00:00Z", createdTo: "2026-01-01T00:00:00Z"}) { totalCount } }`},
+		{"unknown expression field", `{ users(filter: {expression: "password==x"}) { totalCount } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := execute(t, NewHandler(&mockUserRepository{}), tt.query, nil)
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, models.CodeInvalidRequest, response.Errors[0].Extensions["code"])
+		})
+	}
+}
+
+// TestUser_NotFound tests that a missing user resolves to null rather than an error
+func TestUser_NotFound(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	id := uuid.New()
+	mockRepo.On("GetUsersByIDs", mock.Anything, []uuid.UUID{id}, []string(nil)).Return([]models.User{}, nil)
+
+	response := execute(t, h, `query($id: ID!) { user(id: $id) { id } }`, map[string]interface{}{"id": id.String()})
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"user": null}`, string(response.Data))
+
+	response = execute(t, h, `{ user(id: "bogus") { id } }`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, models.CodeInvalidUserID, response.Errors[0].Extensions["code"])
+}
+
+// TestUserByEmail tests looking up a user by email
+func TestUserByEmail(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	user := testUser("alice", nil)
+	mockRepo.On("GetUserByEmail", mock.Anything, "alice@example.com", []string(nil)).Return(&user, nil)
+	mockRepo.On("GetUserByEmail", mock.Anything, "nobody@example.com", []string(nil)).Return(nil, errors.New("user not found"))
+
+	response := execute(t, h, `{ userByEmail(email: " alice@example.com ") { id role } }`, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"userByEmail": {"id": "`+user.ID.String()+`", "role": "STAFF"}}`, string(response.Data))
+
+	response = execute(t, h, `{ userByEmail(email: "nobody@example.com") { id } }`, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"userByEmail": null}`, string(response.Data))
+}
+
+// TestCreateUser tests creating a user and reporting validation errors per field
+func TestCreateUser(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	created := testUser("alice", nil)
+	mockRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+		return user.Email == "alice@example.com" && user.Role == "staff"
+	})).Return(&created, nil)
+
+	response := execute(t, h, `mutation { createUser(input: {email: "alice@example.com", fullName: "alice", role: STAFF}) { id } }`, nil)
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"createUser": {"id": "`+created.ID.String()+`"}}`, string(response.Data))
+
+	response = execute(t, h, `mutation { createUser(input: {email: "not-an-email", fullName: "", role: STAFF}) { id } }`, nil)
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, models.CodeValidationFailed, response.Errors[0].Extensions["code"])
+	fieldErrors := response.Errors[0].Extensions["errors"].([]interface{})
+	require.Len(t, fieldErrors, 2)
+	assert.Equal(t, "email", fieldErrors[0].(map[string]interface{})["field"])
+	assert.Equal(t, "fullName", fieldErrors[1].(map[string]interface{})["field"])
+}
+
+// TestCreateUser_Errors tests that repository errors are reported with the REST API's codes
+func TestCreateUser_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoErr  error
+		wantCode string
+	}{
+		{"manager not found", errors.New("manager not found"), models.CodeManagerNotFound},
+		{"email conflict", errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`), models.CodeEmailConflict},
+		{"internal", errors.New("connection refused"), models.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockUserRepository{}
+			mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(nil, tt.repoErr)
+
+			response := execute(t, NewHandler(mockRepo), `mutation { createUser(input: {email: "taken@example.com", fullName: "x", role: STAFF}) { id } }`, nil)
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, tt.wantCode, response.Errors[0].Extensions["code"])
+			assert.Equal(t, []interface{}{"createUser"}, response.Errors[0].Path)
+		})
+	}
+}
+
+// TestUpdateUser_Errors tests that repository errors are reported with the REST API's codes
+func TestUpdateUser_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		repoErr  error
+		wantCode string
+	}{
+		{"not found", errors.New("user not found"), models.CodeUserNotFound},
+		{"cycle", errors.New("manager assignment would create a cycle"), models.CodeManagerCycle},
+		{"email conflict", errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`), models.CodeEmailConflict},
+		{"merged", errors.New("user has already been merged"), models.CodeUserMerged},
+		{"erased", errors.New("user has already been erased"), models.CodeUserAlreadyErased},
+		{"internal", errors.New("connection refused"), models.CodeInternal},
+		{"forbidden", fmt.Errorf("%w: supplier may not update user", authz.ErrForbidden), models.CodeForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := &mockUserRepository{}
+			id := uuid.New()
+			mockRepo.On("UpdateUser", mock.Anything, id, mock.Anything).Return(nil, tt.repoErr)
+
+			response := execute(t, NewHandler(mockRepo), `mutation($id: ID!) { updateUser(id: $id, input: {fullName: "x"}) { id } }`,
+				map[string]interface{}{"id": id.String()})
+			require.Len(t, response.Errors, 1)
+			assert.Equal(t, tt.wantCode, response.Errors[0].Extensions["code"])
+			assert.Equal(t, []interface{}{"updateUser"}, response.Errors[0].Path)
+		})
+	}
+}
+
+// TestMergeUser tests that kept fields are passed to the repository in its spelling
+func TestMergeUser(t *testing.T) {
+	mockRepo := &mockUserRepository{}
+	h := NewHandler(mockRepo)
+
+	source, target := testUser("source", nil), testUser("target", nil)
+	mockRepo.On("MergeUsers", mock.Anything, source.ID, &models.MergeUsersRequest{
+		TargetID: target.ID,
+		Keep:     map[string]string{"full_name": "source"},
+	}).Return(&models.MergeUsersResponse{Target: target, Source: source}, nil)
+
+	response := execute(t, h, `mutation($id: ID!, $target: ID!) {
+		mergeUser(id: $id, targetId: $target, keep: [{field: FULL_NAME, from: SOURCE}]) { target { id } }
+	}`, map[string]interface{}{"id": source.ID.String(), "target": target.ID.String()})
+	require.Empty(t, response.Errors)
+	assert.JSONEq(t, `{"mergeUser": {"target": {"id": "`+target.ID.String()+`"}}}`, string(response.Data))
+}
+
+// TestEraseUser tests that erasure returns a receipt signed like the REST API's
+func TestEraseUser(t *testing.T) {
+	id := uuid.New()
+	result := &models.ErasureResult{
+		UserID:        id,
+		ErasedUserIDs: []uuid.UUID{id},
+		ErasedFields:  []string{"email", "full_name"},
+		ErasedAt:      time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	t.Run("signed receipt", func(t *testing.T) {
+		mockRepo := &mockUserRepository{}
+		signer := receipt.NewSigner([]byte("secret"))
+		mockRepo.On("EraseUser", mock.Anything, id).Return(result, nil)
+
+		response := execute(t, NewHandler(mockRepo, WithReceiptSigner(signer)),
+			`mutation($id: ID!) { eraseUser(id: $id) { receiptId algorithm signature } }`,
+			map[string]interface{}{"id": id.String()})
+		require.Empty(t, response.Errors)
+
+		var data struct {
+			EraseUser struct {
+				ReceiptID string
+				Algorithm string
+				Signature string
+			}
+		}
+		require.NoError(t, json.Unmarshal(response.Data, &data))
+		valid, err := signer.Verify(models.ErasureReceipt{
+			ReceiptID:     uuid.MustParse(data.EraseUser.ReceiptID),
+			ErasureResult: *result,
+			Algorithm:     data.EraseUser.Algorithm,
+		}, data.EraseUser.Signature)
+		require.NoError(t, err)
+		assert.True(t, valid)
+	})
+
+	t.Run("no signer", func(t *testing.T) {
+		response := execute(t, NewHandler(&mockUserRepository{}),
+			`mutation($id: ID!) { eraseUser(id: $id) { signature } }`,
+			map[string]interface{}{"id": id.String()})
+		require.Len(t, response.Errors, 1)
+		assert.Equal(t, models.CodeInternal, response.Errors[0].Extensions["code"])
+	})
+}
+
+// TestServeHTTP_RequiresPost tests that queries cannot be sent with GET
+func TestServeHTTP_RequiresPost(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "/graphql?query={users{totalCount}}", nil)
+	w := httptest.NewRecorder()
+	NewHandler(&mockUserRepository{}).ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, http.MethodPost, w.Header().Get("Allow"))
+}
@@ -0,0 +1,93 @@
+// Package graphqlserver serves the user repository as a GraphQL API.
+//
+// Requests are validated with the same rules as the REST API, and errors carry the same stable
+// codes as problem documents in their extensions. Users referenced from other users, such as
+// managers and direct reports, are loaded in batches per request rather than one query each.
+package graphqlserver
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var schemaSource string
+
+// Limits protecting the database from expensive queries
+const (
+	maxDepth = 10
+	// Every user on a full page may load its manager and reports concurrently, so that they are
+	// fetched in one batch each
+	maxParallelism = 4 * maxFirst
+)
+
+// Handler serves GraphQL requests
+type Handler struct {
+	userRepo repository.UserRepository
+	schema   *graphql.Schema
+}
+
+// Option configures optional dependencies of a Handler
+type Option func(*Resolver)
+
+// WithReceiptSigner sets the signer used for erasure receipts; without one eraseUser fails
+func WithReceiptSigner(signer *receipt.Signer) Option {
+	return func(r *Resolver) {
+		r.receiptSigner = signer
+	}
+}
+
+// NewHandler creates a new GraphQL Handler
+func NewHandler(userRepo repository.UserRepository, opts ...Option) *Handler {
+	resolver := newResolver(userRepo)
+	for _, opt := range opts {
+		opt(resolver)
+	}
+
+	return &Handler{
+		userRepo: userRepo,
+		schema: graphql.MustParseSchema(schemaSource, resolver,
+			graphql.MaxDepth(maxDepth),
+			graphql.MaxParallelism(maxParallelism),
+		),
+	}
+}
+
+// graphqlRequest is the body of a GraphQL request over HTTP
+type graphqlRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// ServeHTTP executes a GraphQL request posted as JSON. Errors in the query are reported in the
+// response's errors with status 200, as GraphQL clients expect.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "GraphQL requests must be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req graphqlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid GraphQL request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := withLoaders(r.Context(), newLoaders(h.userRepo))
+	response := h.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		http.Error(w, "failed to encode GraphQL response", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(body)
+}
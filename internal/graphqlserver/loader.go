@@ -0,0 +1,141 @@
+package graphqlserver
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/google/uuid"
+)
+
+// loaderWait is how long a loader collects keys before fetching them. Resolvers of sibling fields
+// run concurrently, so the lookups of a whole list of users arrive within this window.
+const loaderWait = 2 * time.Millisecond
+
+// loader batches lookups by key made while resolving one request into a single fetch, and caches
+// the results for the rest of the request. Keys the fetch does not return load as the zero value.
+type loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu       sync.Mutex
+	pending  *batch[K, V]
+	inFlight map[K]*batch[K, V] // Batches of keys that have been requested but not loaded yet
+	cache    map[K]V
+}
+
+// batch is a set of keys fetched together
+type batch[K comparable, V any] struct {
+	keys   []K
+	done   chan struct{}
+	values map[K]V
+	err    error
+}
+
+func newLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *loader[K, V] {
+	return &loader[K, V]{fetch: fetch, inFlight: make(map[K]*batch[K, V]), cache: make(map[K]V)}
+}
+
+// Load returns the value for key, fetching it together with the other keys requested within loaderWait
+func (l *loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	if value, ok := l.cache[key]; ok {
+		l.mu.Unlock()
+		return value, nil
+	}
+	b, ok := l.inFlight[key]
+	if !ok {
+		if b = l.pending; b == nil {
+			b = &batch[K, V]{done: make(chan struct{})}
+			l.pending = b
+			go l.dispatch(ctx, b)
+		}
+		b.keys = append(b.keys, key)
+		l.inFlight[key] = b
+	}
+	l.mu.Unlock()
+
+	<-b.done
+	if b.err != nil {
+		var zero V
+		return zero, b.err
+	}
+	return b.values[key], nil
+}
+
+// Prime caches a value that is already known, such as a user on the page being resolved
+func (l *loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cache[key] = value
+}
+
+func (l *loader[K, V]) dispatch(ctx context.Context, b *batch[K, V]) {
+	time.Sleep(loaderWait)
+
+	l.mu.Lock()
+	l.pending = nil
+	l.mu.Unlock()
+
+	b.values, b.err = l.fetch(ctx, b.keys)
+
+	l.mu.Lock()
+	for _, key := range b.keys {
+		if b.err == nil {
+			l.cache[key] = b.values[key]
+		}
+		delete(l.inFlight, key)
+	}
+	l.mu.Unlock()
+	close(b.done)
+}
+
+// loaders holds the loaders of one request
+type loaders struct {
+	users   *loader[uuid.UUID, *models.User]
+	reports *loader[uuid.UUID, []models.User]
+}
+
+type loadersKey struct{}
+
+// newLoaders creates the loaders for one request. Users are read with GetUsersByIDs, and the
+// direct reports of several managers with one StreamUsers query.
+func newLoaders(userRepo repository.UserRepository) *loaders {
+	return &loaders{
+		users: newLoader(func(ctx context.Context, ids []uuid.UUID) (map[uuid.UUID]*models.User, error) {
+			users, err := userRepo.GetUsersByIDs(ctx, ids, nil)
+			if err != nil {
+				return nil, err
+			}
+			byID := make(map[uuid.UUID]*models.User, len(users))
+			for i := range users {
+				byID[users[i].ID] = &users[i]
+			}
+			return byID, nil
+		}),
+		reports: newLoader(func(ctx context.Context, managerIDs []uuid.UUID) (map[uuid.UUID][]models.User, error) {
+			values := make([]string, len(managerIDs))
+			for i, id := range managerIDs {
+				values[i] = id.String()
+			}
+			filters := &models.FilterParams{Conditions: []models.FilterCondition{{Field: "manager_id", Values: values}}}
+			sort := &models.SortParams{Fields: []models.SortField{{Field: "full_name", Order: "asc"}}}
+
+			byManager := make(map[uuid.UUID][]models.User, len(managerIDs))
+			err := userRepo.StreamUsers(ctx, filters, sort, func(user *models.User) error {
+				byManager[*user.ManagerID] = append(byManager[*user.ManagerID], *user)
+				return nil
+			})
+			return byManager, err
+		}),
+	}
+}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey{}).(*loaders)
+}
@@ -0,0 +1,303 @@
+package graphqlserver
+
+import (
+	"context"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// createUserInput is the CreateUserInput input type
+type createUserInput struct {
+	Email     string
+	FullName  string
+	Phone     *string
+	Role      string
+	ManagerID *graphql.ID
+}
+
+// CreateUser creates a user
+func (r *Resolver) CreateUser(ctx context.Context, args struct{ Input createUserInput }) (*userResolver, error) {
+	managerID, err := parseOptionalID("managerId", args.Input.ManagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.CreateUserRequest{
+		Email:     args.Input.Email,
+		FullName:  args.Input.FullName,
+		Phone:     args.Input.Phone,
+		Role:      strings.ToLower(args.Input.Role),
+		ManagerID: managerID,
+	}
+	if err := r.validator.Struct(req); err != nil {
+		return nil, validationError(err)
+	}
+
+	user, err := r.userRepo.CreateUser(ctx, &models.User{
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Phone:     req.Phone,
+		Role:      req.Role,
+		ManagerID: req.ManagerID,
+	})
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.Contains(errMsg, "manager not found"):
+			return nil, newError(models.CodeManagerNotFound, "manager not found")
+		case strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists"):
+			return nil, newError(models.CodeEmailConflict, "email already exists")
+		default:
+			return nil, repositoryError(err, "failed to create user")
+		}
+	}
+	return &userResolver{user: user}, nil
+}
+
+// updateUserInput is the UpdateUserInput input type
+type updateUserInput struct {
+	Email     *string
+	FullName  *string
+	Phone     *string
+	Role      *string
+	IsActive  *bool
+	ManagerID *graphql.ID
+}
+
+// UpdateUser updates the given fields of a user
+func (r *Resolver) UpdateUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input updateUserInput
+}) (*userResolver, error) {
+	id, err := parseUserID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	managerID, err := parseOptionalID("managerId", args.Input.ManagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	req := models.UpdateUserRequest{
+		Email:     args.Input.Email,
+		FullName:  args.Input.FullName,
+		Phone:     args.Input.Phone,
+		Role:      lowerOptional(args.Input.Role),
+		IsActive:  args.Input.IsActive,
+		ManagerID: managerID,
+	}
+	if err := r.validator.Struct(req); err != nil {
+		return nil, validationError(err)
+	}
+
+	user, err := r.userRepo.UpdateUser(ctx, id, &req)
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+// replaceUserInput is the ReplaceUserInput input type
+type replaceUserInput struct {
+	Email     string
+	FullName  string
+	Phone     *string
+	Role      string
+	IsActive  bool
+	ManagerID *graphql.ID
+}
+
+// ReplaceUser replaces every field of a user
+func (r *Resolver) ReplaceUser(ctx context.Context, args struct {
+	ID    graphql.ID
+	Input replaceUserInput
+}) (*userResolver, error) {
+	id, err := parseUserID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	managerID, err := parseOptionalID("managerId", args.Input.ManagerID)
+	if err != nil {
+		return nil, err
+	}
+
+	isActive := args.Input.IsActive
+	req := models.ReplaceUserRequest{
+		Email:     args.Input.Email,
+		FullName:  args.Input.FullName,
+		Phone:     args.Input.Phone,
+		Role:      strings.ToLower(args.Input.Role),
+		IsActive:  &isActive,
+		ManagerID: managerID,
+	}
+	if err := r.validator.Struct(req); err != nil {
+		return nil, validationError(err)
+	}
+
+	user, err := r.userRepo.ReplaceUser(ctx, id, &req, nil)
+	if err != nil {
+		return nil, updateError(err)
+	}
+	return &userResolver{user: user}, nil
+}
+
+// DeleteUser deletes a user and returns it as it was
+func (r *Resolver) DeleteUser(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseUserID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := r.userRepo.DeleteUser(ctx, id)
+	if err != nil {
+		return nil, repositoryError(err, "failed to delete user")
+	}
+	return &userResolver{user: user}, nil
+}
+
+// mergeKeepInput is the MergeKeep input type
+type mergeKeepInput struct {
+	Field string
+	From  string
+}
+
+// MergeUser merges a duplicate user into a surviving one
+func (r *Resolver) MergeUser(ctx context.Context, args struct {
+	ID       graphql.ID
+	TargetID graphql.ID
+	Keep     *[]*mergeKeepInput
+}) (*mergeResultResolver, error) {
+	sourceID, err := parseUserID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	targetID, err := uuid.Parse(string(args.TargetID))
+	if err != nil {
+		return nil, newError(models.CodeInvalidRequest, "invalid targetId format")
+	}
+
+	req := models.MergeUsersRequest{TargetID: targetID}
+	if args.Keep != nil {
+		req.Keep = make(map[string]string, len(*args.Keep))
+		for _, keep := range *args.Keep {
+			req.Keep[strings.ToLower(keep.Field)] = strings.ToLower(keep.From)
+		}
+	}
+	if err := r.validator.Struct(req); err != nil {
+		return nil, validationError(err)
+	}
+
+	result, err := r.userRepo.MergeUsers(ctx, sourceID, &req)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.Contains(errMsg, "cannot merge a user into itself"):
+			return nil, newError(models.CodeInvalidMerge, "cannot merge a user into itself")
+		case strings.Contains(errMsg, "merge target not found"):
+			return nil, newError(models.CodeMergeTargetNotFound, "merge target not found")
+		case strings.Contains(errMsg, "already been merged"):
+			return nil, newError(models.CodeInvalidMerge, errMsg)
+		case strings.Contains(errMsg, "would create a cycle"):
+			return nil, newError(models.CodeInvalidMerge, "cannot merge a user into one of their subordinates")
+		case strings.Contains(errMsg, "duplicate key value"):
+			return nil, newError(models.CodeEmailConflict, "email already exists")
+		default:
+			return nil, repositoryError(err, "failed to merge users")
+		}
+	}
+	return &mergeResultResolver{result: result}, nil
+}
+
+// mergeResultResolver resolves the fields of a MergeResult
+type mergeResultResolver struct {
+	result *models.MergeUsersResponse
+}
+
+func (r *mergeResultResolver) Target() *userResolver {
+	return &userResolver{user: &r.result.Target}
+}
+
+func (r *mergeResultResolver) Source() *userResolver {
+	return &userResolver{user: &r.result.Source}
+}
+
+// EraseUser erases a user's personal data and returns a signed erasure receipt
+func (r *Resolver) EraseUser(ctx context.Context, args struct{ ID graphql.ID }) (*erasureReceiptResolver, error) {
+	id, err := parseUserID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse before erasing anything if we could not hand out a receipt afterwards
+	if r.receiptSigner == nil {
+		return nil, newError(models.CodeInternal, "erasure receipts are not configured")
+	}
+
+	result, err := r.userRepo.EraseUser(ctx, id)
+	if err != nil {
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "already been erased") {
+			return nil, newError(models.CodeUserAlreadyErased, "user has already been erased")
+		}
+		return nil, repositoryError(err, "failed to erase user")
+	}
+
+	erasureReceipt := models.ErasureReceipt{
+		ReceiptID:     uuid.New(),
+		ErasureResult: *result,
+		Algorithm:     receipt.Algorithm,
+	}
+	signature, err := r.receiptSigner.Sign(erasureReceipt)
+	if err != nil {
+		return nil, newError(models.CodeInternal, "failed to sign erasure receipt")
+	}
+	erasureReceipt.Signature = signature
+
+	return &erasureReceiptResolver{receipt: &erasureReceipt}, nil
+}
+
+// erasureReceiptResolver resolves the fields of an ErasureReceipt. The signature is an HMAC-SHA256
+// of the receipt's REST representation, which only holders of the shared secret can verify.
+type erasureReceiptResolver struct {
+	receipt *models.ErasureReceipt
+}
+
+func (r *erasureReceiptResolver) ReceiptID() graphql.ID {
+	return graphql.ID(r.receipt.ReceiptID.String())
+}
+
+func (r *erasureReceiptResolver) UserID() graphql.ID {
+	return graphql.ID(r.receipt.UserID.String())
+}
+
+func (r *erasureReceiptResolver) ErasedUserIDs() []graphql.ID {
+	ids := make([]graphql.ID, len(r.receipt.ErasedUserIDs))
+	for i, id := range r.receipt.ErasedUserIDs {
+		ids[i] = graphql.ID(id.String())
+	}
+	return ids
+}
+
+func (r *erasureReceiptResolver) ErasedFields() []string {
+	return r.receipt.ErasedFields
+}
+
+func (r *erasureReceiptResolver) HistoryEntriesScrubbed() int32 {
+	return int32(r.receipt.HistoryEntriesScrubbed)
+}
+
+func (r *erasureReceiptResolver) ErasedAt() graphql.Time {
+	return graphql.Time{Time: r.receipt.ErasedAt}
+}
+
+func (r *erasureReceiptResolver) Algorithm() string {
+	return r.receipt.Algorithm
+}
+
+func (r *erasureReceiptResolver) Signature() string {
+	return r.receipt.Signature
+}
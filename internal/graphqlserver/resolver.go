@@ -0,0 +1,247 @@
+package graphqlserver
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/filter"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// maxFirst is the largest page the users query returns
+const maxFirst = 100
+
+// Resolver resolves the Query and Mutation fields
+type Resolver struct {
+	userRepo      repository.UserRepository
+	validator     *validator.Validate
+	receiptSigner *receipt.Signer
+}
+
+func newResolver(userRepo repository.UserRepository) *Resolver {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	return &Resolver{
+		userRepo:  userRepo,
+		validator: v,
+	}
+}
+
+// User resolves a user by ID through the batching loader
+func (r *Resolver) User(ctx context.Context, args struct{ ID graphql.ID }) (*userResolver, error) {
+	id, err := parseUserID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+	return loadUser(ctx, &id)
+}
+
+// UserByEmail resolves a user by exact, case-insensitive email
+func (r *Resolver) UserByEmail(ctx context.Context, args struct{ Email string }) (*userResolver, error) {
+	email := strings.TrimSpace(args.Email)
+	if err := r.validator.Var(email, "required,email"); err != nil {
+		return nil, newError(models.CodeInvalidRequest, "invalid email format")
+	}
+
+	user, err := r.userRepo.GetUserByEmail(ctx, email, nil)
+	if err != nil {
+		if strings.Contains(err.Error(), "user not found") {
+			return nil, nil
+		}
+		return nil, repositoryError(err, "failed to retrieve user")
+	}
+	loadersFrom(ctx).users.Prime(user.ID, user)
+	return &userResolver{user: user}, nil
+}
+
+// userFilterInput is the UserFilter input type
+type userFilterInput struct {
+	IDs          *[]graphql.ID
+	Role         *[]string
+	IsActive     *bool
+	Search       *string
+	EmailDomain  *string
+	CreatedFrom  *graphql.Time
+	CreatedTo    *graphql.Time
+	UpdatedFrom  *graphql.Time
+	UpdatedTo    *graphql.Time
+	ManagerID    *graphql.ID
+	UnderManager *graphql.ID
+	Expression   *string
+}
+
+// userSortInput is the UserSort input type
+type userSortInput struct {
+	Field     string
+	Direction string
+	Nulls     *string
+}
+
+type usersArgs struct {
+	Filter *userFilterInput
+	Sort   *[]*userSortInput
+	First  int32
+	After  *string
+}
+
+// Users resolves a page of the users matching a filter
+func (r *Resolver) Users(ctx context.Context, args usersArgs) (*userConnectionResolver, error) {
+	first := int(args.First)
+	if first < 1 || first > maxFirst {
+		return nil, newError(models.CodeInvalidRequest, "first must be between 1 and 100")
+	}
+
+	offset := 0
+	if args.After != nil {
+		var err error
+		if offset, err = decodeCursor(*args.After); err != nil {
+			return nil, newError(models.CodeInvalidRequest, err.Error())
+		}
+	}
+
+	filters, err := toFilterParams(args.Filter)
+	if err != nil {
+		return nil, newError(models.CodeInvalidRequest, err.Error())
+	}
+
+	sort := &models.SortParams{Field: "created_at", Order: "asc"}
+	if args.Sort != nil && len(*args.Sort) > 0 {
+		sort = &models.SortParams{}
+		for _, key := range *args.Sort {
+			field := models.SortField{Field: strings.ToLower(key.Field), Order: strings.ToLower(key.Direction)}
+			if key.Nulls != nil {
+				field.Nulls = strings.ToLower(*key.Nulls)
+			}
+			sort.Fields = append(sort.Fields, field)
+		}
+	}
+
+	pagination := &models.PaginationParams{Page: offset/first + 1, PageSize: first, Offset: offset}
+	response, err := r.userRepo.GetAllUsers(ctx, filters, sort, pagination, nil)
+	if err != nil {
+		// Sort keys the repository cannot express, such as nulls on a column that is never null
+		if strings.HasPrefix(err.Error(), "invalid ") {
+			return nil, newError(models.CodeInvalidRequest, err.Error())
+		}
+		return nil, repositoryError(err, "failed to retrieve users")
+	}
+
+	return &userConnectionResolver{
+		users:  userResolvers(ctx, response.Data),
+		offset: offset,
+		total:  response.Pagination.Total,
+	}, nil
+}
+
+// toFilterParams converts a UserFilter. Plain dates in the expression are interpreted in UTC.
+func toFilterParams(input *userFilterInput) (*models.FilterParams, error) {
+	filters := &models.FilterParams{}
+	if input == nil {
+		return filters, nil
+	}
+
+	filters.IsActive = input.IsActive
+	filters.Search = input.Search
+	filters.EmailDomain = input.EmailDomain
+	filters.CreatedFrom = optionalTime(input.CreatedFrom)
+	filters.CreatedTo = optionalTime(input.CreatedTo)
+	filters.UpdatedFrom = optionalTime(input.UpdatedFrom)
+	filters.UpdatedTo = optionalTime(input.UpdatedTo)
+	if filters.CreatedFrom != nil && filters.CreatedTo != nil && !filters.CreatedFrom.Before(*filters.CreatedTo) {
+		return nil, newError(models.CodeInvalidRequest, "invalid created range: createdFrom must be before createdTo")
+	}
+	if filters.UpdatedFrom != nil && filters.UpdatedTo != nil && !filters.UpdatedFrom.Before(*filters.UpdatedTo) {
+		return nil, newError(models.CodeInvalidRequest, "invalid updated range: updatedFrom must be before updatedTo")
+	}
+
+	var err error
+	if filters.ManagerID, err = parseOptionalID("managerId", input.ManagerID); err != nil {
+		return nil, err
+	}
+	if filters.UnderManager, err = parseOptionalID("underManager", input.UnderManager); err != nil {
+		return nil, err
+	}
+
+	if input.IDs != nil {
+		values := make([]string, len(*input.IDs))
+		for i, raw := range *input.IDs {
+			id, err := uuid.Parse(string(raw))
+			if err != nil {
+				return nil, newError(models.CodeInvalidRequest, "invalid ids format")
+			}
+			values[i] = id.String()
+		}
+		filters.Conditions = append(filters.Conditions, models.FilterCondition{Field: "id", Values: values})
+	}
+	if input.Role != nil {
+		values := make([]string, len(*input.Role))
+		for i, role := range *input.Role {
+			values[i] = strings.ToLower(role)
+		}
+		filters.Conditions = append(filters.Conditions, models.FilterCondition{Field: "role", Values: values})
+	}
+
+	if input.Expression != nil && *input.Expression != "" {
+		expression, err := filter.Parse(*input.Expression)
+		if err != nil {
+			return nil, err
+		}
+		if err := filter.Check(expression, models.UserFilterSchema); err != nil {
+			return nil, err
+		}
+		filters.Expression = filter.InLocation(expression, models.UserFilterSchema, time.UTC)
+	}
+
+	return filters, nil
+}
+
+func optionalTime(t *graphql.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	return &t.Time
+}
+
+// parseUserID parses the ID of the user a field refers to
+func parseUserID(raw graphql.ID) (uuid.UUID, error) {
+	id, err := uuid.Parse(string(raw))
+	if err != nil {
+		return uuid.Nil, newError(models.CodeInvalidUserID, "invalid user ID format")
+	}
+	return id, nil
+}
+
+// parseOptionalID parses an optional ID argument, which is nil when absent
+func parseOptionalID(name string, raw *graphql.ID) (*uuid.UUID, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	id, err := uuid.Parse(string(*raw))
+	if err != nil {
+		return nil, newError(models.CodeInvalidRequest, "invalid "+name+" format")
+	}
+	return &id, nil
+}
+
+// lowerOptional lower-cases an optional enum value, as the models store roles in lower case
+func lowerOptional(value *string) *string {
+	if value == nil {
+		return nil
+	}
+	lower := strings.ToLower(*value)
+	return &lower
+}
@@ -0,0 +1,204 @@
+package graphqlserver
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+)
+
+// userResolver resolves the fields of a User
+type userResolver struct {
+	user *models.User
+}
+
+func (r *userResolver) ID() graphql.ID {
+	return graphql.ID(r.user.ID.String())
+}
+
+func (r *userResolver) Email() string {
+	return r.user.Email
+}
+
+func (r *userResolver) FullName() string {
+	return r.user.FullName
+}
+
+func (r *userResolver) Phone() *string {
+	return r.user.Phone
+}
+
+func (r *userResolver) Role() string {
+	return strings.ToUpper(r.user.Role)
+}
+
+func (r *userResolver) IsActive() bool {
+	return r.user.IsActive
+}
+
+func (r *userResolver) ManagerID() *graphql.ID {
+	return optionalID(r.user.ManagerID)
+}
+
+// Manager loads the user's manager in a batch with the other users' managers
+func (r *userResolver) Manager(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.user.ManagerID)
+}
+
+// DirectReports loads the user's direct reports in a batch with the other users' reports
+func (r *userResolver) DirectReports(ctx context.Context) ([]*userResolver, error) {
+	reports, err := loadersFrom(ctx).reports.Load(ctx, r.user.ID)
+	if err != nil {
+		return nil, repositoryError(err, "failed to retrieve direct reports")
+	}
+	return userResolvers(ctx, reports), nil
+}
+
+// MergedInto loads the user this user has been merged into
+func (r *userResolver) MergedInto(ctx context.Context) (*userResolver, error) {
+	return loadUser(ctx, r.user.MergedInto)
+}
+
+func (r *userResolver) ErasedAt() *graphql.Time {
+	if r.user.ErasedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.user.ErasedAt}
+}
+
+func (r *userResolver) CreatedAt() graphql.Time {
+	return graphql.Time{Time: r.user.CreatedAt}
+}
+
+func (r *userResolver) UpdatedAt() graphql.Time {
+	return graphql.Time{Time: r.user.UpdatedAt}
+}
+
+// loadUser loads a referenced user through the request's batching loader
+func loadUser(ctx context.Context, id *uuid.UUID) (*userResolver, error) {
+	if id == nil {
+		return nil, nil
+	}
+	user, err := loadersFrom(ctx).users.Load(ctx, *id)
+	if err != nil {
+		return nil, repositoryError(err, "failed to retrieve user")
+	}
+	if user == nil {
+		return nil, nil
+	}
+	return &userResolver{user: user}, nil
+}
+
+// userResolvers wraps users in resolvers and primes the request's loader with them, so that
+// references to them are not fetched again
+func userResolvers(ctx context.Context, users []models.User) []*userResolver {
+	resolvers := make([]*userResolver, len(users))
+	for i := range users {
+		loadersFrom(ctx).users.Prime(users[i].ID, &users[i])
+		resolvers[i] = &userResolver{user: &users[i]}
+	}
+	return resolvers
+}
+
+func optionalID(id *uuid.UUID) *graphql.ID {
+	if id == nil {
+		return nil
+	}
+	gqlID := graphql.ID(id.String())
+	return &gqlID
+}
+
+// userConnectionResolver resolves a page of users. Cursors encode the offset of a user in the
+// filtered, sorted list, so they stay valid as long as the list does not change.
+type userConnectionResolver struct {
+	users  []*userResolver
+	offset int
+	total  int
+}
+
+func (r *userConnectionResolver) Edges() []*userEdgeResolver {
+	edges := make([]*userEdgeResolver, len(r.users))
+	for i, user := range r.users {
+		edges[i] = &userEdgeResolver{cursor: encodeCursor(r.offset + i + 1), node: user}
+	}
+	return edges
+}
+
+func (r *userConnectionResolver) PageInfo() *pageInfoResolver {
+	info := &pageInfoResolver{
+		hasNextPage:     r.offset+len(r.users) < r.total,
+		hasPreviousPage: r.offset > 0,
+	}
+	if len(r.users) > 0 {
+		start, end := encodeCursor(r.offset+1), encodeCursor(r.offset+len(r.users))
+		info.startCursor, info.endCursor = &start, &end
+	}
+	return info
+}
+
+func (r *userConnectionResolver) TotalCount() int32 {
+	return int32(r.total)
+}
+
+type userEdgeResolver struct {
+	cursor string
+	node   *userResolver
+}
+
+func (r *userEdgeResolver) Cursor() string {
+	return r.cursor
+}
+
+func (r *userEdgeResolver) Node() *userResolver {
+	return r.node
+}
+
+type pageInfoResolver struct {
+	hasNextPage     bool
+	hasPreviousPage bool
+	startCursor     *string
+	endCursor       *string
+}
+
+func (r *pageInfoResolver) HasNextPage() bool {
+	return r.hasNextPage
+}
+
+func (r *pageInfoResolver) HasPreviousPage() bool {
+	return r.hasPreviousPage
+}
+
+func (r *pageInfoResolver) StartCursor() *string {
+	return r.startCursor
+}
+
+func (r *pageInfoResolver) EndCursor() *string {
+	return r.endCursor
+}
+
+// cursorPrefix marks cursors as offsets, leaving room for other kinds of cursor
+const cursorPrefix = "offset:"
+
+// encodeCursor returns the cursor of the user at a 1-based position; the page after it starts
+// at that position's offset
+func encodeCursor(position int) string {
+	return base64.URLEncoding.EncodeToString([]byte(cursorPrefix + strconv.Itoa(position)))
+}
+
+// decodeCursor returns the offset of the page after a cursor
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(raw), cursorPrefix) {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), cursorPrefix))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
@@ -0,0 +1,54 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/pkg/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthInterceptor returns an interceptor that requires calls to the user service to carry
+// "authorization" metadata that one of the authenticators accepts, in the same form as the HTTP
+// Authorization header. The caller's principal is stored in the context, as for REST requests.
+// The health and reflection services stay open.
+func AuthInterceptor(authenticators ...auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if !strings.HasPrefix(info.FullMethod, "/"+userpb.UserService_ServiceDesc.ServiceName+"/") {
+			return handler(ctx, req)
+		}
+
+		var header string
+		if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+			header = values[0]
+		}
+		scheme, credentials, _ := strings.Cut(header, " ")
+		credentials = strings.TrimSpace(credentials)
+		if credentials == "" {
+			return nil, status.Error(codes.Unauthenticated, "missing credentials")
+		}
+
+		for _, a := range authenticators {
+			if !strings.EqualFold(a.Scheme(), scheme) {
+				continue
+			}
+			principal, err := a.Authenticate(ctx, credentials)
+			if err != nil {
+				if errors.Is(err, auth.ErrInvalidCredentials) {
+					log.Printf("Rejected %s credentials: %v", a.Scheme(), err)
+					return nil, status.Error(codes.Unauthenticated, "invalid credentials")
+				}
+				log.Printf("Error authenticating call: %v", err)
+				return nil, status.Error(codes.Internal, "failed to authenticate request")
+			}
+			return handler(auth.NewContext(ctx, principal), req)
+		}
+		return nil, status.Error(codes.Unauthenticated, "unsupported authorization scheme")
+	}
+}
@@ -0,0 +1,81 @@
+package grpcserver
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/pkg/userpb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tokenAuthenticator accepts a single bearer token
+type tokenAuthenticator string
+
+func (a tokenAuthenticator) Scheme() string {
+	return "Bearer"
+}
+
+func (a tokenAuthenticator) Authenticate(ctx context.Context, token string) (*auth.Principal, error) {
+	if token != string(a) {
+		return nil, fmt.Errorf("%w: unknown token", auth.ErrInvalidCredentials)
+	}
+	return &auth.Principal{Subject: "alice"}, nil
+}
+
+// TestAuthInterceptor tests that user service calls require credentials and see the caller's principal
+func TestAuthInterceptor(t *testing.T) {
+	conn, mockRepo := setupTestServer(t, grpc.UnaryInterceptor(AuthInterceptor(tokenAuthenticator("token"))))
+	client := userpb.NewUserServiceClient(conn)
+	user := testUser()
+
+	mockRepo.On("GetUserByID", mock.MatchedBy(func(ctx context.Context) bool {
+		principal, ok := auth.FromContext(ctx)
+		return ok && principal.Subject == "alice"
+	}), user.ID, []string(nil)).Return(user, nil)
+
+	tests := []struct {
+		name          string
+		authorization string
+		code          codes.Code
+		message       string
+	}{
+		{"valid token", "Bearer token", codes.OK, ""},
+		{"missing metadata", "", codes.Unauthenticated, "missing credentials"},
+		{"unsupported scheme", "Basic dXNlcjpwYXNz", codes.Unauthenticated, "unsupported authorization scheme"},
+		{"invalid token", "Bearer forged", codes.Unauthenticated, "invalid credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.authorization != "" {
+				ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tt.authorization)
+			}
+			_, err := client.GetUser(ctx, &userpb.GetUserRequest{Id: user.ID.String()})
+			st := status.Convert(err)
+			assert.Equal(t, tt.code, st.Code())
+			if tt.message != "" {
+				assert.Equal(t, tt.message, st.Message())
+			}
+		})
+	}
+	mockRepo.AssertNumberOfCalls(t, "GetUserByID", 1)
+}
+
+// TestAuthInterceptor_HealthOpen tests that health checks need no credentials
+func TestAuthInterceptor_HealthOpen(t *testing.T) {
+	conn, _ := setupTestServer(t, grpc.UnaryInterceptor(AuthInterceptor(tokenAuthenticator("token"))))
+
+	resp, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+}
@@ -0,0 +1,218 @@
+package grpcserver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/GoodsChain/user/internal/filter"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/pkg/userpb"
+	"github.com/google/uuid"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Defaults of list requests, as for GET /api/v1/users
+const (
+	defaultPage     = 1
+	defaultPageSize = 10
+	defaultSort     = "created_at"
+	defaultOrder    = "asc"
+)
+
+// toUser converts a user to its protobuf message
+func toUser(user *models.User) *userpb.User {
+	message := &userpb.User{
+		Id:         user.ID.String(),
+		Email:      user.Email,
+		FullName:   user.FullName,
+		Phone:      user.Phone,
+		Role:       user.Role,
+		IsActive:   user.IsActive,
+		ManagerId:  optionalID(user.ManagerID),
+		MergedInto: optionalID(user.MergedInto),
+		CreatedAt:  timestamppb.New(user.CreatedAt),
+		UpdatedAt:  timestamppb.New(user.UpdatedAt),
+	}
+	if user.ErasedAt != nil {
+		message.ErasedAt = timestamppb.New(*user.ErasedAt)
+	}
+	return message
+}
+
+func optionalID(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func toListUsersResponse(response *models.GetUsersResponse) *userpb.ListUsersResponse {
+	users := make([]*userpb.User, len(response.Data))
+	for i := range response.Data {
+		users[i] = toUser(&response.Data[i])
+	}
+
+	pagination := response.Pagination
+	return &userpb.ListUsersResponse{
+		Users: users,
+		Pagination: &userpb.PaginationMetadata{
+			Page:       int32(pagination.Page),
+			PageSize:   int32(pagination.PageSize),
+			Total:      int32(pagination.Total),
+			TotalPages: int32(pagination.TotalPages),
+			HasNext:    pagination.HasNext,
+			HasPrev:    pagination.HasPrev,
+		},
+	}
+}
+
+// toFilterParams converts and type-checks list filters. Plain dates in the expression are
+// interpreted in UTC.
+func toFilterParams(message *userpb.FilterParams) (*models.FilterParams, error) {
+	filters := &models.FilterParams{}
+	if message == nil {
+		return filters, nil
+	}
+
+	filters.Role = message.Role
+	filters.IsActive = message.IsActive
+	filters.Search = message.Search
+	filters.EmailDomain = message.EmailDomain
+	filters.CreatedFrom = optionalTime(message.CreatedFrom)
+	filters.CreatedTo = optionalTime(message.CreatedTo)
+	filters.UpdatedFrom = optionalTime(message.UpdatedFrom)
+	filters.UpdatedTo = optionalTime(message.UpdatedTo)
+	if filters.CreatedFrom != nil && filters.CreatedTo != nil && !filters.CreatedFrom.Before(*filters.CreatedTo) {
+		return nil, fmt.Errorf("invalid created range: created_from must be before created_to")
+	}
+	if filters.UpdatedFrom != nil && filters.UpdatedTo != nil && !filters.UpdatedFrom.Before(*filters.UpdatedTo) {
+		return nil, fmt.Errorf("invalid updated range: updated_from must be before updated_to")
+	}
+
+	var err error
+	if filters.ManagerID, err = parseFilterID("manager_id", message.ManagerId); err != nil {
+		return nil, err
+	}
+	if filters.UnderManager, err = parseFilterID("under_manager", message.UnderManager); err != nil {
+		return nil, err
+	}
+
+	if filters.Conditions, err = toFilterConditions(message.GetConditions()); err != nil {
+		return nil, err
+	}
+	for _, group := range message.GetAnyOf() {
+		conditions, err := toFilterConditions(group.GetConditions())
+		if err != nil {
+			return nil, err
+		}
+		if len(conditions) == 0 {
+			return nil, fmt.Errorf("invalid filter: empty OR group")
+		}
+		filters.AnyOf = append(filters.AnyOf, conditions)
+	}
+
+	if message.GetExpression() != "" {
+		expression, err := filter.Parse(message.GetExpression())
+		if err != nil {
+			return nil, err
+		}
+		if err := filter.Check(expression, models.UserFilterSchema); err != nil {
+			return nil, err
+		}
+		filters.Expression = filter.InLocation(expression, models.UserFilterSchema, time.UTC)
+	}
+
+	return filters, nil
+}
+
+// toFilterConditions converts conditions, checking their values against the field types of
+// filter expressions so that malformed IDs and booleans never reach the database
+func toFilterConditions(messages []*userpb.FilterCondition) ([]models.FilterCondition, error) {
+	var conditions []models.FilterCondition
+	for _, message := range messages {
+		condition := models.FilterCondition{
+			Field:  message.GetField(),
+			Values: message.GetValues(),
+			IsNull: message.GetIsNull(),
+			Negate: message.GetNegate(),
+		}
+
+		check := filter.Comparison{Field: condition.Field, Operator: filter.OpIn, Args: condition.Values}
+		if condition.IsNull {
+			check = filter.Comparison{Field: condition.Field, Operator: filter.OpIsNull, Args: []string{"true"}}
+		}
+		if err := filter.Check(check, models.UserFilterSchema); err != nil {
+			return nil, err
+		}
+		if condition.Field == "role" {
+			for _, role := range condition.Values {
+				if role != "admin" && role != "staff" && role != "supplier" {
+					return nil, fmt.Errorf("invalid role: %q", role)
+				}
+			}
+		}
+
+		conditions = append(conditions, condition)
+	}
+	return conditions, nil
+}
+
+func parseFilterID(field string, raw *string) (*uuid.UUID, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s format: %w", field, err)
+	}
+	return &id, nil
+}
+
+func optionalTime(timestamp *timestamppb.Timestamp) *time.Time {
+	if timestamp == nil {
+		return nil
+	}
+	t := timestamp.AsTime()
+	return &t
+}
+
+// toSortParams converts sort keys, defaulting to created_at ascending
+func toSortParams(message *userpb.SortParams) *models.SortParams {
+	if fields := message.GetFields(); len(fields) > 0 {
+		sort := &models.SortParams{}
+		for _, field := range fields {
+			sort.Fields = append(sort.Fields, models.SortField{
+				Field: field.GetField(),
+				Order: field.GetOrder(),
+				Nulls: field.GetNulls(),
+			})
+		}
+		return sort
+	}
+
+	sort := &models.SortParams{Field: defaultSort, Order: defaultOrder}
+	if message.GetField() != "" {
+		sort.Field = message.GetField()
+	}
+	if message.GetOrder() != "" {
+		sort.Order = message.GetOrder()
+	}
+	return sort
+}
+
+// toPaginationParams converts pagination, filling in defaults for unset values
+func toPaginationParams(message *userpb.PaginationParams) *models.PaginationParams {
+	pagination := &models.PaginationParams{
+		Page:     int(message.GetPage()),
+		PageSize: int(message.GetPageSize()),
+	}
+	if pagination.Page == 0 {
+		pagination.Page = defaultPage
+	}
+	if pagination.PageSize == 0 {
+		pagination.PageSize = defaultPageSize
+	}
+	pagination.Offset = (pagination.Page - 1) * pagination.PageSize
+	return pagination
+}
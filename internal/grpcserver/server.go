@@ -0,0 +1,214 @@
+// Package grpcserver exposes the user repository over gRPC, alongside the REST API.
+//
+// Requests are validated with the same rules as their REST counterparts, and repository errors
+// are mapped to the status codes equivalent to the REST responses.
+package grpcserver
+
+import (
+	"context"
+	"reflect"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/GoodsChain/user/pkg/userpb"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+)
+
+// UserServer implements userpb.UserServiceServer on top of a UserRepository
+type UserServer struct {
+	userpb.UnimplementedUserServiceServer
+
+	userRepo  repository.UserRepository
+	validator *validator.Validate
+}
+
+// NewUserServer creates a new UserServer
+func NewUserServer(userRepo repository.UserRepository) *UserServer {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+
+	return &UserServer{
+		userRepo:  userRepo,
+		validator: v,
+	}
+}
+
+// NewServer creates a gRPC server with the user service, the standard health service reporting
+// it as serving, and server reflection for tools such as grpcurl
+func NewServer(userRepo repository.UserRepository, opts ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(opts...)
+	userpb.RegisterUserServiceServer(server, NewUserServer(userRepo))
+
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(userpb.UserService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+	healthpb.RegisterHealthServer(server, healthServer)
+
+	reflection.Register(server)
+	return server
+}
+
+// CreateUser creates an active user
+func (s *UserServer) CreateUser(ctx context.Context, req *userpb.CreateUserRequest) (*userpb.User, error) {
+	managerID, err := parseOptionalID("manager_id", req.ManagerId)
+	if err != nil {
+		return nil, err
+	}
+
+	create := models.CreateUserRequest{
+		Email:     req.GetEmail(),
+		FullName:  req.GetFullName(),
+		Phone:     req.Phone,
+		Role:      req.GetRole(),
+		ManagerID: managerID,
+	}
+	if err := s.validator.Struct(create); err != nil {
+		return nil, validationStatus(err)
+	}
+
+	createdUser, err := s.userRepo.CreateUser(ctx, &models.User{
+		Email:     create.Email,
+		FullName:  create.FullName,
+		Phone:     create.Phone,
+		Role:      create.Role,
+		ManagerID: create.ManagerID,
+	})
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.Contains(errMsg, "manager not found"):
+			return nil, status.Error(codes.InvalidArgument, "manager not found")
+		case strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists"):
+			return nil, status.Error(codes.AlreadyExists, "email already exists")
+		default:
+			return nil, repositoryStatus(err, "failed to create user")
+		}
+	}
+
+	return toUser(createdUser), nil
+}
+
+// GetUser returns a user by ID. Merged users are returned as stored, with merged_into set.
+func (s *UserServer) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.User, error) {
+	userID, err := parseUserID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.GetUserByID(ctx, userID, nil)
+	if err != nil {
+		return nil, repositoryStatus(err, "failed to retrieve user")
+	}
+
+	return toUser(user), nil
+}
+
+// UpdateUser updates the fields that are set
+func (s *UserServer) UpdateUser(ctx context.Context, req *userpb.UpdateUserRequest) (*userpb.User, error) {
+	userID, err := parseUserID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+	managerID, err := parseOptionalID("manager_id", req.ManagerId)
+	if err != nil {
+		return nil, err
+	}
+
+	update := models.UpdateUserRequest{
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Phone:     req.Phone,
+		Role:      req.Role,
+		IsActive:  req.IsActive,
+		ManagerID: managerID,
+	}
+	if update.Email == nil && update.FullName == nil && update.Phone == nil && update.Role == nil && update.IsActive == nil && update.ManagerID == nil {
+		return nil, status.Error(codes.InvalidArgument, "at least one field must be provided for update")
+	}
+	if err := s.validator.Struct(update); err != nil {
+		return nil, validationStatus(err)
+	}
+
+	updatedUser, err := s.userRepo.UpdateUser(ctx, userID, &update)
+	if err != nil {
+		return nil, updateStatus(err)
+	}
+
+	return toUser(updatedUser), nil
+}
+
+// DeleteUser deletes a user and returns it
+func (s *UserServer) DeleteUser(ctx context.Context, req *userpb.DeleteUserRequest) (*userpb.User, error) {
+	userID, err := parseUserID(req.GetId())
+	if err != nil {
+		return nil, err
+	}
+
+	deletedUser, err := s.userRepo.DeleteUser(ctx, userID)
+	if err != nil {
+		return nil, repositoryStatus(err, "failed to delete user")
+	}
+
+	return toUser(deletedUser), nil
+}
+
+// ListUsers returns a page of the users matching a filter
+func (s *UserServer) ListUsers(ctx context.Context, req *userpb.ListUsersRequest) (*userpb.ListUsersResponse, error) {
+	filters, err := toFilterParams(req.GetFilter())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	sort := toSortParams(req.GetSort())
+	if err := s.validator.Struct(sort); err != nil {
+		return nil, validationStatus(err)
+	}
+	pagination := toPaginationParams(req.GetPagination())
+	if err := s.validator.Struct(pagination); err != nil {
+		return nil, validationStatus(err)
+	}
+
+	response, err := s.userRepo.GetAllUsers(ctx, filters, sort, pagination, nil)
+	if err != nil {
+		// Filters and sort keys the repository cannot express are the caller's mistake
+		if strings.HasPrefix(err.Error(), "invalid ") {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		return nil, repositoryStatus(err, "failed to retrieve users")
+	}
+
+	return toListUsersResponse(response), nil
+}
+
+// parseUserID parses the ID of the user a request refers to
+func parseUserID(raw string) (uuid.UUID, error) {
+	id, err := uuid.Parse(raw)
+	if err != nil {
+		return uuid.Nil, status.Error(codes.InvalidArgument, "invalid user ID format")
+	}
+	return id, nil
+}
+
+// parseOptionalID parses an optional UUID field, which is nil when unset
+func parseOptionalID(field string, raw *string) (*uuid.UUID, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	id, err := uuid.Parse(*raw)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid %s format", field)
+	}
+	return &id, nil
+}
@@ -0,0 +1,333 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/GoodsChain/user/pkg/userpb"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// mockUserRepository mocks the repository methods the gRPC server uses; the embedded interface
+// panics if any other method is called
+type mockUserRepository struct {
+	mock.Mock
+	repository.UserRepository
+}
+
+func (m *mockUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	args := m.Called(ctx, id, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
+	args := m.Called(ctx, id, updates)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	args := m.Called(ctx, filters, sort, pagination, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GetUsersResponse), args.Error(1)
+}
+
+// setupTestServer serves the gRPC server over an in-memory listener and returns a connection to it
+func setupTestServer(t *testing.T, opts ...grpc.ServerOption) (*grpc.ClientConn, *mockUserRepository) {
+	mockRepo := &mockUserRepository{}
+	listener := bufconn.Listen(1 << 20)
+	server := NewServer(mockRepo, opts...)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn, mockRepo
+}
+
+func testUser() *models.User {
+	phone := "+62 812 0000"
+	managerID := uuid.New()
+	now := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	return &models.User{
+		ID:        uuid.New(),
+		Email:     "ayu@example.com",
+		FullName:  "Ayu Lestari",
+		Phone:     &phone,
+		Role:      "staff",
+		IsActive:  true,
+		ManagerID: &managerID,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func timestamppbDate(year int, month time.Month, day int) *timestamppb.Timestamp {
+	return timestamppb.New(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// TestCreateUser tests that created users are converted to messages and invalid requests are rejected
+func TestCreateUser(t *testing.T) {
+	conn, mockRepo := setupTestServer(t)
+	client := userpb.NewUserServiceClient(conn)
+	user := testUser()
+
+	mockRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+		return u.Email == user.Email && *u.ManagerID == *user.ManagerID
+	})).Return(user, nil)
+
+	created, err := client.CreateUser(context.Background(), &userpb.CreateUserRequest{
+		Email:     user.Email,
+		FullName:  user.FullName,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		ManagerId: proto.String(user.ManagerID.String()),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, user.ID.String(), created.GetId())
+	assert.Equal(t, *user.Phone, created.GetPhone())
+	assert.Equal(t, user.ManagerID.String(), created.GetManagerId())
+	assert.Nil(t, created.MergedInto)
+	assert.Nil(t, created.ErasedAt)
+	assert.True(t, created.GetCreatedAt().AsTime().Equal(user.CreatedAt))
+
+	_, err = client.CreateUser(context.Background(), &userpb.CreateUserRequest{Email: "not-an-email", Role: "owner"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	require.Len(t, st.Details(), 1)
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	var fields []string
+	for _, violation := range badRequest.GetFieldViolations() {
+		fields = append(fields, violation.GetField())
+	}
+	assert.Equal(t, []string{"email", "full_name", "role"}, fields)
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 1)
+}
+
+// TestStatusCodes tests that repository errors map to the status codes equivalent to the REST responses
+func TestStatusCodes(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name      string
+		setupMock func(*mockUserRepository)
+		call      func(userpb.UserServiceClient) error
+		code      codes.Code
+	}{
+		{"InvalidID", nil, func(c userpb.UserServiceClient) error {
+			_, err := c.GetUser(context.Background(), &userpb.GetUserRequest{Id: "not-a-uuid"})
+			return err
+		}, codes.InvalidArgument},
+		{"NotFound", func(m *mockUserRepository) {
+			m.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, errors.New("user not found"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.GetUser(context.Background(), &userpb.GetUserRequest{Id: userID.String()})
+			return err
+		}, codes.NotFound},
+		{"ManagerNotFound", func(m *mockUserRepository) {
+			m.On("CreateUser", mock.Anything, mock.Anything).Return(nil, errors.New("manager not found"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.CreateUser(context.Background(), &userpb.CreateUserRequest{Email: "a@example.com", FullName: "A", Role: "staff", ManagerId: proto.String(uuid.NewString())})
+			return err
+		}, codes.InvalidArgument},
+		{"CreateEmailConflict", func(m *mockUserRepository) {
+			m.On("CreateUser", mock.Anything, mock.Anything).Return(nil, errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.CreateUser(context.Background(), &userpb.CreateUserRequest{Email: "taken@example.com", FullName: "A", Role: "staff"})
+			return err
+		}, codes.AlreadyExists},
+		{"EmptyUpdate", nil, func(c userpb.UserServiceClient) error {
+			_, err := c.UpdateUser(context.Background(), &userpb.UpdateUserRequest{Id: userID.String()})
+			return err
+		}, codes.InvalidArgument},
+		{"EmailConflict", func(m *mockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("duplicate key value violates unique constraint"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.UpdateUser(context.Background(), &userpb.UpdateUserRequest{Id: userID.String(), Email: proto.String("taken@example.com")})
+			return err
+		}, codes.AlreadyExists},
+		{"ManagerCycle", func(m *mockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("manager assignment would create a cycle"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.UpdateUser(context.Background(), &userpb.UpdateUserRequest{Id: userID.String(), ManagerId: proto.String(uuid.NewString())})
+			return err
+		}, codes.FailedPrecondition},
+		{"ConcurrentModification", func(m *mockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("user was modified concurrently"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.UpdateUser(context.Background(), &userpb.UpdateUserRequest{Id: userID.String(), IsActive: proto.Bool(false)})
+			return err
+		}, codes.Aborted},
+		{"UpdateErased", func(m *mockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("user has already been erased"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.UpdateUser(context.Background(), &userpb.UpdateUserRequest{Id: userID.String(), FullName: proto.String("Jane Doe")})
+			return err
+		}, codes.FailedPrecondition},
+		{"DeleteInternal", func(m *mockUserRepository) {
+			m.On("DeleteUser", mock.Anything, userID).Return(nil, errors.New("connection refused"))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.DeleteUser(context.Background(), &userpb.DeleteUserRequest{Id: userID.String()})
+			return err
+		}, codes.Internal},
+		{"PermissionDenied", func(m *mockUserRepository) {
+			m.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(nil, fmt.Errorf("%w: supplier may not list users", authz.ErrForbidden))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.ListUsers(context.Background(), &userpb.ListUsersRequest{})
+			return err
+		}, codes.PermissionDenied},
+		{"InvalidSort", func(m *mockUserRepository) {
+			m.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(nil, errors.New(`invalid sort field: "password"`))
+		}, func(c userpb.UserServiceClient) error {
+			_, err := c.ListUsers(context.Background(), &userpb.ListUsersRequest{Sort: &userpb.SortParams{Fields: []*userpb.SortField{{Field: "password", Order: "asc"}}}})
+			return err
+		}, codes.InvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mockRepo := setupTestServer(t)
+			if tt.setupMock != nil {
+				tt.setupMock(mockRepo)
+			}
+
+			err := tt.call(userpb.NewUserServiceClient(conn))
+			assert.Equal(t, tt.code, status.Code(err), err)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestListUsers tests that filter, sort and pagination messages become repository parameters
+func TestListUsers(t *testing.T) {
+	conn, mockRepo := setupTestServer(t)
+	client := userpb.NewUserServiceClient(conn)
+	user := testUser()
+	managerID := uuid.New()
+
+	mockRepo.On("GetAllUsers", mock.Anything,
+		mock.MatchedBy(func(f *models.FilterParams) bool {
+			return *f.Role == "staff" && *f.ManagerID == managerID && f.CreatedFrom.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) &&
+				len(f.Conditions) == 1 && f.Conditions[0].IsNull && f.Conditions[0].Field == "phone" &&
+				len(f.AnyOf) == 1 && len(f.AnyOf[0]) == 2 && f.Expression != nil
+		}),
+		&models.SortParams{Fields: []models.SortField{{Field: "role", Order: "desc"}, {Field: "phone", Order: "asc", Nulls: "last"}}},
+		&models.PaginationParams{Page: 2, PageSize: 10, Offset: 10},
+		[]string(nil),
+	).Return(&models.GetUsersResponse{
+		Data:       []models.User{*user},
+		Pagination: models.PaginationMetadata{Page: 2, PageSize: 10, Total: 11, TotalPages: 2, HasPrev: true},
+	}, nil)
+
+	response, err := client.ListUsers(context.Background(), &userpb.ListUsersRequest{
+		Filter: &userpb.FilterParams{
+			Role:        proto.String("staff"),
+			ManagerId:   proto.String(managerID.String()),
+			CreatedFrom: timestamppbDate(2026, 1, 1),
+			Conditions:  []*userpb.FilterCondition{{Field: "phone", IsNull: true}},
+			AnyOf: []*userpb.FilterGroup{{Conditions: []*userpb.FilterCondition{
+				{Field: "role", Values: []string{"admin"}},
+				{Field: "is_active", Values: []string{"false"}},
+			}}},
+			Expression: "email=like=*@example.com",
+		},
+		Sort: &userpb.SortParams{Fields: []*userpb.SortField{
+			{Field: "role", Order: "desc"},
+			{Field: "phone", Order: "asc", Nulls: "last"},
+		}},
+		Pagination: &userpb.PaginationParams{Page: 2},
+	})
+	require.NoError(t, err)
+	require.Len(t, response.GetUsers(), 1)
+	assert.Equal(t, user.Email, response.GetUsers()[0].GetEmail())
+	assert.Equal(t, int32(11), response.GetPagination().GetTotal())
+	assert.True(t, response.GetPagination().GetHasPrev())
+}
+
+// TestListUsers_InvalidRequests tests that malformed filters and pagination never reach the repository
+func TestListUsers_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		request *userpb.ListUsersRequest
+	}{
+		{"ConditionUUID", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{Conditions: []*userpb.FilterCondition{{Field: "id", Values: []string{"nope"}}}}}},
+		{"ConditionRole", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{Conditions: []*userpb.FilterCondition{{Field: "role", Values: []string{"owner"}}}}}},
+		{"ConditionNotNullable", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{Conditions: []*userpb.FilterCondition{{Field: "email", IsNull: true}}}}},
+		{"EmptyGroup", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{AnyOf: []*userpb.FilterGroup{{}}}}},
+		{"Expression", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{Expression: "is_active=like=true"}}},
+		{"ManagerID", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{ManagerId: proto.String("nope")}}},
+		{"CreatedRange", &userpb.ListUsersRequest{Filter: &userpb.FilterParams{CreatedFrom: timestamppbDate(2026, 2, 1), CreatedTo: timestamppbDate(2026, 1, 1)}}},
+		{"SortField", &userpb.ListUsersRequest{Sort: &userpb.SortParams{Field: "password"}}},
+		{"PageSize", &userpb.ListUsersRequest{Pagination: &userpb.PaginationParams{PageSize: 500}}},
+		{"NegativePage", &userpb.ListUsersRequest{Pagination: &userpb.PaginationParams{Page: -1}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mockRepo := setupTestServer(t)
+
+			_, err := userpb.NewUserServiceClient(conn).ListUsers(context.Background(), tt.request)
+			assert.Equal(t, codes.InvalidArgument, status.Code(err), err)
+			mockRepo.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestHealth tests that the health service reports the user service as serving
+func TestHealth(t *testing.T) {
+	conn, _ := setupTestServer(t)
+
+	response, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: userpb.UserService_ServiceDesc.ServiceName,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.GetStatus())
+}
@@ -0,0 +1,77 @@
+package grpcserver
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/go-playground/validator/v10"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// validationStatus reports failed validation rules as an InvalidArgument status with one
+// BadRequest field violation per rule, named as in the JSON API
+func validationStatus(err error) error {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(validationErrors))
+	descriptions := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		description := fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
+		if fe.Param() != "" {
+			description = fmt.Sprintf("%s failed the %s=%s rule", fe.Field(), fe.Tag(), fe.Param())
+		}
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       fe.Field(),
+			Description: description,
+		})
+		descriptions = append(descriptions, description)
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, strings.Join(descriptions, "; ")).
+		WithDetails(&errdetails.BadRequest{FieldViolations: violations})
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, strings.Join(descriptions, "; "))
+	}
+	return st.Err()
+}
+
+// updateStatus maps an error from updating a user to a status, as writeUpdateError does for REST
+func updateStatus(err error) error {
+	errMsg := err.Error()
+	switch {
+	case strings.Contains(errMsg, "manager not found"):
+		return status.Error(codes.InvalidArgument, "manager not found")
+	case strings.Contains(errMsg, "would create a cycle"):
+		return status.Error(codes.FailedPrecondition, "manager assignment would create a cycle")
+	case strings.Contains(errMsg, "modified concurrently"):
+		return status.Error(codes.Aborted, "user was modified concurrently, retry the request")
+	case strings.Contains(errMsg, "no fields to update"):
+		return status.Error(codes.InvalidArgument, "no fields to update")
+	case strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists"):
+		return status.Error(codes.AlreadyExists, "email already exists")
+	case strings.Contains(errMsg, "already been merged"), strings.Contains(errMsg, "already been erased"):
+		return status.Error(codes.FailedPrecondition, errMsg)
+	default:
+		return repositoryStatus(err, "failed to update user")
+	}
+}
+
+// repositoryStatus maps an error of the user repository that the method does not map itself, as
+// writeRepositoryError does for REST. Other errors are reported with the fallback message.
+func repositoryStatus(err error, fallback string) error {
+	switch {
+	case errors.Is(err, authz.ErrForbidden):
+		return status.Error(codes.PermissionDenied, "insufficient permissions")
+	case strings.Contains(err.Error(), "user not found"):
+		return status.Error(codes.NotFound, "user not found")
+	default:
+		return status.Error(codes.Internal, fallback)
+	}
+}
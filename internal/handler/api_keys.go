@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler handles the management of service API keys
+type APIKeyHandler struct {
+	apiKeyRepo repository.APIKeyRepository
+	validator  *validator.Validate
+	now        func() time.Time
+}
+
+// NewAPIKeyHandler creates a new instance of APIKeyHandler
+func NewAPIKeyHandler(apiKeyRepo repository.APIKeyRepository) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyRepo: apiKeyRepo,
+		validator:  requestValidator,
+		now:        time.Now,
+	}
+}
+
+// CreateAPIKey generates a key for a service principal. The response is the only time the secret
+// key is returned.
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(h.now()) {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "expires_at must be in the future")
+		return
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		log.Printf("Error generating api key: %v", err)
+		writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to create api key")
+		return
+	}
+
+	created, err := h.apiKeyRepo.CreateAPIKey(c.Request.Context(), &models.APIKey{
+		Name:      req.Name,
+		Service:   req.Service,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    req.Scopes,
+		ExpiresAt: req.ExpiresAt,
+	})
+	if err != nil {
+		log.Printf("Error creating api key: %v", err)
+		writeRepositoryError(c, err, "failed to create api key")
+		return
+	}
+
+	log.Printf("Created api key %s for service %q", created.Prefix, created.Service)
+	c.JSON(http.StatusCreated, models.CreateAPIKeyResponse{APIKey: *created, Key: key})
+}
+
+// ListAPIKeys lists the keys, optionally of one service, without their secrets
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	var req models.ListAPIKeysRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	keys, err := h.apiKeyRepo.ListAPIKeys(c.Request.Context(), &req)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve api keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ListAPIKeysResponse{APIKeys: keys})
+}
+
+// RevokeAPIKey revokes a key, which is rejected from then on. Revoking a revoked key succeeds and
+// keeps the time of the first revocation.
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "invalid API key ID format")
+		return
+	}
+
+	key, err := h.apiKeyRepo.RevokeAPIKey(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "api key not found") {
+			writeProblem(c, http.StatusNotFound, models.CodeAPIKeyNotFound, "api key not found")
+			return
+		}
+		writeRepositoryError(c, err, "failed to revoke api key")
+		return
+	}
+
+	log.Printf("Revoked api key %s of service %q", key.Prefix, key.Service)
+	c.JSON(http.StatusOK, key)
+}
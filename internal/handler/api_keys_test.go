@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// MockAPIKeyRepository is a mock implementation of APIKeyRepository for testing
+type MockAPIKeyRepository struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	args := m.Called(ctx, prefix)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error) {
+	args := m.Called(ctx, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return m.Called(ctx, id, usedAt).Error(0)
+}
+
+// setupAPIKeyRouter serves the API key routes
+func setupAPIKeyRouter() (*gin.Engine, *MockAPIKeyRepository) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &MockAPIKeyRepository{}
+	h := NewAPIKeyHandler(mockRepo)
+
+	r := gin.New()
+	r.Use(RequestID())
+	apiKeys := r.Group("/api/v1/api-keys")
+	{
+		apiKeys.GET("/", h.ListAPIKeys)
+		apiKeys.POST("/", h.CreateAPIKey)
+		apiKeys.DELETE("/:id", h.RevokeAPIKey)
+	}
+	return r, mockRepo
+}
+
+func testAPIKey() *models.APIKey {
+	return &models.APIKey{
+		ID:        uuid.New(),
+		Name:      "nightly sync",
+		Service:   "erp-sync",
+		Prefix:    "gcu_0123456789ab",
+		KeyHash:   []byte{1, 2, 3},
+		Scopes:    []string{"users:read"},
+		CreatedAt: time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	router, mockRepo := setupAPIKeyRouter()
+
+	// The repository returns the key it was given
+	stored := &models.APIKey{}
+	mockRepo.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*models.APIKey")).
+		Run(func(args mock.Arguments) {
+			*stored = *args.Get(1).(*models.APIKey)
+			stored.ID = uuid.New()
+		}).
+		Return(stored, nil)
+
+	body := `{"name": "nightly sync", "service": "erp-sync", "scopes": ["users:read"], "expires_at": "2099-01-01T00:00:00Z"}`
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/", bytes.NewBufferString(body)))
+
+	require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	key := response["key"].(string)
+	assert.Equal(t, stored.Prefix, response["prefix"])
+	assert.Equal(t, stored.Prefix, key[:len(stored.Prefix)])
+	assert.Equal(t, "erp-sync", stored.Service)
+	assert.Equal(t, []string{"users:read"}, stored.Scopes)
+	assert.NotContains(t, response, "key_hash", "the hash is never returned")
+
+	// The stored hash verifies the returned key
+	mockRepo.On("GetAPIKeyByPrefix", mock.Anything, stored.Prefix).Return(stored, nil)
+	mockRepo.On("TouchAPIKey", mock.Anything, stored.ID, mock.Anything).Return(nil)
+	principal, err := auth.NewAPIKeyAuthenticator(mockRepo).Authenticate(context.Background(), key)
+	require.NoError(t, err)
+	assert.Equal(t, "erp-sync", principal.Subject)
+}
+
+func TestCreateAPIKey_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"missing name", `{"service": "erp-sync", "scopes": ["users:read"]}`, models.CodeValidationFailed},
+		{"no scopes", `{"name": "sync", "service": "erp-sync", "scopes": []}`, models.CodeValidationFailed},
+		{"scope with space", `{"name": "sync", "service": "erp-sync", "scopes": ["users:read users:write"]}`, models.CodeValidationFailed},
+		{"expired", `{"name": "sync", "service": "erp-sync", "scopes": ["users:read"], "expires_at": "2020-01-01T00:00:00Z"}`, models.CodeInvalidRequest},
+		{"malformed", `{"name": 1}`, models.CodeValidationFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockRepo := setupAPIKeyRouter()
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/api-keys/", bytes.NewBufferString(tt.body)))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			var problem models.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, tt.code, problem.Code)
+			mockRepo.AssertNotCalled(t, "CreateAPIKey", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestListAPIKeys(t *testing.T) {
+	router, mockRepo := setupAPIKeyRouter()
+
+	service := "erp-sync"
+	mockRepo.On("ListAPIKeys", mock.Anything, &models.ListAPIKeysRequest{Service: &service, IncludeRevoked: true}).
+		Return([]models.APIKey{*testAPIKey()}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/api-keys/?service=erp-sync&include_revoked=true", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var response models.ListAPIKeysResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.APIKeys, 1)
+	assert.Equal(t, "gcu_0123456789ab", response.APIKeys[0].Prefix)
+	assert.NotContains(t, w.Body.String(), "key_hash")
+}
+
+func TestRevokeAPIKey(t *testing.T) {
+	key := testAPIKey()
+	revokedAt := time.Now()
+	key.RevokedAt = &revokedAt
+
+	tests := []struct {
+		name           string
+		id             string
+		repoErr        error
+		expectedStatus int
+		expectedCode   string
+	}{
+		{name: "revoked", id: key.ID.String(), expectedStatus: http.StatusOK},
+		{name: "not found", id: key.ID.String(), repoErr: errors.New("api key not found"), expectedStatus: http.StatusNotFound, expectedCode: models.CodeAPIKeyNotFound},
+		{name: "invalid ID", id: "not-a-uuid", expectedStatus: http.StatusBadRequest, expectedCode: models.CodeInvalidRequest},
+		{name: "database error", id: key.ID.String(), repoErr: errors.New("connection refused"), expectedStatus: http.StatusInternalServerError, expectedCode: models.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, mockRepo := setupAPIKeyRouter()
+			if tt.repoErr != nil {
+				mockRepo.On("RevokeAPIKey", mock.Anything, key.ID).Return(nil, tt.repoErr)
+			} else {
+				mockRepo.On("RevokeAPIKey", mock.Anything, key.ID).Return(key, nil)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/api/v1/api-keys/"+tt.id, nil))
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedCode != "" {
+				var problem models.Problem
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedCode, problem.Code)
+				return
+			}
+			var revoked models.APIKey
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &revoked))
+			assert.NotNil(t, revoked.RevokedAt)
+		})
+	}
+}
+
+// TestAPIKeys_Forbidden tests that callers who may not manage API keys are answered with 403
+func TestAPIKeys_Forbidden(t *testing.T) {
+	router, mockRepo := setupAPIKeyRouter()
+	mockRepo.On("ListAPIKeys", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("%w: staff may not manage api keys", authz.ErrForbidden))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/api-keys/", nil))
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, models.CodeForbidden, problem.Code)
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// authRealm is the realm of the WWW-Authenticate challenges
+const authRealm = "user"
+
+// Authenticate returns middleware that requires an Authorization header with credentials one of
+// the authenticators accepts, chosen by the header's scheme. The caller's principal is stored in
+// the request context, where auth.FromContext finds it. Requests without valid credentials are
+// answered with 401 and a WWW-Authenticate challenge for every scheme.
+func Authenticate(authenticators ...auth.Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scheme, credentials, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+		credentials = strings.TrimSpace(credentials)
+		if !ok || credentials == "" {
+			challenge(c, authenticators)
+			writeProblem(c, http.StatusUnauthorized, models.CodeUnauthenticated, "missing credentials")
+			return
+		}
+
+		var authenticator auth.Authenticator
+		for _, a := range authenticators {
+			// Schemes are case-insensitive (RFC 9110, section 11.1)
+			if strings.EqualFold(a.Scheme(), scheme) {
+				authenticator = a
+				break
+			}
+		}
+		if authenticator == nil {
+			challenge(c, authenticators)
+			writeProblem(c, http.StatusUnauthorized, models.CodeUnauthenticated, "unsupported authorization scheme")
+			return
+		}
+
+		principal, err := authenticator.Authenticate(c.Request.Context(), credentials)
+		if err != nil {
+			if errors.Is(err, auth.ErrInvalidCredentials) {
+				log.Printf("Rejected %s credentials: %v", authenticator.Scheme(), err)
+				challenge(c, authenticators)
+				writeProblem(c, http.StatusUnauthorized, models.CodeUnauthenticated, "invalid credentials")
+				return
+			}
+			log.Printf("Error authenticating request: %v", err)
+			writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to authenticate request")
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), principal))
+		c.Next()
+	}
+}
+
+// challenge asks the client to authenticate with one of the authenticators' schemes
+func challenge(c *gin.Context, authenticators []auth.Authenticator) {
+	for _, a := range authenticators {
+		c.Writer.Header().Add("WWW-Authenticate", a.Scheme()+` realm="`+authRealm+`"`)
+	}
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAuthenticator accepts a single credential
+type stubAuthenticator struct {
+	scheme string
+	valid  string
+	err    error
+}
+
+func (a *stubAuthenticator) Scheme() string {
+	return a.scheme
+}
+
+func (a *stubAuthenticator) Authenticate(ctx context.Context, credentials string) (*auth.Principal, error) {
+	if a.err != nil {
+		return nil, a.err
+	}
+	if credentials != a.valid {
+		return nil, fmt.Errorf("%w: unknown credential", auth.ErrInvalidCredentials)
+	}
+	return &auth.Principal{Subject: a.scheme + "-caller", Scopes: []string{"users:read"}}, nil
+}
+
+func setupAuthRouter(authenticators ...auth.Authenticator) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestID(), Authenticate(authenticators...))
+	r.GET("/whoami", func(c *gin.Context) {
+		principal, ok := auth.FromContext(c.Request.Context())
+		if !ok {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"subject": principal.Subject})
+	})
+	return r
+}
+
+func TestAuthenticate(t *testing.T) {
+	router := setupAuthRouter(
+		&stubAuthenticator{scheme: "Bearer", valid: "token"},
+		&stubAuthenticator{scheme: "ApiKey", valid: "key"},
+	)
+
+	tests := []struct {
+		name           string
+		authorization  string
+		expectedStatus int
+		expectedDetail string
+		expectedCaller string
+	}{
+		{name: "bearer", authorization: "Bearer token", expectedStatus: http.StatusOK, expectedCaller: "Bearer-caller"},
+		{name: "scheme is case-insensitive", authorization: "bearer token", expectedStatus: http.StatusOK, expectedCaller: "Bearer-caller"},
+		{name: "second scheme", authorization: "ApiKey key", expectedStatus: http.StatusOK, expectedCaller: "ApiKey-caller"},
+		{name: "missing header", expectedStatus: http.StatusUnauthorized, expectedDetail: "missing credentials"},
+		{name: "scheme only", authorization: "Bearer", expectedStatus: http.StatusUnauthorized, expectedDetail: "missing credentials"},
+		{name: "empty credentials", authorization: "Bearer   ", expectedStatus: http.StatusUnauthorized, expectedDetail: "missing credentials"},
+		{name: "unsupported scheme", authorization: "Basic dXNlcjpwYXNz", expectedStatus: http.StatusUnauthorized, expectedDetail: "unsupported authorization scheme"},
+		{name: "invalid credentials", authorization: "Bearer forged", expectedStatus: http.StatusUnauthorized, expectedDetail: "invalid credentials"},
+		{name: "credentials of another scheme", authorization: "Bearer key", expectedStatus: http.StatusUnauthorized, expectedDetail: "invalid credentials"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.JSONEq(t, `{"subject": "`+tt.expectedCaller+`"}`, w.Body.String())
+				assert.Empty(t, w.Header().Values("WWW-Authenticate"))
+				return
+			}
+
+			assert.Equal(t, []string{`Bearer realm="user"`, `ApiKey realm="user"`}, w.Header().Values("WWW-Authenticate"))
+			var problem models.Problem
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, models.CodeUnauthenticated, problem.Code)
+			assert.Equal(t, tt.expectedDetail, problem.Detail)
+		})
+	}
+}
+
+func TestAuthenticate_Translated(t *testing.T) {
+	router := setupAuthRouter(&stubAuthenticator{scheme: "Bearer", valid: "token"})
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("Accept-Language", "nl")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Contains(t, w.Body.String(), "inloggegevens ontbreken")
+}
+
+func TestAuthenticate_Error(t *testing.T) {
+	router := setupAuthRouter(&stubAuthenticator{scheme: "Bearer", err: errors.New("key set unavailable")})
+
+	req := httptest.NewRequest(http.MethodGet, "/whoami", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Empty(t, w.Header().Values("WWW-Authenticate"))
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, models.CodeInternal, problem.Code)
+}
@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// userETag returns a strong entity tag for one representation of a user. updated_at is moved
+// by every change to the row, and the field list distinguishes sparse fieldsets.
+func userETag(user *models.User, fields []string) string {
+	return entityTag(fmt.Sprintf("%s|%d|%s", user.ID, user.UpdatedAt.UnixNano(), strings.Join(fields, ",")))
+}
+
+// listETag returns a strong entity tag for a page of users, derived from the normalised query,
+// the total and the version of every user on the page rather than from the encoded body
+func listETag(query string, response *models.GetUsersResponse) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s|%d", query, response.Pagination.Total)
+	for _, user := range response.Data {
+		fmt.Fprintf(&b, "|%s:%d", user.ID, user.UpdatedAt.UnixNano())
+	}
+	return entityTag(b.String())
+}
+
+func entityTag(version string) string {
+	sum := sha256.Sum256([]byte(version))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// lastModified returns the newest updated_at of the users, or the zero time if there are none
+func lastModified(users []models.User) time.Time {
+	var latest time.Time
+	for _, user := range users {
+		if user.UpdatedAt.After(latest) {
+			latest = user.UpdatedAt
+		}
+	}
+	return latest
+}
+
+// notModified sets the ETag and Last-Modified headers and reports whether the request's
+// preconditions allow answering 304 Not Modified. If-None-Match takes precedence over
+// If-Modified-Since, which is only evaluated when checkModifiedSince is set.
+func notModified(c *gin.Context, etag string, modified time.Time, checkModifiedSince bool) bool {
+	c.Header("ETag", etag)
+	if !modified.IsZero() {
+		c.Header("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+
+	if ifNoneMatch := c.GetHeader("If-None-Match"); ifNoneMatch != "" {
+		return etagListMatches(ifNoneMatch, etag)
+	}
+
+	if checkModifiedSince && !modified.IsZero() {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		// HTTP dates have a resolution of one second
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// etagListMatches reports whether an If-None-Match header matches etag, using weak comparison
+func etagListMatches(header, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
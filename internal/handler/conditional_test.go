@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// conditionalTestUser returns a user last modified at a fixed time with sub-second precision
+func conditionalTestUser() *models.User {
+	return &models.User{
+		ID:        uuid.New(),
+		Email:     "jane@example.com",
+		FullName:  "Jane Doe",
+		Role:      "staff",
+		IsActive:  true,
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2026, 3, 1, 9, 30, 15, 250000000, time.UTC),
+	}
+}
+
+// getWithHeaders sends a GET request with the given request headers
+func getWithHeaders(handler *UserHandler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	router := setupTestRouter(handler)
+	req, _ := http.NewRequest("GET", path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestGetUserByID_Validators tests that a user response carries a strong ETag and Last-Modified
+func TestGetUserByID_Validators(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	user := conditionalTestUser()
+	mockRepo.On("GetUserByID", mock.Anything, user.ID, []string(nil)).Return(user, nil)
+
+	w := getWithHeaders(handler, "/api/v1/users/"+user.ID.String(), nil)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, w.Header().Get("ETag"))
+	assert.Equal(t, "Sun, 01 Mar 2026 09:30:15 GMT", w.Header().Get("Last-Modified"))
+}
+
+// TestGetUserByID_Conditional tests If-None-Match and If-Modified-Since handling
+func TestGetUserByID_Conditional(t *testing.T) {
+	user := conditionalTestUser()
+	etag := userETag(user, nil)
+
+	tests := []struct {
+		name         string
+		headers      map[string]string
+		expectedCode int
+	}{
+		{"MatchingETag", map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+		{"WeakMatchingETag", map[string]string{"If-None-Match": "W/" + etag}, http.StatusNotModified},
+		{"ETagInList", map[string]string{"If-None-Match": `"stale", ` + etag}, http.StatusNotModified},
+		{"Wildcard", map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		{"StaleETag", map[string]string{"If-None-Match": `"stale"`}, http.StatusOK},
+		{"NotModifiedSince", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 09:30:15 GMT"}, http.StatusNotModified},
+		{"ModifiedSince", map[string]string{"If-Modified-Since": "Sun, 01 Mar 2026 09:30:14 GMT"}, http.StatusOK},
+		{"InvalidDate", map[string]string{"If-Modified-Since": "yesterday"}, http.StatusOK},
+		{"ETagTakesPrecedence", map[string]string{"If-None-Match": `"stale"`, "If-Modified-Since": "Mon, 02 Mar 2026 00:00:00 GMT"}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			mockRepo.On("GetUserByID", mock.Anything, user.ID, []string(nil)).Return(user, nil)
+
+			w := getWithHeaders(handler, "/api/v1/users/"+user.ID.String(), tt.headers)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, etag, w.Header().Get("ETag"))
+			if tt.expectedCode == http.StatusNotModified {
+				assert.Empty(t, w.Body.String())
+			} else {
+				assert.Contains(t, w.Body.String(), user.Email)
+			}
+		})
+	}
+}
+
+// TestUserETag tests that the entity tag changes with the row version and the representation
+func TestUserETag(t *testing.T) {
+	user := conditionalTestUser()
+	etag := userETag(user, nil)
+
+	assert.Equal(t, etag, userETag(user, nil))
+	assert.NotEqual(t, etag, userETag(user, []string{"id", "email"}))
+
+	modified := *user
+	modified.UpdatedAt = user.UpdatedAt.Add(time.Microsecond)
+	assert.NotEqual(t, etag, userETag(&modified, nil))
+}
+
+// TestGetUserByID_MergedHasNoValidators tests that redirects for merged users are not tagged
+func TestGetUserByID_MergedHasNoValidators(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	user := conditionalTestUser()
+	targetID := uuid.New()
+	user.MergedInto = &targetID
+	mockRepo.On("GetUserByID", mock.Anything, user.ID, []string(nil)).Return(user, nil)
+
+	w := getWithHeaders(handler, "/api/v1/users/"+user.ID.String(), map[string]string{"If-None-Match": "*"})
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Empty(t, w.Header().Get("ETag"))
+}
+
+// TestGetAllUsers_Conditional tests list validators and that lists only honour If-None-Match
+func TestGetAllUsers_Conditional(t *testing.T) {
+	older := conditionalTestUser()
+	newer := conditionalTestUser()
+	newer.UpdatedAt = older.UpdatedAt.Add(time.Hour)
+	response := &models.GetUsersResponse{
+		Data:       []models.User{*older, *newer},
+		Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, Total: 2, TotalPages: 1},
+	}
+
+	first := func(headers map[string]string) *httptest.ResponseRecorder {
+		handler, mockRepo := setupTestHandler()
+		mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(response, nil)
+		return getWithHeaders(handler, "/api/v1/users/?role=staff", headers)
+	}
+
+	w := first(nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	etag := w.Header().Get("ETag")
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "Sun, 01 Mar 2026 10:30:15 GMT", w.Header().Get("Last-Modified"))
+
+	w = first(map[string]string{"If-None-Match": etag})
+	assert.Equal(t, http.StatusNotModified, w.Code)
+	assert.Empty(t, w.Body.String())
+
+	w = first(map[string]string{"If-Modified-Since": "Mon, 02 Mar 2026 00:00:00 GMT"})
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+// TestListETag tests that the list entity tag covers the query, the total and every row version
+func TestListETag(t *testing.T) {
+	user := conditionalTestUser()
+	response := &models.GetUsersResponse{
+		Data:       []models.User{*user},
+		Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, Total: 1, TotalPages: 1},
+	}
+	etag := listETag("role=staff", response)
+
+	assert.NotEqual(t, etag, listETag("role=admin", response))
+
+	moreUsers := *response
+	moreUsers.Pagination.Total = 11
+	assert.NotEqual(t, etag, listETag("role=staff", &moreUsers))
+
+	modified := *user
+	modified.UpdatedAt = modified.UpdatedAt.Add(time.Second)
+	assert.NotEqual(t, etag, listETag("role=staff", &models.GetUsersResponse{Data: []models.User{modified}, Pagination: response.Pagination}))
+
+	empty := &models.GetUsersResponse{Data: []models.User{}, Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, TotalPages: 1}}
+	assert.Equal(t, listETag("", empty), listETag("", empty))
+	assert.True(t, lastModified(empty.Data).IsZero())
+}
+
+// TestGetUserByEmail_Conditional tests that lookups by email are conditional too
+func TestGetUserByEmail_Conditional(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	user := conditionalTestUser()
+	mockRepo.On("GetUserByEmail", mock.Anything, user.Email, []string(nil)).Return(user, nil)
+
+	w := getWithHeaders(handler, fmt.Sprintf("/api/v1/users/by-email/%s", user.Email), map[string]string{"If-None-Match": userETag(user, nil)})
+
+	assert.Equal(t, http.StatusNotModified, w.Code)
+}
@@ -118,10 +118,10 @@ func TestCreateUser_MalformedJSON(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "invalid character")
+	assert.Contains(t, response.Detail, "invalid character")
 }
 
 // TestCreateUser_InvalidEmail tests validation of invalid email format
@@ -144,10 +144,10 @@ func TestCreateUser_InvalidEmail(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "email")
+	assert.Contains(t, response.Detail, "email")
 }
 
 // TestCreateUser_EmptyEmail tests validation of empty email
@@ -170,10 +170,10 @@ func TestCreateUser_EmptyEmail(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "required")
+	assert.Contains(t, response.Detail, "required")
 }
 
 // TestCreateUser_EmptyFullName tests validation of empty full name
@@ -196,10 +196,10 @@ func TestCreateUser_EmptyFullName(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "required")
+	assert.Contains(t, response.Detail, "required")
 }
 
 // TestCreateUser_InvalidRole tests validation of invalid role
@@ -222,10 +222,10 @@ func TestCreateUser_InvalidRole(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "Role")
+	assert.Contains(t, response.Detail, "role must be one of")
 }
 
 // TestCreateUser_RepositoryError tests handling of repository errors
@@ -250,10 +250,10 @@ func TestCreateUser_RepositoryError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "database connection failed", response["error"])
+	assert.Equal(t, "failed to create user", response.Detail, "internal errors are not disclosed")
 
 	mockRepo.AssertExpectations(t)
 }
@@ -322,12 +322,13 @@ func TestCreateUser_DuplicateEmail(t *testing.T) {
 
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, http.StatusConflict, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "duplicate key value violates unique constraint", response["error"])
+	assert.Equal(t, models.CodeEmailConflict, response.Code)
+	assert.Equal(t, "email already exists", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -59,10 +59,10 @@ func TestDeleteUser_InvalidUUID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "invalid user ID format", response["error"])
+	assert.Equal(t, "invalid user ID format", response.Detail)
 }
 
 // TestDeleteUser_UserNotFound tests handling when user doesn't exist
@@ -80,10 +80,10 @@ func TestDeleteUser_UserNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "user not found", response["error"])
+	assert.Equal(t, "user not found", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -103,10 +103,10 @@ func TestDeleteUser_DatabaseError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "failed to delete user", response["error"])
+	assert.Equal(t, "failed to delete user", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EraseUser handles erasing a user's personal data and returns a signed erasure receipt
+func (h *UserHandler) EraseUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	// Refuse before erasing anything if we could not hand out a receipt afterwards
+	if h.receiptSigner == nil {
+		writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "erasure receipts are not configured")
+		return
+	}
+
+	result, err := h.userRepo.EraseUser(c.Request.Context(), userID)
+	if err != nil {
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "already been erased") {
+			writeProblem(c, http.StatusConflict, models.CodeUserAlreadyErased, "user has already been erased")
+			return
+		}
+		writeRepositoryError(c, err, "failed to erase user")
+		return
+	}
+
+	erasureReceipt := models.ErasureReceipt{
+		ReceiptID:     uuid.New(),
+		ErasureResult: *result,
+		Algorithm:     receipt.Algorithm,
+	}
+	signature, err := h.receiptSigner.Sign(erasureReceipt)
+	if err != nil {
+		writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to sign erasure receipt")
+		return
+	}
+	erasureReceipt.Signature = signature
+
+	c.JSON(http.StatusOK, erasureReceipt)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEraseUser_Success tests that an erasure returns a verifiable signed receipt
+func TestEraseUser_Success(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	signer := receipt.NewSigner([]byte("test-secret"))
+	router := setupTestRouter(NewUserHandler(mockRepo, WithReceiptSigner(signer)))
+
+	userID := uuid.New()
+	result := &models.ErasureResult{
+		UserID:                 userID,
+		ErasedUserIDs:          []uuid.UUID{userID},
+		ErasedFields:           []string{"email", "full_name", "phone"},
+		HistoryEntriesScrubbed: 2,
+		ErasedAt:               time.Now().UTC().Truncate(time.Second),
+	}
+	mockRepo.On("EraseUser", mock.Anything, userID).Return(result, nil)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/erase", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ErasureReceipt
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, userID, response.UserID)
+	assert.Equal(t, receipt.Algorithm, response.Algorithm)
+	assert.NotEqual(t, uuid.Nil, response.ReceiptID)
+
+	signature := response.Signature
+	response.Signature = ""
+	valid, err := signer.Verify(response, signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestEraseUser_SignerNotConfigured tests that nothing is erased without a receipt signer
+func TestEraseUser_SignerNotConfigured(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/erase", uuid.New()), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockRepo.AssertNotCalled(t, "EraseUser")
+}
+
+// TestEraseUser_Errors tests mapping of repository errors to status codes
+func TestEraseUser_Errors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		repoErr        error
+		expectedStatus int
+	}{
+		{"UserNotFound", fmt.Errorf("user not found: sql: no rows in result set"), http.StatusNotFound},
+		{"AlreadyErased", fmt.Errorf("user has already been erased"), http.StatusConflict},
+		{"DatabaseError", fmt.Errorf("failed to commit erasure"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := &MockUserRepository{}
+			router := setupTestRouter(NewUserHandler(mockRepo, WithReceiptSigner(receipt.NewSigner([]byte("k")))))
+
+			mockRepo.On("EraseUser", mock.Anything, mock.Anything).Return(nil, tc.repoErr)
+
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/erase", uuid.New()), nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ExportUserData handles a subject access request, returning everything stored about a user
+func (h *UserHandler) ExportUserData(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	var req models.ExportUserDataRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	export, err := h.userRepo.ExportUserData(c.Request.Context(), userID)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to export user data")
+		return
+	}
+
+	filename := fmt.Sprintf("user-%s-export.json", userID)
+
+	if req.Format == nil || *req.Format == "json" {
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+		c.JSON(http.StatusOK, export)
+		return
+	}
+
+	archive, err := zipExport(filename, export)
+	if err != nil {
+		writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to export user data")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", strings.TrimSuffix(filename, ".json")+".zip"))
+	c.Data(http.StatusOK, "application/zip", archive)
+}
+
+// zipExport packs an export into a zip archive holding a single JSON file
+func zipExport(filename string, export *models.UserDataExport) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	w, err := zw.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create archive entry: %w", err)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(export); err != nil {
+		return nil, fmt.Errorf("failed to encode export: %w", err)
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
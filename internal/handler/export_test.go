@@ -0,0 +1,120 @@
+package handler
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleExport(userID uuid.UUID) *models.UserDataExport {
+	return &models.UserDataExport{
+		UserID:      userID,
+		GeneratedAt: time.Now(),
+		Tables: map[string][]json.RawMessage{
+			"users":        {json.RawMessage(`{"email":"jane@example.com"}`)},
+			"user_history": {},
+		},
+	}
+}
+
+// TestExportUserData_JSON tests the default JSON export
+func TestExportUserData_JSON(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	mockRepo.On("ExportUserData", mock.Anything, userID).Return(sampleExport(userID), nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/export", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+
+	var response models.UserDataExport
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, userID, response.UserID)
+	assert.JSONEq(t, `{"email":"jane@example.com"}`, string(response.Tables["users"][0]))
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestExportUserData_Zip tests the zipped export
+func TestExportUserData_Zip(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	mockRepo.On("ExportUserData", mock.Anything, userID).Return(sampleExport(userID), nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/export?format=zip", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	require.NoError(t, err)
+	require.Len(t, zr.File, 1)
+	assert.Equal(t, fmt.Sprintf("user-%s-export.json", userID), zr.File[0].Name)
+
+	f, err := zr.File[0].Open()
+	require.NoError(t, err)
+	defer f.Close()
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+
+	var response models.UserDataExport
+	require.NoError(t, json.Unmarshal(content, &response))
+	assert.Equal(t, userID, response.UserID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestExportUserData_InvalidFormat tests rejection of unsupported formats
+func TestExportUserData_InvalidFormat(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/export?format=xml", uuid.New()), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "ExportUserData")
+}
+
+// TestExportUserData_UserNotFound tests handling when the user doesn't exist
+func TestExportUserData_UserNotFound(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	mockRepo.On("ExportUserData", mock.Anything, userID).Return(nil, fmt.Errorf("user not found"))
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/export", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportFlushInterval is the number of rows written between flushes of the response
+const exportFlushInterval = 100
+
+// ExportUsers handles streaming a filtered, sorted user list as CSV or NDJSON
+func (h *UserHandler) ExportUsers(c *gin.Context) {
+	var req models.ExportUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+	splitListParams(&req.GetUsersRequest)
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	filters, sort, _, err := h.parseQueryParams(&req.GetUsersRequest)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	columns, err := parseExportColumns(req.Columns)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	format := "csv"
+	if req.Format != nil {
+		format = *req.Format
+	}
+
+	var writeRow func(*models.User) error
+	var csvWriter *csv.Writer
+	started := false
+
+	start := func() error {
+		started = true
+		if format == "csv" {
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", `attachment; filename="users.csv"`)
+		} else {
+			c.Header("Content-Type", "application/x-ndjson")
+			c.Header("Content-Disposition", `attachment; filename="users.ndjson"`)
+		}
+		c.Status(http.StatusOK)
+		if format == "csv" {
+			csvWriter = csv.NewWriter(c.Writer)
+			return csvWriter.Write(columns)
+		}
+		return nil
+	}
+
+	if format == "csv" {
+		writeRow = func(user *models.User) error {
+			record := make([]string, len(columns))
+			for i, column := range columns {
+				record[i] = sanitizeCSVCell(formatCSVValue(exportValue(user, column)))
+			}
+			return csvWriter.Write(record)
+		}
+	} else {
+		writeRow = func(user *models.User) error {
+			line, err := encodeOrderedJSON(user, columns)
+			if err != nil {
+				return err
+			}
+			_, err = c.Writer.Write(append(line, '\n'))
+			return err
+		}
+	}
+
+	rowCount := 0
+	err = h.userRepo.StreamUsers(c.Request.Context(), filters, sort, func(user *models.User) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		if err := writeRow(user); err != nil {
+			return err
+		}
+		rowCount++
+		if rowCount%exportFlushInterval == 0 {
+			if csvWriter != nil {
+				csvWriter.Flush()
+				if err := csvWriter.Error(); err != nil {
+					return err
+				}
+			}
+			c.Writer.Flush()
+		}
+		return nil
+	})
+	if err != nil {
+		if !started {
+			writeRepositoryError(c, err, "failed to export users")
+			return
+		}
+		// The status line has already been sent; all we can do is cut the stream short
+		log.Printf("Error streaming user export after %d rows: %v", rowCount, err)
+		c.Abort()
+		return
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			log.Printf("Error writing user export header: %v", err)
+			c.Abort()
+			return
+		}
+	}
+	if csvWriter != nil {
+		csvWriter.Flush()
+		if err := csvWriter.Error(); err != nil {
+			log.Printf("Error writing user export after %d rows: %v", rowCount, err)
+			c.Abort()
+		}
+	}
+}
+
+// parseExportColumns validates a comma-separated column list, defaulting to every user field
+func parseExportColumns(raw *string) ([]string, error) {
+	columns, err := parseFieldList(raw, "export column")
+	if err != nil {
+		return nil, err
+	}
+	if columns == nil {
+		return userFields, nil
+	}
+	return columns, nil
+}
+
+// exportValue returns the value of a user column for export
+func exportValue(user *models.User, column string) interface{} {
+	switch column {
+	case "id":
+		return user.ID
+	case "email":
+		return user.Email
+	case "full_name":
+		return user.FullName
+	case "phone":
+		return user.Phone
+	case "role":
+		return user.Role
+	case "is_active":
+		return user.IsActive
+	case "manager_id":
+		return user.ManagerID
+	case "merged_into":
+		return user.MergedInto
+	case "erased_at":
+		return user.ErasedAt
+	case "created_at":
+		return user.CreatedAt
+	case "updated_at":
+		return user.UpdatedAt
+	}
+	return nil
+}
+
+// formatCSVValue renders an export value as a CSV cell, leaving nulls empty
+func formatCSVValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case *string:
+		if v == nil {
+			return ""
+		}
+		return *v
+	case bool:
+		return strconv.FormatBool(v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case *time.Time:
+		if v == nil {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case uuid.UUID:
+		return v.String()
+	case *uuid.UUID:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// sanitizeCSVCell neutralises cells that spreadsheet applications would evaluate as formulas
+func sanitizeCSVCell(cell string) string {
+	if cell == "" {
+		return cell
+	}
+	switch cell[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + cell
+	}
+	return cell
+}
+
+// encodeOrderedJSON encodes the given user columns as a JSON object, preserving column order
+func encodeOrderedJSON(user *models.User, columns []string) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, column := range columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(column)
+		value, err := json.Marshal(exportValue(user, column))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", column, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportUsers_CSV tests CSV export with escaping and formula neutralisation
+func TestExportUsers_CSV(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	phone := "+6281234567"
+	users := []models.User{
+		{ID: userID, Email: "jane@example.com", FullName: `Doe, "Jane"`, Phone: &phone, Role: "staff", IsActive: true},
+		{ID: uuid.New(), Email: "evil@example.com", FullName: "=HYPERLINK(\"http://x\")", Role: "supplier"},
+	}
+
+	mockRepo.On("StreamUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(users, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/export?format=csv&columns=id,full_name,phone,is_active", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", w.Header().Get("Content-Type"))
+
+	records, err := csv.NewReader(strings.NewReader(w.Body.String())).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, []string{"id", "full_name", "phone", "is_active"}, records[0])
+	assert.Equal(t, []string{userID.String(), `Doe, "Jane"`, "'+6281234567", "true"}, records[1])
+	assert.Equal(t, "'=HYPERLINK(\"http://x\")", records[2][1])
+	assert.Equal(t, "", records[2][2])
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestExportUsers_NDJSON tests NDJSON export preserves column order and nulls
+func TestExportUsers_NDJSON(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	role := "admin"
+	users := []models.User{
+		{ID: uuid.New(), Email: "a@example.com", Role: "admin"},
+		{ID: uuid.New(), Email: "b@example.com", Role: "admin"},
+	}
+
+	mockRepo.On("StreamUsers", mock.Anything, mock.MatchedBy(func(f *models.FilterParams) bool {
+		return f.Role != nil && *f.Role == role
+	}), mock.MatchedBy(func(s *models.SortParams) bool {
+		return s.Field == "email" && s.Order == "desc"
+	}), mock.Anything).Return(users, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/export?format=ndjson&columns=email,phone&role=admin&sort_by=email&sort_order=desc", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+
+	scanner := bufio.NewScanner(strings.NewReader(w.Body.String()))
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	require.Len(t, lines, 2)
+	assert.Equal(t, `{"email":"a@example.com","phone":null}`, lines[0])
+
+	var row map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &row))
+	assert.Equal(t, "b@example.com", row["email"])
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestExportUsers_EmptyResult tests that an empty CSV export still has a header row
+func TestExportUsers_EmptyResult(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	mockRepo.On("StreamUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]models.User{}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/export?columns=id,email", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "id,email\n", w.Body.String())
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestExportUsers_InvalidParameters tests rejection of unknown formats and columns
+func TestExportUsers_InvalidParameters(t *testing.T) {
+	testCases := []struct {
+		name  string
+		query string
+	}{
+		{"InvalidFormat", "format=xlsx"},
+		{"InvalidColumn", "columns=id,password"},
+		{"InvalidRole", "role=superuser"},
+		{"InvalidDate", "created_from=yesterday"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/export?"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockRepo.AssertNotCalled(t, "StreamUsers")
+		})
+	}
+}
+
+// TestExportUsers_RepositoryError tests handling of errors before any row is written
+func TestExportUsers_RepositoryError(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	mockRepo.On("StreamUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("failed to declare cursor"))
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/export", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// failingResponseWriter accepts headers but fails every write of the body
+type failingResponseWriter struct {
+	*httptest.ResponseRecorder
+}
+
+func (w *failingResponseWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection reset by peer")
+}
+
+// TestExportUsers_CSVWriteError tests that a CSV export is cut short when its rows cannot be
+// written, whether they fail at the end or while the export is streamed
+func TestExportUsers_CSVWriteError(t *testing.T) {
+	for _, count := range []int{1, exportFlushInterval} {
+		t.Run(fmt.Sprintf("%d rows", count), func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			users := make([]models.User, count)
+			for i := range users {
+				users[i] = models.User{ID: uuid.New(), Email: fmt.Sprintf("user%d@example.com", i), FullName: "User", Role: "staff"}
+			}
+			mockRepo.On("StreamUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(users, nil)
+
+			gin.SetMode(gin.TestMode)
+			aborted := false
+			r := gin.New()
+			r.GET("/export", func(c *gin.Context) {
+				handler.ExportUsers(c)
+				aborted = c.IsAborted()
+			})
+
+			req, _ := http.NewRequest("GET", "/export?format=csv&columns=role", nil)
+			r.ServeHTTP(&failingResponseWriter{httptest.NewRecorder()}, req)
+
+			assert.True(t, aborted)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestSanitizeCSVCell tests neutralisation of formula prefixes
+func TestSanitizeCSVCell(t *testing.T) {
+	testCases := map[string]string{
+		"":         "",
+		"plain":    "plain",
+		"=1+1":     "'=1+1",
+		"+1":       "'+1",
+		"-1":       "'-1",
+		"@SUM(A1)": "'@SUM(A1)",
+		"\tcmd":    "'\tcmd",
+		"a=b":      "a=b",
+	}
+
+	for input, expected := range testCases {
+		assert.Equal(t, expected, sanitizeCSVCell(input), "input %q", input)
+	}
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+)
+
+// userFields lists the user fields clients can select, in their default order
+var userFields = []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "merged_into", "erased_at", "created_at", "updated_at"}
+
+// parseFieldList validates a comma-separated list of user fields, dropping duplicates.
+// It returns nil when the list is absent or empty; kind names the parameter in errors.
+func parseFieldList(raw *string, kind string) ([]string, error) {
+	if raw == nil || strings.TrimSpace(*raw) == "" {
+		return nil, nil
+	}
+
+	allowed := make(map[string]bool, len(userFields))
+	for _, field := range userFields {
+		allowed[field] = true
+	}
+
+	var fields []string
+	seen := map[string]bool{}
+	for _, field := range strings.Split(*raw, ",") {
+		field = strings.TrimSpace(field)
+		if !allowed[field] {
+			return nil, fmt.Errorf("invalid %s: %q", kind, field)
+		}
+		if seen[field] {
+			continue
+		}
+		seen[field] = true
+		fields = append(fields, field)
+	}
+
+	return fields, nil
+}
+
+// withField returns fields with field appended unless it is already present
+func withField(fields []string, field string) []string {
+	for _, f := range fields {
+		if f == field {
+			return fields
+		}
+	}
+	return append(append([]string{}, fields...), field)
+}
+
+// singleUserFields returns the fields to read for a single user response. merged_into is needed
+// to detect merged users and updated_at to derive validators, even if neither is returned.
+func singleUserFields(fields []string) []string {
+	if fields == nil {
+		return nil
+	}
+	return withField(withField(fields, "merged_into"), "updated_at")
+}
+
+// sparseUsers encodes each user as a JSON object holding only the given fields
+func sparseUsers(users []models.User, fields []string) ([]json.RawMessage, error) {
+	data := make([]json.RawMessage, 0, len(users))
+	for i := range users {
+		encoded, err := encodeOrderedJSON(&users[i], fields)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, encoded)
+	}
+	return data, nil
+}
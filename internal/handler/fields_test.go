@@ -0,0 +1,124 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserByID_SparseFields tests that only the requested fields are returned
+func TestGetUserByID_SparseFields(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	user := &models.User{ID: userID, FullName: "Sparse User", Role: "staff"}
+
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string{"id", "full_name", "role", "merged_into", "updated_at"}).Return(user, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s?fields=id,full_name,role", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fmt.Sprintf(`{"id":"%s","full_name":"Sparse User","role":"staff"}`, userID), w.Body.String())
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetUserByID_SparseFieldsMerged tests that merged users still redirect when merged_into is not requested
+func TestGetUserByID_SparseFieldsMerged(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	targetID := uuid.New()
+
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string{"id", "merged_into", "updated_at"}).Return(&models.User{ID: userID, MergedInto: &targetID}, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s?fields=id", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, fmt.Sprintf("/api/v1/users/%s", targetID), w.Header().Get("Location"))
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetUserByID_UnknownField tests that unknown fields are rejected
+func TestGetUserByID_UnknownField(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s?fields=id,password", uuid.New()), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response models.Problem
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Contains(t, response.Detail, `invalid field: "password"`)
+
+	mockRepo.AssertNotCalled(t, "GetUserByID", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestGetAllUsers_SparseFields tests that list entries contain only the requested fields
+func TestGetAllUsers_SparseFields(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	expectedResponse := &models.GetUsersResponse{
+		Data:       []models.User{{ID: userID, FullName: "Sparse User", Role: "admin"}},
+		Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, Total: 1, TotalPages: 1},
+	}
+
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string{"id", "full_name", "role", "updated_at"}).Return(expectedResponse, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/?fields=id,full_name,role,id", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Data       []map[string]interface{}  `json:"data"`
+		Pagination models.PaginationMetadata `json:"pagination"`
+	}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, map[string]interface{}{"id": userID.String(), "full_name": "Sparse User", "role": "admin"}, response.Data[0])
+	assert.Equal(t, 1, response.Pagination.Total)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetAllUsers_UnknownField tests that unknown fields are rejected
+func TestGetAllUsers_UnknownField(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/?fields=id,nickname", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+)
+
+// nullFilterValue is the filter value matching a NULL column
+const nullFilterValue = "null"
+
+// filterFields lists the fields that list filters and OR groups may refer to
+var filterFields = map[string]bool{
+	"id":         true,
+	"email":      true,
+	"phone":      true,
+	"role":       true,
+	"is_active":  true,
+	"manager_id": true,
+}
+
+// nullableFilterFields lists the filter fields that may be matched against null
+var nullableFilterFields = map[string]bool{
+	"phone":      true,
+	"manager_id": true,
+}
+
+// splitListParams expands comma-separated values of the list filters, so that
+// role=admin,staff binds the same as role=admin&role=staff
+func splitListParams(req *models.GetUsersRequest) {
+	for _, list := range []*[]string{
+		&req.Role, &req.RoleNot,
+		&req.ID, &req.IDNot,
+		&req.Phone, &req.PhoneNot,
+		&req.ManagerID, &req.ManagerIDNot,
+	} {
+		*list = splitValues(*list)
+	}
+}
+
+// splitValues splits every value on commas, trimming spaces and dropping empty entries
+func splitValues(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+// parseFilterCondition validates the values of a filter on field and builds its condition
+func parseFilterCondition(field string, values []string, negate bool) (models.FilterCondition, error) {
+	condition := models.FilterCondition{Field: field, Negate: negate}
+
+	if !filterFields[field] {
+		return condition, fmt.Errorf("invalid filter field: %q", field)
+	}
+	if len(values) == 0 {
+		return condition, fmt.Errorf("invalid %s filter: no values", field)
+	}
+
+	for _, value := range values {
+		if value == nullFilterValue {
+			if !nullableFilterFields[field] {
+				return condition, fmt.Errorf("invalid %s filter: %s cannot be null", field, field)
+			}
+			if len(values) > 1 {
+				return condition, fmt.Errorf("invalid %s filter: null cannot be combined with other values", field)
+			}
+			condition.IsNull = true
+			return condition, nil
+		}
+
+		switch field {
+		case "id", "manager_id":
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return condition, fmt.Errorf("invalid %s format: %q", field, value)
+			}
+			value = id.String()
+		case "role":
+			if value != "admin" && value != "staff" && value != "supplier" {
+				return condition, fmt.Errorf("invalid role: %q", value)
+			}
+		case "is_active":
+			active, err := strconv.ParseBool(value)
+			if err != nil {
+				return condition, fmt.Errorf("invalid is_active value: %q", value)
+			}
+			value = strconv.FormatBool(active)
+		}
+		condition.Values = append(condition.Values, value)
+	}
+
+	return condition, nil
+}
+
+// parseOrGroup parses an OR group such as "role=admin|phone=null|id!=<uuid>,<uuid>"
+func parseOrGroup(raw string) ([]models.FilterCondition, error) {
+	var group []models.FilterCondition
+	for _, term := range strings.Split(raw, "|") {
+		term = strings.TrimSpace(term)
+
+		field, values, found := strings.Cut(term, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid or filter term %q, expected field=value", term)
+		}
+		negate := strings.HasSuffix(field, "!")
+		field = strings.TrimSpace(strings.TrimSuffix(field, "!"))
+
+		condition, err := parseFilterCondition(field, splitValues([]string{values}), negate)
+		if err != nil {
+			return nil, err
+		}
+		group = append(group, condition)
+	}
+	return group, nil
+}
+
+// loadTimeZone loads an IANA time zone such as Asia/Jakarta or Europe/Amsterdam
+func loadTimeZone(name string) (*time.Location, error) {
+	// "Local" would silently depend on the server's configuration
+	if name == "Local" {
+		return nil, fmt.Errorf("invalid tz: %q, use an IANA time zone such as Asia/Jakarta", name)
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tz: %q, use an IANA time zone such as Asia/Jakarta", name)
+	}
+	return loc, nil
+}
+
+// requestLocation returns the time zone named by the tz parameter, or UTC if none is given
+func requestLocation(tz *string) (*time.Location, error) {
+	if tz == nil || *tz == "" {
+		return time.UTC, nil
+	}
+	return loadTimeZone(*tz)
+}
+
+// parseTimeBound parses one end of a half-open time range. RFC 3339 datetimes are used as given;
+// a plain YYYY-MM-DD date means midnight in loc, or the following midnight for an upper bound
+// so that the whole day is included.
+func parseTimeBound(param string, raw *string, loc *time.Location, upper bool) (*time.Time, error) {
+	if raw == nil || *raw == "" {
+		return nil, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, *raw); err == nil {
+		return &parsed, nil
+	}
+
+	date, err := time.ParseInLocation("2006-01-02", *raw, loc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s date format, use YYYY-MM-DD or an RFC 3339 datetime: %q", param, *raw)
+	}
+	if upper {
+		date = date.AddDate(0, 0, 1)
+	}
+	return &date, nil
+}
@@ -0,0 +1,216 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/filter"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAllUsers_ListFilters tests that multi-value, negated and null filters become conditions
+func TestGetAllUsers_ListFilters(t *testing.T) {
+	idA := uuid.New()
+	idB := uuid.New()
+	managerID := uuid.New()
+
+	tests := []struct {
+		name     string
+		query    string
+		expected *models.FilterParams
+	}{
+		{
+			name:     "SingleRole",
+			query:    "role=admin",
+			expected: &models.FilterParams{Role: stringPtr("admin")},
+		},
+		{
+			name:  "CommaSeparatedRoles",
+			query: "role=admin,staff",
+			expected: &models.FilterParams{Conditions: []models.FilterCondition{
+				{Field: "role", Values: []string{"admin", "staff"}},
+			}},
+		},
+		{
+			name:  "RepeatedRoles",
+			query: "role=admin&role=staff",
+			expected: &models.FilterParams{Conditions: []models.FilterCondition{
+				{Field: "role", Values: []string{"admin", "staff"}},
+			}},
+		},
+		{
+			name:  "NegatedRole",
+			query: "role!=supplier",
+			expected: &models.FilterParams{Conditions: []models.FilterCondition{
+				{Field: "role", Values: []string{"supplier"}, Negate: true},
+			}},
+		},
+		{
+			name:  "IDList",
+			query: "id=" + idA.String() + "," + idB.String(),
+			expected: &models.FilterParams{Conditions: []models.FilterCondition{
+				{Field: "id", Values: []string{idA.String(), idB.String()}},
+			}},
+		},
+		{
+			name:  "NullPhone",
+			query: "phone=null",
+			expected: &models.FilterParams{Conditions: []models.FilterCondition{
+				{Field: "phone", IsNull: true},
+			}},
+		},
+		{
+			name:  "WithoutManagerAndNotExcluded",
+			query: "manager_id=null&id!=" + idA.String(),
+			expected: &models.FilterParams{Conditions: []models.FilterCondition{
+				{Field: "id", Values: []string{idA.String()}, Negate: true},
+				{Field: "manager_id", IsNull: true},
+			}},
+		},
+		{
+			name:     "SingleManager",
+			query:    "manager_id=" + managerID.String(),
+			expected: &models.FilterParams{ManagerID: &managerID},
+		},
+		{
+			name:  "OrGroups",
+			query: "or=" + url.QueryEscape("role=admin|phone!=null") + "&or=" + url.QueryEscape("is_active=false|manager_id="+managerID.String()),
+			expected: &models.FilterParams{AnyOf: [][]models.FilterCondition{
+				{
+					{Field: "role", Values: []string{"admin"}},
+					{Field: "phone", IsNull: true, Negate: true},
+				},
+				{
+					{Field: "is_active", Values: []string{"false"}},
+					{Field: "manager_id", Values: []string{managerID.String()}},
+				},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			expectedResponse := &models.GetUsersResponse{Data: []models.User{}, Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, TotalPages: 1}}
+			mockRepo.On("GetAllUsers", mock.Anything, tt.expected, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetAllUsers_InvalidListFilters tests that malformed list filters are rejected
+func TestGetAllUsers_InvalidListFilters(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		errMsg string
+	}{
+		{"InvalidRoleInList", "role=admin,superuser", "role[1]"},
+		{"InvalidNegatedRole", "role!=superuser", "role![0]"},
+		{"InvalidID", "id=not-a-uuid", "invalid id format"},
+		{"NullMixedWithValues", "phone=null,123", "null cannot be combined"},
+		{"NullRole", "or=" + url.QueryEscape("role=null"), "role cannot be null"},
+		{"UnknownOrField", "or=" + url.QueryEscape("password=x|role=admin"), "invalid filter field"},
+		{"MalformedOrTerm", "or=" + url.QueryEscape("role"), "expected field=value"},
+		{"InvalidOrRole", "or=" + url.QueryEscape("role=root"), "invalid role"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Contains(t, response.Detail, tt.errMsg)
+
+			mockRepo.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestGetAllUsers_FilterExpression tests that filter expressions are parsed and passed to the repository
+func TestGetAllUsers_FilterExpression(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	expected := &models.FilterParams{
+		Expression: filter.And{Operands: []filter.Node{
+			filter.Comparison{Field: "role", Operator: filter.OpEqual, Args: []string{"supplier"}},
+			filter.Or{Operands: []filter.Node{
+				filter.Comparison{Field: "email", Operator: filter.OpLike, Args: []string{"*@acme.com"}},
+				filter.Comparison{Field: "created_at", Operator: filter.OpGreaterEqual, Args: []string{"2026-01-01T00:00:00Z"}},
+			}},
+		}},
+	}
+	expectedResponse := &models.GetUsersResponse{Data: []models.User{}, Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, TotalPages: 1}}
+	mockRepo.On("GetAllUsers", mock.Anything, expected, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
+
+	query := url.Values{"filter": {"role==supplier;(email=like=*@acme.com,created_at=ge=2026-01-01)"}}
+	req, _ := http.NewRequest("GET", "/api/v1/users/?"+query.Encode(), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetAllUsers_InvalidFilterExpression tests that malformed or ill-typed expressions are rejected
+func TestGetAllUsers_InvalidFilterExpression(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		errMsg string
+	}{
+		{"SyntaxError", "role==admin;(email==x", "invalid filter at position"},
+		{"UnknownField", "password==secret", `unknown field "password"`},
+		{"TypeError", "created_at=ge=last-week", `invalid value "last-week" for created_at`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			query := url.Values{"filter": {tt.filter}}
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+query.Encode(), nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Contains(t, response.Detail, tt.errMsg)
+
+			mockRepo.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -48,7 +48,7 @@ func TestGetAllUsers_Success(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/", nil)
 	w := httptest.NewRecorder()
@@ -94,7 +94,7 @@ func TestGetAllUsers_WithFilters(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/?role=admin&is_active=true&search=admin", nil)
 	w := httptest.NewRecorder()
@@ -146,7 +146,7 @@ func TestGetAllUsers_WithSorting(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/?sort_by=email&sort_order=desc", nil)
 	w := httptest.NewRecorder()
@@ -190,7 +190,7 @@ func TestGetAllUsers_WithPagination(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/?page=2&page_size=5", nil)
 	w := httptest.NewRecorder()
@@ -238,7 +238,7 @@ func TestGetAllUsers_WithDateFilters(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/?created_from=2024-01-01&created_to=2024-12-31", nil)
 	w := httptest.NewRecorder()
@@ -267,10 +267,10 @@ func TestGetAllUsers_InvalidDateFormat(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "invalid created_from date format")
+	assert.Contains(t, response.Detail, "invalid created_from date format")
 }
 
 // TestGetAllUsers_InvalidSortField tests handling of invalid sort fields
@@ -285,10 +285,10 @@ func TestGetAllUsers_InvalidSortField(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "SortBy")
+	assert.Contains(t, response.Detail, "sort_by")
 }
 
 // TestGetAllUsers_InvalidRole tests handling of invalid role values
@@ -303,10 +303,10 @@ func TestGetAllUsers_InvalidRole(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "Role")
+	assert.Contains(t, response.Detail, "role[0]")
 }
 
 // TestGetAllUsers_InvalidPagination tests handling of invalid pagination parameters
@@ -321,10 +321,10 @@ func TestGetAllUsers_InvalidPagination(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "Page")
+	assert.Contains(t, response.Detail, "page must be 1 or greater")
 }
 
 // TestGetAllUsers_DatabaseError tests handling of database errors
@@ -332,7 +332,7 @@ func TestGetAllUsers_DatabaseError(t *testing.T) {
 	handler, mockRepo := setupTestHandler()
 	router := setupTestRouter(handler)
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("database connection failed"))
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(nil, fmt.Errorf("database connection failed"))
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/", nil)
 	w := httptest.NewRecorder()
@@ -341,10 +341,10 @@ func TestGetAllUsers_DatabaseError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "failed to retrieve users", response["error"])
+	assert.Equal(t, "failed to retrieve users", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -366,7 +366,7 @@ func TestGetAllUsers_EmptyResult(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/?search=nonexistent", nil)
 	w := httptest.NewRecorder()
@@ -411,7 +411,7 @@ func TestGetAllUsers_ComplexQuery(t *testing.T) {
 		},
 	}
 
-	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(expectedResponse, nil)
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
 
 	req, _ := http.NewRequest("GET", "/api/v1/users/?role=admin&is_active=true&search=active&email_domain=company.com&sort_by=full_name&sort_order=asc&page=1&page_size=5", nil)
 	w := httptest.NewRecorder()
@@ -28,7 +28,7 @@ func TestGetUserByID_Success(t *testing.T) {
 		IsActive: true,
 	}
 
-	mockRepo.On("GetUserByID", mock.Anything, userID).Return(expectedUser, nil)
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(expectedUser, nil)
 
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s", userID.String()), nil)
 	w := httptest.NewRecorder()
@@ -59,10 +59,10 @@ func TestGetUserByID_InvalidUUID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "invalid user ID format", response["error"])
+	assert.Equal(t, "invalid user ID format", response.Detail)
 }
 
 // TestGetUserByID_UserNotFound tests handling when user doesn't exist
@@ -71,7 +71,7 @@ func TestGetUserByID_UserNotFound(t *testing.T) {
 	router := setupTestRouter(handler)
 
 	userID := uuid.New()
-	mockRepo.On("GetUserByID", mock.Anything, userID).Return(nil, fmt.Errorf("user not found"))
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, fmt.Errorf("user not found"))
 
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s", userID.String()), nil)
 	w := httptest.NewRecorder()
@@ -80,10 +80,10 @@ func TestGetUserByID_UserNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "user not found", response["error"])
+	assert.Equal(t, "user not found", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -94,7 +94,7 @@ func TestGetUserByID_DatabaseError(t *testing.T) {
 	router := setupTestRouter(handler)
 
 	userID := uuid.New()
-	mockRepo.On("GetUserByID", mock.Anything, userID).Return(nil, fmt.Errorf("database connection failed"))
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, fmt.Errorf("database connection failed"))
 
 	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s", userID.String()), nil)
 	w := httptest.NewRecorder()
@@ -103,10 +103,10 @@ func TestGetUserByID_DatabaseError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "failed to retrieve user", response["error"])
+	assert.Equal(t, "failed to retrieve user", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
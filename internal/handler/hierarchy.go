@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// defaultSubtreeDepth is used when a subtree request does not specify max_depth
+const defaultSubtreeDepth = 20
+
+// GetDirectReports handles retrieving the users who report directly to a user
+func (h *UserHandler) GetDirectReports(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	reports, err := h.userRepo.GetDirectReports(c.Request.Context(), userID)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve direct reports")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.UserListResponse{Data: reports})
+}
+
+// GetSubordinates handles retrieving a user's full reporting subtree
+func (h *UserHandler) GetSubordinates(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	var req models.GetUserHierarchyRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	maxDepth := defaultSubtreeDepth
+	if req.MaxDepth != nil {
+		maxDepth = *req.MaxDepth
+	}
+
+	subordinates, err := h.userRepo.GetSubordinates(c.Request.Context(), userID, maxDepth)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve subordinates")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GetUserHierarchyResponse{Data: subordinates})
+}
+
+// GetManagementChain handles retrieving a user's managers up to the root of the hierarchy
+func (h *UserHandler) GetManagementChain(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	chain, err := h.userRepo.GetManagementChain(c.Request.Context(), userID)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve management chain")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.GetUserHierarchyResponse{Data: chain})
+}
@@ -0,0 +1,230 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetDirectReports_Success tests successful retrieval of direct reports
+func TestGetDirectReports_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	managerID := uuid.New()
+	reports := []models.User{
+		{ID: uuid.New(), Email: "report@example.com", FullName: "Report", Role: "staff", ManagerID: &managerID},
+	}
+
+	mockRepo.On("GetDirectReports", mock.Anything, managerID).Return(reports, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/reports", managerID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.UserListResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, managerID, *response.Data[0].ManagerID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetDirectReports_UserNotFound tests handling when the manager doesn't exist
+func TestGetDirectReports_UserNotFound(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	managerID := uuid.New()
+	mockRepo.On("GetDirectReports", mock.Anything, managerID).Return(nil, fmt.Errorf("user not found"))
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/reports", managerID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetSubordinates_Success tests subtree retrieval with an explicit depth
+func TestGetSubordinates_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	managerID := uuid.New()
+	entries := []models.UserHierarchyEntry{
+		{User: models.User{ID: uuid.New(), Email: "a@example.com"}, Depth: 1},
+		{User: models.User{ID: uuid.New(), Email: "b@example.com"}, Depth: 2},
+	}
+
+	mockRepo.On("GetSubordinates", mock.Anything, managerID, 3).Return(entries, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/subordinates?max_depth=3", managerID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.GetUserHierarchyResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, 2, response.Data[1].Depth)
+	assert.Equal(t, "b@example.com", response.Data[1].Email)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetSubordinates_DefaultDepth tests that the default depth is used when none is given
+func TestGetSubordinates_DefaultDepth(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	managerID := uuid.New()
+	mockRepo.On("GetSubordinates", mock.Anything, managerID, defaultSubtreeDepth).Return([]models.UserHierarchyEntry{}, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/subordinates", managerID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetSubordinates_InvalidDepth tests rejection of out-of-range depths
+func TestGetSubordinates_InvalidDepth(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/subordinates?max_depth=50", uuid.New()), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertNotCalled(t, "GetSubordinates")
+}
+
+// TestGetManagementChain_Success tests retrieval of the management chain
+func TestGetManagementChain_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	rootID := uuid.New()
+	chain := []models.UserHierarchyEntry{
+		{User: models.User{ID: rootID, Email: "root@example.com", Role: "admin"}, Depth: 1},
+	}
+
+	mockRepo.On("GetManagementChain", mock.Anything, userID).Return(chain, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s/chain", userID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.GetUserHierarchyResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Data, 1)
+	assert.Equal(t, rootID, response.Data[0].ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetManagementChain_InvalidUUID tests handling of invalid UUID
+func TestGetManagementChain_InvalidUUID(t *testing.T) {
+	handler, _ := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/invalid-uuid/chain", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// TestUpdateUser_ManagerCycle tests that cycle errors are returned as conflicts
+func TestUpdateUser_ManagerCycle(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	managerID := uuid.New()
+
+	mockRepo.On("UpdateUser", mock.Anything, userID, mock.AnythingOfType("*models.UpdateUserRequest")).
+		Return(nil, fmt.Errorf("manager assignment would create a cycle"))
+
+	body, _ := json.Marshal(map[string]interface{}{"manager_id": managerID})
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/users/%s", userID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestCreateUser_ManagerNotFound tests that a missing manager is a bad request
+func TestCreateUser_ManagerNotFound(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	mockRepo.On("CreateUser", mock.Anything, mock.AnythingOfType("*models.User")).
+		Return(nil, fmt.Errorf("manager not found"))
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"email":      "new@example.com",
+		"full_name":  "New User",
+		"role":       "staff",
+		"manager_id": uuid.New(),
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/users/", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetAllUsers_InvalidUnderManager tests rejection of a malformed under_manager filter
+func TestGetAllUsers_InvalidUnderManager(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/?under_manager=not-a-uuid", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.AssertNotCalled(t, "GetAllUsers")
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	"github.com/go-playground/locales/nl"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	idtranslations "github.com/go-playground/validator/v10/translations/id"
+	nltranslations "github.com/go-playground/validator/v10/translations/nl"
+	"golang.org/x/text/language"
+)
+
+// supportedLocales lists the locales error messages are available in; the first one is the default
+var supportedLocales = []struct {
+	tag      language.Tag
+	name     string
+	register func(*validator.Validate, ut.Translator) error
+}{
+	{language.English, "en", entranslations.RegisterDefaultTranslations},
+	{language.Indonesian, "id", idtranslations.RegisterDefaultTranslations},
+	{language.Dutch, "nl", nltranslations.RegisterDefaultTranslations},
+}
+
+// translatorKey caches the request's translator in the gin context
+const translatorKey = "translator"
+
+var (
+	translators   = newTranslators()
+	localeMatcher = newLocaleMatcher()
+)
+
+// newTranslators creates a translator per supported locale, loaded with the message catalogue
+func newTranslators() *ut.UniversalTranslator {
+	uni := ut.New(en.New(), en.New(), id.New(), nl.New())
+	for locale, catalogue := range messageCatalogue {
+		trans, _ := uni.GetTranslator(locale)
+		for key, text := range catalogue {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(fmt.Sprintf("invalid %s message %q: %v", locale, key, err))
+			}
+		}
+	}
+	return uni
+}
+
+func newLocaleMatcher() language.Matcher {
+	tags := make([]language.Tag, len(supportedLocales))
+	for i, locale := range supportedLocales {
+		tags[i] = locale.tag
+	}
+	return language.NewMatcher(tags)
+}
+
+// registerTranslations registers the validator's messages for every supported locale
+func registerTranslations(v *validator.Validate) error {
+	for _, locale := range supportedLocales {
+		trans, _ := translators.GetTranslator(locale.name)
+		if err := locale.register(v, trans); err != nil {
+			return fmt.Errorf("failed to register %s validation messages: %w", locale.name, err)
+		}
+	}
+	return nil
+}
+
+// requestTranslator returns the translator for the best match of the request's Accept-Language
+// header, falling back to English
+func requestTranslator(c *gin.Context) ut.Translator {
+	if trans, ok := c.Get(translatorKey); ok {
+		return trans.(ut.Translator)
+	}
+
+	locale := supportedLocales[0].name
+	if tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language")); err == nil && len(tags) > 0 {
+		if _, index, confidence := localeMatcher.Match(tags...); confidence != language.No {
+			locale = supportedLocales[index].name
+		}
+	}
+
+	trans, _ := translators.GetTranslator(locale)
+	c.Set(translatorKey, trans)
+	return trans
+}
+
+// translate returns the translation of an English message, or the message itself if the
+// catalogue has none, as for messages that embed request details
+func translate(trans ut.Translator, message string, params ...string) string {
+	if translated, err := trans.T(message, params...); err == nil {
+		return translated
+	}
+	return message
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLocaleNegotiation tests that the best supported match of Accept-Language is used
+func TestLocaleNegotiation(t *testing.T) {
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		locale         string
+		detail         string
+	}{
+		{"Default", "", "en", "invalid user ID format"},
+		{"Indonesian", "id-ID,id;q=0.9,en;q=0.8", "id", "format ID pengguna tidak valid"},
+		{"FlemishDutch", "nl-BE", "nl", "ongeldig formaat voor gebruikers-ID"},
+		{"QualityOrder", "en;q=0.2, nl;q=0.9", "nl", "ongeldig formaat voor gebruikers-ID"},
+		{"SkipsUnsupported", "fr-FR, id;q=0.5", "id", "format ID pengguna tidak valid"},
+		{"Unsupported", "fr-FR", "en", "invalid user ID format"},
+		{"Malformed", "!!!", "en", "invalid user ID format"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := setupTestHandler()
+			w := getWithHeaders(handler, "/api/v1/users/not-a-uuid", map[string]string{"Accept-Language": tt.acceptLanguage})
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.detail, problem.Detail)
+			assert.Equal(t, tt.locale, w.Header().Get("Content-Language"))
+			assert.Equal(t, "Accept-Language", w.Header().Get("Vary"))
+		})
+	}
+}
+
+// TestLocalizedValidationErrors tests that field messages are translated while fields and rules are not
+func TestLocalizedValidationErrors(t *testing.T) {
+	tests := []struct {
+		locale   string
+		messages []string
+	}{
+		{"en", []string{"email must be a valid email address", "full_name is a required field", "role must be one of [admin staff supplier]"}},
+		{"id", []string{"email harus berupa alamat email yang valid", "full_name wajib diisi", "role harus berupa salah satu dari [admin staff supplier]"}},
+		{"nl", []string{"email moet een geldig email adres zijn", "full_name is een verplicht veld", "role moet een van de volgende zijn [admin staff supplier]"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.locale, func(t *testing.T) {
+			handler, _ := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("POST", "/api/v1/users/", strings.NewReader(`{"email":"not-an-email","role":"owner"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept-Language", tt.locale)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			problem := decodeProblem(t, w)
+			require.Len(t, problem.Errors, 3)
+			for i, fieldError := range problem.Errors {
+				assert.Equal(t, tt.messages[i], fieldError.Message)
+			}
+			assert.Equal(t, []string{"email", "full_name", "role"}, []string{problem.Errors[0].Field, problem.Errors[1].Field, problem.Errors[2].Field})
+			assert.Equal(t, strings.Join(tt.messages, "; "), problem.Detail)
+		})
+	}
+}
+
+// TestLocalizedTypeError tests the translated message for JSON values of the wrong type
+func TestLocalizedTypeError(t *testing.T) {
+	handler, _ := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/users/"+uuid.New().String(), strings.NewReader(`{"is_active":"yes"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept-Language", "nl")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	problem := decodeProblem(t, w)
+	require.Len(t, problem.Errors, 1)
+	assert.Equal(t, "is_active moet van het type bool zijn", problem.Errors[0].Message)
+}
+
+// TestLocalizedProblemKeepsCode tests that translating the detail leaves the stable code alone
+func TestLocalizedProblemKeepsCode(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	userID := uuid.New()
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, errors.New("user not found"))
+
+	w := getWithHeaders(handler, "/api/v1/users/"+userID.String(), map[string]string{"Accept-Language": "id"})
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, "user_not_found", problem.Code)
+	assert.Equal(t, "pengguna tidak ditemukan", problem.Detail)
+}
+
+// TestMessageCatalogue_CoversAllMessages tests that every literal message passed to writeProblem
+// or writeRepositoryError is translated for every locale, and that the catalogues hold no unused messages
+func TestMessageCatalogue_CoversAllMessages(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+
+	literal := regexp.MustCompile(`(?:writeProblem\(c, [^,]+, [^,]+, |writeRepositoryError\(c, err, )("(?:[^"\\]|\\.)*")\)`)
+	used := map[string]bool{}
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		for _, match := range literal.FindAllStringSubmatch(string(content), -1) {
+			message, err := strconv.Unquote(match[1])
+			require.NoError(t, err)
+			used[message] = true
+		}
+	}
+	require.NotEmpty(t, used)
+
+	for _, locale := range supportedLocales {
+		if locale.name == "en" {
+			continue
+		}
+		catalogue := messageCatalogue[locale.name]
+		for message := range used {
+			assert.Contains(t, catalogue, message, "message %q has no %s translation", message, locale.name)
+		}
+		for message := range catalogue {
+			assert.True(t, used[message] || message == typeMismatchMessage, "%s message %q is not used", locale.name, message)
+		}
+		assert.Contains(t, catalogue, typeMismatchMessage)
+	}
+}
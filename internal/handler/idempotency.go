@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLength bounds the length of Idempotency-Key headers
+const maxIdempotencyKeyLength = 255
+
+// WithIdempotencyStore enables Idempotency-Key handling, keeping responses in store for ttl
+func WithIdempotencyStore(store repository.IdempotencyStore, ttl time.Duration) Option {
+	return func(h *UserHandler) {
+		h.idempotencyStore = store
+		h.idempotencyTTL = ttl
+	}
+}
+
+// responseRecorder keeps a copy of everything written to the response
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotent returns middleware for mutating endpoints that makes requests carrying an
+// Idempotency-Key safe to retry. The first request with a key runs normally and its response
+// is stored; retries with the same method, URL and body replay that response, while reuse of
+// the key for a different request is rejected with 422. Keys belong to the authenticated
+// principal, so callers never see each other's responses. Server errors are not stored, so the
+// request can be retried. Requests without the header are not affected.
+func (h *UserHandler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" || h.idempotencyStore == nil {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := c.GetRawData()
+		if err != nil {
+			writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// The outcome is recorded even if the client gives up waiting, so a retry can replay it
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		owner := ""
+		if principal, ok := auth.FromContext(ctx); ok {
+			owner = principal.Key()
+		}
+		key = scopedIdempotencyKey(owner, key)
+		fingerprint := requestFingerprint(c, owner, body)
+		record, claimed, err := h.idempotencyStore.Begin(ctx, key, fingerprint, h.idempotencyTTL)
+		if err != nil {
+			writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to check idempotency key")
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.Fingerprint != fingerprint:
+				writeProblem(c, http.StatusUnprocessableEntity, models.CodeIdempotencyKeyReused, "Idempotency-Key has already been used for a different request")
+			case record.StatusCode == nil:
+				writeProblem(c, http.StatusConflict, models.CodeIdempotencyKeyInProgress, "a request with this Idempotency-Key is still in progress")
+			default:
+				contentType := ""
+				if record.ContentType != nil {
+					contentType = *record.ContentType
+				}
+				c.Header("Idempotent-Replayed", "true")
+				c.Data(*record.StatusCode, contentType, record.Body)
+				c.Abort()
+			}
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		stored := false
+		defer func() {
+			// Also runs when the handler panics
+			if !stored {
+				if err := h.idempotencyStore.Release(ctx, key); err != nil {
+					log.Printf("Error releasing idempotency key: %v", err)
+				}
+			}
+		}()
+
+		c.Next()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		if err := h.idempotencyStore.Complete(ctx, key, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes()); err != nil {
+			log.Printf("Error storing idempotent response: %v", err)
+			return
+		}
+		stored = true
+	}
+}
+
+// scopedIdempotencyKey returns the key an Idempotency-Key is stored under for the principal owner,
+// as identified by auth.Principal.Key. The length of owner keeps keys of different principals
+// apart whatever characters they contain.
+func scopedIdempotencyKey(owner, key string) string {
+	return strconv.Itoa(len(owner)) + ":" + owner + ":" + key
+}
+
+// requestFingerprint identifies a request by its principal, method, URL, content type and body
+func requestFingerprint(c *gin.Context, owner string, body []byte) string {
+	hash := sha256.New()
+	for _, part := range []string{owner, c.Request.Method, c.Request.URL.RequestURI(), c.ContentType()} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
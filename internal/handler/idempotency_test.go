@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// memoryIdempotencyStore is an in-memory IdempotencyStore for handler tests
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*models.IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: make(map[string]*models.IdempotencyRecord)}
+}
+
+func (s *memoryIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok && record.ExpiresAt.After(time.Now()) {
+		copied := *record
+		return &copied, false, nil
+	}
+	s.records[key] = &models.IdempotencyRecord{Key: key, Fingerprint: fingerprint, ExpiresAt: time.Now().Add(ttl)}
+	return nil, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[key]
+	if !ok {
+		return errors.New("idempotency key not found")
+	}
+	record.StatusCode = &statusCode
+	record.ContentType = &contentType
+	record.Body = append([]byte(nil), body...)
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if record, ok := s.records[key]; ok && record.StatusCode == nil {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// setupIdempotentHandler creates a handler backed by an in-memory idempotency store
+func setupIdempotentHandler() (*UserHandler, *MockUserRepository, *memoryIdempotencyStore) {
+	mockRepo := &MockUserRepository{}
+	store := newMemoryIdempotencyStore()
+	handler := NewUserHandler(mockRepo, WithIdempotencyStore(store, time.Hour))
+	return handler, mockRepo, store
+}
+
+// sendWithKey sends a JSON request carrying the given Idempotency-Key
+func sendWithKey(handler *UserHandler, method, path, key, body string) *httptest.ResponseRecorder {
+	router := setupTestRouter(handler)
+	req, _ := http.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set("Idempotency-Key", key)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+const idempotentCreateBody = `{"email":"test@example.com","full_name":"John Doe","role":"admin"}`
+
+// TestIdempotent_ReplaysResponse tests that a retry replays the first response without creating another user
+func TestIdempotent_ReplaysResponse(t *testing.T) {
+	handler, mockRepo, _ := setupIdempotentHandler()
+	created := &models.User{ID: uuid.New(), Email: "test@example.com", FullName: "John Doe", Role: "admin", IsActive: true}
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(created, nil).Once()
+
+	first := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+	retry := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, first.Header().Get("Content-Type"), retry.Header().Get("Content-Type"))
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 1)
+}
+
+// TestIdempotent_ReplaysClientErrors tests that 4xx responses are stored like successful ones
+func TestIdempotent_ReplaysClientErrors(t *testing.T) {
+	handler, mockRepo, _ := setupIdempotentHandler()
+
+	first := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", `{"email":"invalid"}`)
+	retry := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", `{"email":"invalid"}`)
+
+	assert.Equal(t, http.StatusBadRequest, first.Code)
+	assert.Equal(t, http.StatusBadRequest, retry.Code)
+	assert.Equal(t, "true", retry.Header().Get("Idempotent-Replayed"))
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+// TestIdempotent_ScopedToPrincipal tests that principals using the same key neither replay nor
+// block each other's requests
+func TestIdempotent_ScopedToPrincipal(t *testing.T) {
+	handler, mockRepo, store := setupIdempotentHandler()
+	created := &models.User{ID: uuid.New(), Email: "test@example.com", FullName: "John Doe", Role: "admin", IsActive: true}
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(created, nil)
+	router := setupTestRouter(handler)
+
+	send := func(principal *auth.Principal) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/users/", strings.NewReader(idempotentCreateBody))
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "key-1")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Principals that only share their subject must not replay each other's responses
+	alice := &auth.Principal{Subject: "alice", Issuer: "https://issuer.example.com/"}
+	others := []*auth.Principal{
+		{Subject: "bob", Issuer: "https://issuer.example.com/"},
+		{Subject: "alice", Issuer: "https://other.example.com/"},
+		{Subject: "alice", Service: true},
+		{Subject: "alice", Scopes: []string{auth.ScopeUsersWrite}, Service: true},
+	}
+
+	assert.Equal(t, http.StatusCreated, send(alice).Code)
+	for _, other := range others {
+		w := send(other)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, w.Header().Get("Idempotent-Replayed"), "%+v replayed the response of alice", other)
+	}
+	assert.Equal(t, "true", send(alice).Header().Get("Idempotent-Replayed"))
+	assert.Len(t, store.records, 1+len(others))
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 1+len(others))
+}
+
+// TestIdempotent_DifferentRequest tests that reusing a key for another request is rejected
+func TestIdempotent_DifferentRequest(t *testing.T) {
+	handler, mockRepo, _ := setupIdempotentHandler()
+	created := &models.User{ID: uuid.New(), Email: "test@example.com", FullName: "John Doe", Role: "admin", IsActive: true}
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(created, nil).Once()
+
+	sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+	w := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", `{"email":"other@example.com","full_name":"Jane Doe","role":"admin"}`)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "different request")
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 1)
+}
+
+// TestIdempotent_InProgress tests that a retry while the first request runs is rejected
+func TestIdempotent_InProgress(t *testing.T) {
+	handler, mockRepo, store := setupIdempotentHandler()
+	_, claimed, _ := store.Begin(context.Background(), scopedIdempotencyKey("", "key-1"), requestFingerprintFor(t, "POST", "/api/v1/users/", idempotentCreateBody), time.Hour)
+	assert.True(t, claimed)
+
+	w := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "still in progress")
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+// TestIdempotent_ServerErrorReleasesKey tests that a failed request can be retried with the same key
+func TestIdempotent_ServerErrorReleasesKey(t *testing.T) {
+	handler, mockRepo, store := setupIdempotentHandler()
+	created := &models.User{ID: uuid.New(), Email: "test@example.com", FullName: "John Doe", Role: "admin", IsActive: true}
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(nil, errors.New("connection refused")).Once()
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(created, nil).Once()
+
+	first := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+	assert.Equal(t, http.StatusInternalServerError, first.Code)
+	assert.Empty(t, store.records)
+
+	retry := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Empty(t, retry.Header().Get("Idempotent-Replayed"))
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 2)
+}
+
+// TestIdempotent_WithoutKey tests that requests without the header are not recorded
+func TestIdempotent_WithoutKey(t *testing.T) {
+	handler, mockRepo, store := setupIdempotentHandler()
+	userID := uuid.New()
+	mockRepo.On("DeleteUser", mock.Anything, userID).Return(&models.User{ID: userID}, nil).Twice()
+
+	first := sendWithKey(handler, "DELETE", "/api/v1/users/"+userID.String(), "", "")
+	second := sendWithKey(handler, "DELETE", "/api/v1/users/"+userID.String(), "", "")
+
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Equal(t, http.StatusOK, second.Code)
+	assert.Empty(t, store.records)
+	mockRepo.AssertNumberOfCalls(t, "DeleteUser", 2)
+}
+
+// TestIdempotent_KeyTooLong tests that oversized keys are rejected
+func TestIdempotent_KeyTooLong(t *testing.T) {
+	handler, mockRepo, _ := setupIdempotentHandler()
+
+	w := sendWithKey(handler, "POST", "/api/v1/users/", strings.Repeat("k", maxIdempotencyKeyLength+1), idempotentCreateBody)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+// TestIdempotent_NoStore tests that the header is ignored when no store is configured
+func TestIdempotent_NoStore(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	created := &models.User{ID: uuid.New(), Email: "test@example.com", FullName: "John Doe", Role: "admin", IsActive: true}
+	mockRepo.On("CreateUser", mock.Anything, mock.Anything).Return(created, nil).Twice()
+
+	sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+	w := sendWithKey(handler, "POST", "/api/v1/users/", "key-1", idempotentCreateBody)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	mockRepo.AssertNumberOfCalls(t, "CreateUser", 2)
+}
+
+// requestFingerprintFor computes the fingerprint the middleware derives for a JSON request
+func requestFingerprintFor(t *testing.T, method, path, body string) string {
+	t.Helper()
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest(method, path, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return requestFingerprint(c, "", []byte(body))
+}
@@ -0,0 +1,276 @@
+package handler
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+)
+
+// maxImportRows bounds the number of data rows accepted in a single import
+const maxImportRows = 5000
+
+// importFields lists the user fields CSV columns can be mapped to
+var importFields = map[string]bool{
+	"email":      true,
+	"full_name":  true,
+	"phone":      true,
+	"role":       true,
+	"manager_id": true,
+}
+
+// Import row statuses assigned by the handler, before anything reaches the repository
+const (
+	importStatusInvalid = "invalid"
+	importStatusSkipped = "skipped"
+)
+
+// ImportUsers handles importing users from CSV, with a per-row validation report
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	var req models.ImportUsersRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	dryRun := req.DryRun != nil && *req.DryRun
+	mode := "create"
+	if req.Mode != nil {
+		mode = *req.Mode
+	}
+
+	var mapping map[string]string
+	if req.Mapping != nil && *req.Mapping != "" {
+		if err := json.Unmarshal([]byte(*req.Mapping), &mapping); err != nil {
+			writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "invalid mapping, expected a JSON object of CSV header to field")
+			return
+		}
+		for header, field := range mapping {
+			if !importFields[field] {
+				writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, fmt.Sprintf("invalid mapping for %q: unknown field %q", header, field))
+				return
+			}
+		}
+	}
+
+	body, err := importBody(c)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+	defer body.Close()
+
+	rows, results, err := h.parseImportCSV(body, mapping)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	hasInvalid := false
+	for _, result := range results {
+		if result.Status == importStatusInvalid {
+			hasInvalid = true
+			break
+		}
+	}
+
+	response := models.ImportUsersResponse{DryRun: dryRun, Mode: mode}
+
+	if hasInvalid && !dryRun {
+		// Nothing is written unless every row is valid
+		for i := range results {
+			if results[i].Status == "" {
+				results[i].Status = importStatusSkipped
+			}
+		}
+	} else if len(rows) > 0 {
+		written, err := h.userRepo.ImportUsers(c.Request.Context(), rows, mode == "upsert", dryRun)
+		if err != nil {
+			writeRepositoryError(c, err, "failed to import users")
+			return
+		}
+		response.Committed = written.Committed
+
+		byRow := make(map[int]models.ImportRowResult, len(written.Rows))
+		for _, rowResult := range written.Rows {
+			byRow[rowResult.Row] = rowResult
+		}
+		for i := range results {
+			if rowResult, ok := byRow[results[i].Row]; ok {
+				results[i] = rowResult
+			}
+		}
+	}
+
+	response.Rows = results
+	response.Summary = map[string]int{}
+	for _, result := range results {
+		response.Summary[result.Status]++
+	}
+
+	status := http.StatusOK
+	if !dryRun && !response.Committed && len(results) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+	c.JSON(status, response)
+}
+
+// importBody returns the CSV payload from a multipart "file" field or the raw request body
+func importBody(c *gin.Context) (io.ReadCloser, error) {
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		fileHeader, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing CSV file in form field \"file\"")
+		}
+		return fileHeader.Open()
+	}
+	return c.Request.Body, nil
+}
+
+// parseImportCSV reads and validates CSV rows. It returns the valid rows and a result for every row,
+// with the status left empty for rows that passed validation.
+func (h *UserHandler) parseImportCSV(body io.Reader, mapping map[string]string) ([]models.ImportRow, []models.ImportRowResult, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, fmt.Errorf("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+
+	// Resolve which column feeds which field
+	columns := map[string]int{}
+	for i, name := range header {
+		name = strings.TrimSpace(name)
+		field := strings.ToLower(name)
+		if mapping != nil {
+			field = mapping[name]
+		}
+		if importFields[field] {
+			columns[field] = i
+		}
+	}
+	for headerName := range mapping {
+		found := false
+		for _, name := range header {
+			if strings.TrimSpace(name) == headerName {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("mapped column %q not found in CSV header", headerName)
+		}
+	}
+	if _, ok := columns["email"]; !ok {
+		return nil, nil, fmt.Errorf("no CSV column is mapped to email")
+	}
+
+	var rows []models.ImportRow
+	var results []models.ImportRowResult
+	seenEmails := map[string]int{}
+
+	for rowNumber := 1; ; rowNumber++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if rowNumber > maxImportRows {
+			return nil, nil, fmt.Errorf("CSV has more than %d rows", maxImportRows)
+		}
+
+		result := models.ImportRowResult{Row: rowNumber}
+		if err != nil {
+			if !errors.Is(err, csv.ErrFieldCount) {
+				return nil, nil, fmt.Errorf("invalid CSV at row %d: %w", rowNumber, err)
+			}
+			result.Status = importStatusInvalid
+			result.Errors = []string{"wrong number of columns"}
+			results = append(results, result)
+			continue
+		}
+
+		value := func(field string) string {
+			if i, ok := columns[field]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+
+		user := models.CreateUserRequest{
+			Email:    value("email"),
+			FullName: value("full_name"),
+			Role:     value("role"),
+		}
+		result.Email = user.Email
+		if phone := value("phone"); phone != "" {
+			user.Phone = &phone
+		}
+
+		var rowErrors []string
+		if managerID := value("manager_id"); managerID != "" {
+			parsed, err := uuid.Parse(managerID)
+			if err != nil {
+				rowErrors = append(rowErrors, "manager_id: invalid UUID format")
+			} else {
+				user.ManagerID = &parsed
+			}
+		}
+
+		if err := h.validator.Struct(user); err != nil {
+			for _, fieldErr := range err.(validator.ValidationErrors) {
+				rowErrors = append(rowErrors, fmt.Sprintf("%s: failed on the '%s' rule", importFieldName(fieldErr.Field()), fieldErr.Tag()))
+			}
+		}
+
+		key := strings.ToLower(user.Email)
+		if previous, ok := seenEmails[key]; ok && key != "" {
+			rowErrors = append(rowErrors, fmt.Sprintf("email: duplicates row %d", previous))
+		} else {
+			seenEmails[key] = rowNumber
+		}
+
+		if len(rowErrors) > 0 {
+			result.Status = importStatusInvalid
+			result.Errors = rowErrors
+		} else {
+			rows = append(rows, models.ImportRow{Row: rowNumber, User: user})
+		}
+		results = append(results, result)
+	}
+
+	return rows, results, nil
+}
+
+// importFieldName maps CreateUserRequest struct fields to their CSV field names
+func importFieldName(structField string) string {
+	switch structField {
+	case "Email":
+		return "email"
+	case "FullName":
+		return "full_name"
+	case "Phone":
+		return "phone"
+	case "Role":
+		return "role"
+	case "ManagerID":
+		return "manager_id"
+	}
+	return structField
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newImportRequest(query string, csvBody string) *http.Request {
+	req, _ := http.NewRequest("POST", "/api/v1/users/import"+query, strings.NewReader(csvBody))
+	req.Header.Set("Content-Type", "text/csv")
+	return req
+}
+
+// TestImportUsers_DryRunReport tests the per-row report for valid, invalid and existing rows
+func TestImportUsers_DryRunReport(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	csvBody := "email,full_name,role,phone\n" +
+		"new@example.com,New User,staff,\n" +
+		"not-an-email,Bad User,staff,\n" +
+		"existing@example.com,Existing,supplier,+62811\n" +
+		"NEW@example.com,Dup,staff,\n" +
+		"short@example.com,Short\n"
+
+	existingID := uuid.New()
+	phone := "+62811"
+	mockRepo.On("ImportUsers", mock.Anything, []models.ImportRow{
+		{Row: 1, User: models.CreateUserRequest{Email: "new@example.com", FullName: "New User", Role: "staff"}},
+		{Row: 3, User: models.CreateUserRequest{Email: "existing@example.com", FullName: "Existing", Role: "supplier", Phone: &phone}},
+	}, true, true).Return(&models.ImportResult{Rows: []models.ImportRowResult{
+		{Row: 1, Email: "new@example.com", Status: "would_create"},
+		{Row: 3, Email: "existing@example.com", Status: "would_update", UserID: &existingID},
+	}}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest("?dry_run=true&mode=upsert", csvBody))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ImportUsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.True(t, response.DryRun)
+	assert.False(t, response.Committed)
+	require.Len(t, response.Rows, 5)
+	assert.Equal(t, "would_create", response.Rows[0].Status)
+	assert.Equal(t, "invalid", response.Rows[1].Status)
+	assert.Equal(t, []string{"email: failed on the 'email' rule"}, response.Rows[1].Errors)
+	assert.Equal(t, "would_update", response.Rows[2].Status)
+	assert.Equal(t, "invalid", response.Rows[3].Status)
+	assert.Contains(t, response.Rows[3].Errors, "email: duplicates row 1")
+	assert.Equal(t, []string{"wrong number of columns"}, response.Rows[4].Errors)
+	assert.Equal(t, map[string]int{"would_create": 1, "would_update": 1, "invalid": 3}, response.Summary)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestImportUsers_InvalidRowsBlockImport tests that invalid rows stop a real import before writing
+func TestImportUsers_InvalidRowsBlockImport(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	csvBody := "email,full_name,role\nok@example.com,Ok,staff\nbad@example.com,Bad,owner\n"
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest("", csvBody))
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response models.ImportUsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, "skipped", response.Rows[0].Status)
+	assert.Equal(t, []string{"role: failed on the 'oneof' rule"}, response.Rows[1].Errors)
+
+	mockRepo.AssertNotCalled(t, "ImportUsers")
+}
+
+// TestImportUsers_CommittedWithMapping tests a committed import using a column mapping
+func TestImportUsers_CommittedWithMapping(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	csvBody := "Email Address,Name,Type,Ignored\njane@example.com,Jane,supplier,x\n"
+	mapping := url.QueryEscape(`{"Email Address":"email","Name":"full_name","Type":"role"}`)
+
+	createdID := uuid.New()
+	mockRepo.On("ImportUsers", mock.Anything, []models.ImportRow{
+		{Row: 1, User: models.CreateUserRequest{Email: "jane@example.com", FullName: "Jane", Role: "supplier"}},
+	}, false, false).Return(&models.ImportResult{
+		Committed: true,
+		Rows:      []models.ImportRowResult{{Row: 1, Email: "jane@example.com", Status: "created", UserID: &createdID}},
+	}, nil)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newImportRequest("?mapping="+mapping, csvBody))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.ImportUsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.True(t, response.Committed)
+	assert.Equal(t, "create", response.Mode)
+	assert.Equal(t, createdID, *response.Rows[0].UserID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestImportUsers_Multipart tests uploading the CSV as a multipart form file
+func TestImportUsers_Multipart(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "users.csv")
+	require.NoError(t, err)
+	part.Write([]byte("email,full_name,role\njane@example.com,Jane,staff\n"))
+	writer.Close()
+
+	mockRepo.On("ImportUsers", mock.Anything, mock.Anything, false, true).Return(&models.ImportResult{
+		Rows: []models.ImportRowResult{{Row: 1, Email: "jane@example.com", Status: "would_create"}},
+	}, nil)
+
+	req, _ := http.NewRequest("POST", "/api/v1/users/import?dry_run=true", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestImportUsers_BadRequests tests rejection of malformed imports
+func TestImportUsers_BadRequests(t *testing.T) {
+	testCases := []struct {
+		name    string
+		query   string
+		csvBody string
+	}{
+		{"EmptyBody", "", ""},
+		{"NoEmailColumn", "", "name,role\nJane,staff\n"},
+		{"UnknownMappingField", "?mapping=" + url.QueryEscape(`{"Email":"password"}`), "Email\njane@example.com\n"},
+		{"MappedColumnMissing", "?mapping=" + url.QueryEscape(`{"Mail":"email"}`), "Email\njane@example.com\n"},
+		{"InvalidMode", "?mode=replace", "email\njane@example.com\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, newImportRequest(tc.query, tc.csvBody))
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockRepo.AssertNotCalled(t, "ImportUsers")
+		})
+	}
+}
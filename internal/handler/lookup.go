@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// GetUserByEmail handles retrieving a user by exact, case-insensitive email
+func (h *UserHandler) GetUserByEmail(c *gin.Context) {
+	email := strings.TrimSpace(c.Param("email"))
+	if err := h.validator.Var(email, "required,email"); err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "invalid email format")
+		return
+	}
+
+	var req models.GetUserRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	fields, err := parseFieldList(req.Fields, "field")
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	user, err := h.userRepo.GetUserByEmail(c.Request.Context(), email, singleUserFields(fields))
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve user")
+		return
+	}
+
+	writeUser(c, user, fields)
+}
+
+// BatchGetUsers handles retrieving up to 500 users by ID in one request, reporting the IDs that were not found
+func (h *UserHandler) BatchGetUsers(c *gin.Context) {
+	var req models.BatchGetUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	var query models.GetUserRequest
+	if err := c.ShouldBindQuery(&query); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	fields, err := parseFieldList(query.Fields, "field")
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// The id is always needed to match users to the request, even if it is not returned
+	selected := fields
+	if fields != nil {
+		selected = withField(fields, "id")
+	}
+
+	// Drop duplicate IDs, keeping the order of first appearance
+	ids := make([]uuid.UUID, 0, len(req.IDs))
+	seen := make(map[uuid.UUID]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	users, err := h.userRepo.GetUsersByIDs(c.Request.Context(), ids, selected)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve users")
+		return
+	}
+
+	byID := make(map[uuid.UUID]models.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	response := models.BatchGetUsersResponse{
+		Data:    make([]models.User, 0, len(users)),
+		Missing: []uuid.UUID{},
+	}
+	for _, id := range ids {
+		if user, ok := byID[id]; ok {
+			response.Data = append(response.Data, user)
+		} else {
+			response.Missing = append(response.Missing, id)
+		}
+	}
+
+	if fields != nil {
+		data, err := sparseUsers(response.Data, fields)
+		if err != nil {
+			writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to retrieve users")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": data, "missing": response.Missing})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserByEmail_Success tests retrieving a user by email
+func TestGetUserByEmail_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	expectedUser := &models.User{
+		ID:        uuid.New(),
+		Email:     "jane@example.com",
+		FullName:  "Jane Doe",
+		Role:      "staff",
+		IsActive:  true,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	mockRepo.On("GetUserByEmail", mock.Anything, "Jane@Example.com", []string(nil)).Return(expectedUser, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/by-email/Jane@Example.com", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.User
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, expectedUser.ID, response.ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetUserByEmail_SparseFields tests that fields limit the response while merged_into is always read
+func TestGetUserByEmail_SparseFields(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	user := &models.User{ID: uuid.New(), FullName: "Jane Doe"}
+	mockRepo.On("GetUserByEmail", mock.Anything, "jane+ops@example.com", []string{"id", "full_name", "merged_into", "updated_at"}).Return(user, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/by-email/jane+ops@example.com?fields=id,full_name", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, fmt.Sprintf(`{"id":%q,"full_name":"Jane Doe"}`, user.ID), w.Body.String())
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetUserByEmail_Merged tests that a merged user redirects to the merge target
+func TestGetUserByEmail_Merged(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	targetID := uuid.New()
+	mockRepo.On("GetUserByEmail", mock.Anything, "old@example.com", []string(nil)).Return(&models.User{ID: uuid.New(), MergedInto: &targetID}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/by-email/old@example.com", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "/api/v1/users/"+targetID.String(), w.Header().Get("Location"))
+}
+
+// TestGetUserByEmail_Errors tests invalid emails, unknown users and repository failures
+func TestGetUserByEmail_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		email        string
+		repoErr      error
+		expectedCode int
+		errMsg       string
+	}{
+		{"InvalidEmail", "not-an-email", nil, http.StatusBadRequest, "invalid email format"},
+		{"NotFound", "nobody@example.com", fmt.Errorf("user not found: sql: no rows in result set"), http.StatusNotFound, "user not found"},
+		{"RepositoryError", "jane@example.com", fmt.Errorf("connection reset"), http.StatusInternalServerError, "failed to retrieve user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			if tt.repoErr != nil {
+				mockRepo.On("GetUserByEmail", mock.Anything, tt.email, []string(nil)).Return(nil, tt.repoErr)
+			}
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/by-email/"+tt.email, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var response models.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Equal(t, tt.errMsg, response.Detail)
+
+			if tt.repoErr == nil {
+				mockRepo.AssertNotCalled(t, "GetUserByEmail", mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+// TestBatchGetUsers_Success tests that found users are returned in request order alongside missing IDs
+func TestBatchGetUsers_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	firstID, secondID, missingID := uuid.New(), uuid.New(), uuid.New()
+	mockRepo.On("GetUsersByIDs", mock.Anything, []uuid.UUID{firstID, missingID, secondID}, []string(nil)).Return([]models.User{
+		{ID: secondID, Email: "second@example.com"},
+		{ID: firstID, Email: "first@example.com"},
+	}, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{"ids": []uuid.UUID{firstID, missingID, secondID, firstID}})
+	req, _ := http.NewRequest("POST", "/api/v1/users/batch-get", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.BatchGetUsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	require.Len(t, response.Data, 2)
+	assert.Equal(t, firstID, response.Data[0].ID)
+	assert.Equal(t, secondID, response.Data[1].ID)
+	assert.Equal(t, []uuid.UUID{missingID}, response.Missing)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestBatchGetUsers_SparseFields tests that the id is read to match users even when it is not returned
+func TestBatchGetUsers_SparseFields(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	mockRepo.On("GetUsersByIDs", mock.Anything, []uuid.UUID{userID}, []string{"email", "id"}).Return([]models.User{
+		{ID: userID, Email: "jane@example.com"},
+	}, nil)
+
+	body := fmt.Sprintf(`{"ids":[%q]}`, userID)
+	req, _ := http.NewRequest("POST", "/api/v1/users/batch-get?fields=email", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"data":[{"email":"jane@example.com"}],"missing":[]}`, w.Body.String())
+	mockRepo.AssertExpectations(t)
+}
+
+// TestBatchGetUsers_InvalidRequest tests that malformed, empty and oversized requests are rejected
+func TestBatchGetUsers_InvalidRequest(t *testing.T) {
+	tooMany := make([]string, 501)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("%q", uuid.New())
+	}
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"InvalidJSON", `{"ids":`},
+		{"InvalidUUID", `{"ids":["not-a-uuid"]}`},
+		{"Empty", `{"ids":[]}`},
+		{"Missing", `{}`},
+		{"TooMany", `{"ids":[` + strings.Join(tooMany, ",") + `]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("POST", "/api/v1/users/batch-get", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockRepo.AssertNotCalled(t, "GetUsersByIDs", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestBatchGetUsers_RepositoryError tests that repository failures return 500
+func TestBatchGetUsers_RepositoryError(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	mockRepo.On("GetUsersByIDs", mock.Anything, mock.Anything, []string(nil)).Return(nil, fmt.Errorf("connection reset"))
+
+	body := fmt.Sprintf(`{"ids":[%q]}`, uuid.New())
+	req, _ := http.NewRequest("POST", "/api/v1/users/batch-get", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "failed to retrieve users")
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// MergeUser handles folding a duplicate user into a surviving user
+func (h *UserHandler) MergeUser(c *gin.Context) {
+	sourceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	var req models.MergeUsersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	result, err := h.userRepo.MergeUsers(c.Request.Context(), sourceID, &req)
+	if err != nil {
+		errMsg := err.Error()
+		if strings.Contains(errMsg, "cannot merge a user into itself") {
+			writeProblem(c, http.StatusBadRequest, models.CodeInvalidMerge, "cannot merge a user into itself")
+			return
+		}
+		if strings.Contains(errMsg, "merge target not found") {
+			writeProblem(c, http.StatusBadRequest, models.CodeMergeTargetNotFound, "merge target not found")
+			return
+		}
+		if strings.Contains(errMsg, "already been merged") {
+			writeProblem(c, http.StatusConflict, models.CodeInvalidMerge, errMsg)
+			return
+		}
+		if strings.Contains(errMsg, "would create a cycle") {
+			writeProblem(c, http.StatusConflict, models.CodeInvalidMerge, "cannot merge a user into one of their subordinates")
+			return
+		}
+		if strings.Contains(errMsg, "duplicate key value") {
+			writeProblem(c, http.StatusConflict, models.CodeEmailConflict, "email already exists")
+			return
+		}
+		writeRepositoryError(c, err, "failed to merge users")
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMergeUser_Success tests a successful merge
+func TestMergeUser_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	expected := &models.MergeUsersResponse{
+		Target: models.User{ID: targetID, Email: "keep@example.com", IsActive: true},
+		Source: models.User{ID: sourceID, Email: "old@example.com", MergedInto: &targetID},
+	}
+
+	mockRepo.On("MergeUsers", mock.Anything, sourceID, &models.MergeUsersRequest{
+		TargetID: targetID,
+		Keep:     map[string]string{"email": "source"},
+	}).Return(expected, nil)
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"target_id": targetID,
+		"keep":      map[string]string{"email": "source"},
+	})
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/merge", sourceID), bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.MergeUsersResponse
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, targetID, response.Target.ID)
+	assert.Equal(t, targetID, *response.Source.MergedInto)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestMergeUser_InvalidKeep tests rejection of unknown fields and sides in keep
+func TestMergeUser_InvalidKeep(t *testing.T) {
+	testCases := []struct {
+		name string
+		keep map[string]string
+	}{
+		{"UnknownField", map[string]string{"is_active": "source"}},
+		{"UnknownSide", map[string]string{"email": "both"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			body, _ := json.Marshal(map[string]interface{}{
+				"target_id": uuid.New(),
+				"keep":      tc.keep,
+			})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/merge", uuid.New()), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockRepo.AssertNotCalled(t, "MergeUsers")
+		})
+	}
+}
+
+// TestMergeUser_MissingTarget tests that target_id is required
+func TestMergeUser_MissingTarget(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/merge", uuid.New()), bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockRepo.AssertNotCalled(t, "MergeUsers")
+}
+
+// TestMergeUser_Errors tests mapping of repository errors to status codes
+func TestMergeUser_Errors(t *testing.T) {
+	testCases := []struct {
+		name           string
+		repoErr        error
+		expectedStatus int
+	}{
+		{"SourceNotFound", fmt.Errorf("user not found: sql: no rows in result set"), http.StatusNotFound},
+		{"TargetNotFound", fmt.Errorf("merge target not found"), http.StatusBadRequest},
+		{"AlreadyMerged", fmt.Errorf("user has already been merged"), http.StatusConflict},
+		{"Cycle", fmt.Errorf("cannot merge a user into one of their subordinates: would create a cycle"), http.StatusConflict},
+		{"DatabaseError", fmt.Errorf("failed to commit merge"), http.StatusInternalServerError},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			mockRepo.On("MergeUsers", mock.Anything, mock.Anything, mock.Anything).Return(nil, tc.repoErr)
+
+			body, _ := json.Marshal(map[string]interface{}{"target_id": uuid.New()})
+			req, _ := http.NewRequest("POST", fmt.Sprintf("/api/v1/users/%s/merge", uuid.New()), bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetUserByID_Merged tests that a merged user redirects to the survivor
+func TestGetUserByID_Merged(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	mockRepo.On("GetUserByID", mock.Anything, sourceID, []string(nil)).Return(&models.User{ID: sourceID, MergedInto: &targetID}, nil)
+
+	req, _ := http.NewRequest("GET", fmt.Sprintf("/api/v1/users/%s", sourceID), nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, fmt.Sprintf("/api/v1/users/%s", targetID), w.Header().Get("Location"))
+
+	var response map[string]string
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, targetID.String(), response["merged_into"])
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package handler
+
+// typeMismatchMessage is the catalogue key of the message for JSON values of the wrong type
+const typeMismatchMessage = "type-mismatch"
+
+// messageCatalogue translates the fixed English messages of problem documents, keyed by locale.
+// Every message passed to writeProblem as a literal must be translated for every locale but English.
+var messageCatalogue = map[string]map[string]string{
+	"en": {
+		typeMismatchMessage: "{0} must be of type {1}",
+	},
+	"id": {
+		typeMismatchMessage: "{0} harus bertipe {1}",
+
+		"user not found":                                                 "pengguna tidak ditemukan",
+		"invalid user ID format":                                         "format ID pengguna tidak valid",
+		"invalid email format":                                           "format email tidak valid",
+		"email already exists":                                           "email sudah terdaftar",
+		"manager not found":                                              "manajer tidak ditemukan",
+		"manager assignment would create a cycle":                        "penetapan manajer ini akan membentuk siklus",
+		"user was modified concurrently, retry the request":              "pengguna diubah oleh permintaan lain pada saat yang sama, ulangi permintaan",
+		"at least one field must be provided for update":                 "setidaknya satu kolom harus diisi untuk pembaruan",
+		"no fields to update":                                            "tidak ada kolom yang diperbarui",
+		"cannot merge a user into itself":                                "pengguna tidak dapat digabungkan dengan dirinya sendiri",
+		"cannot merge a user into one of their subordinates":             "pengguna tidak dapat digabungkan ke salah satu bawahannya",
+		"merge target not found":                                         "pengguna tujuan penggabungan tidak ditemukan",
+		"user has already been erased":                                   "data pribadi pengguna sudah dihapus",
+		"user has already been merged":                                   "pengguna sudah digabungkan",
+		"erasure receipts are not configured":                            "tanda terima penghapusan belum dikonfigurasi",
+		"invalid mapping, expected a JSON object of CSV header to field": "pemetaan tidak valid, harus berupa objek JSON dari header CSV ke kolom",
+		"Idempotency-Key must be at most 255 characters":                 "Idempotency-Key paling banyak 255 karakter",
+		"Idempotency-Key has already been used for a different request":  "Idempotency-Key sudah digunakan untuk permintaan lain",
+		"a request with this Idempotency-Key is still in progress":       "permintaan dengan Idempotency-Key ini masih diproses",
+		"failed to check idempotency key":                                "gagal memeriksa Idempotency-Key",
+		"missing credentials":                                            "kredensial tidak ada",
+		"unsupported authorization scheme":                               "skema otorisasi tidak didukung",
+		"invalid credentials":                                            "kredensial tidak valid",
+		"failed to authenticate request":                                 "gagal mengautentikasi permintaan",
+		"insufficient permissions":                                       "izin tidak mencukupi",
+		"api key not found":                                              "kunci API tidak ditemukan",
+		"invalid API key ID format":                                      "format ID kunci API tidak valid",
+		"expires_at must be in the future":                               "expires_at harus berada di masa depan",
+		"failed to create api key":                                       "gagal membuat kunci API",
+		"failed to retrieve api keys":                                    "gagal mengambil kunci API",
+		"failed to revoke api key":                                       "gagal mencabut kunci API",
+		"failed to retrieve user":                                        "gagal mengambil data pengguna",
+		"failed to retrieve users":                                       "gagal mengambil data pengguna",
+		"failed to retrieve user statistics":                             "gagal mengambil statistik pengguna",
+		"failed to retrieve direct reports":                              "gagal mengambil bawahan langsung",
+		"failed to retrieve subordinates":                                "gagal mengambil bawahan",
+		"failed to retrieve management chain":                            "gagal mengambil rantai manajemen",
+		"failed to create user":                                          "gagal membuat pengguna",
+		"failed to update user":                                          "gagal memperbarui pengguna",
+		"failed to delete user":                                          "gagal menghapus pengguna",
+		"failed to merge users":                                          "gagal menggabungkan pengguna",
+		"failed to erase user":                                           "gagal menghapus data pribadi pengguna",
+		"failed to sign erasure receipt":                                 "gagal menandatangani tanda terima penghapusan",
+		"failed to import users":                                         "gagal mengimpor pengguna",
+		"failed to export users":                                         "gagal mengekspor pengguna",
+		"failed to export user data":                                     "gagal mengekspor data pengguna",
+	},
+	"nl": {
+		typeMismatchMessage: "{0} moet van het type {1} zijn",
+
+		"user not found":                                                 "gebruiker niet gevonden",
+		"invalid user ID format":                                         "ongeldig formaat voor gebruikers-ID",
+		"invalid email format":                                           "ongeldig e-mailadres",
+		"email already exists":                                           "e-mailadres is al in gebruik",
+		"manager not found":                                              "manager niet gevonden",
+		"manager assignment would create a cycle":                        "deze managertoewijzing zou een kringverwijzing veroorzaken",
+		"user was modified concurrently, retry the request":              "gebruiker is tegelijkertijd gewijzigd, probeer het opnieuw",
+		"at least one field must be provided for update":                 "geef minstens één veld op om bij te werken",
+		"no fields to update":                                            "geen velden om bij te werken",
+		"cannot merge a user into itself":                                "een gebruiker kan niet met zichzelf worden samengevoegd",
+		"cannot merge a user into one of their subordinates":             "een gebruiker kan niet worden samengevoegd met een van diens ondergeschikten",
+		"merge target not found":                                         "gebruiker om mee samen te voegen niet gevonden",
+		"user has already been erased":                                   "persoonsgegevens van de gebruiker zijn al gewist",
+		"user has already been merged":                                   "gebruiker is al samengevoegd",
+		"erasure receipts are not configured":                            "wisbewijzen zijn niet geconfigureerd",
+		"invalid mapping, expected a JSON object of CSV header to field": "ongeldige toewijzing, verwacht een JSON-object van CSV-kolomkop naar veld",
+		"Idempotency-Key must be at most 255 characters":                 "Idempotency-Key mag maximaal 255 tekens lang zijn",
+		"Idempotency-Key has already been used for a different request":  "Idempotency-Key is al gebruikt voor een ander verzoek",
+		"a request with this Idempotency-Key is still in progress":       "een verzoek met deze Idempotency-Key wordt nog verwerkt",
+		"failed to check idempotency key":                                "controleren van de Idempotency-Key is mislukt",
+		"missing credentials":                                            "inloggegevens ontbreken",
+		"unsupported authorization scheme":                               "niet-ondersteund autorisatieschema",
+		"invalid credentials":                                            "ongeldige inloggegevens",
+		"failed to authenticate request":                                 "authenticeren van het verzoek is mislukt",
+		"insufficient permissions":                                       "onvoldoende rechten",
+		"api key not found":                                              "API-sleutel niet gevonden",
+		"invalid API key ID format":                                      "ongeldig formaat voor API-sleutel-ID",
+		"expires_at must be in the future":                               "expires_at moet in de toekomst liggen",
+		"failed to create api key":                                       "aanmaken van API-sleutel is mislukt",
+		"failed to retrieve api keys":                                    "ophalen van API-sleutels is mislukt",
+		"failed to revoke api key":                                       "intrekken van API-sleutel is mislukt",
+		"failed to retrieve user":                                        "ophalen van gebruiker is mislukt",
+		"failed to retrieve users":                                       "ophalen van gebruikers is mislukt",
+		"failed to retrieve user statistics":                             "ophalen van gebruikersstatistieken is mislukt",
+		"failed to retrieve direct reports":                              "ophalen van directe ondergeschikten is mislukt",
+		"failed to retrieve subordinates":                                "ophalen van ondergeschikten is mislukt",
+		"failed to retrieve management chain":                            "ophalen van de managementketen is mislukt",
+		"failed to create user":                                          "aanmaken van gebruiker is mislukt",
+		"failed to update user":                                          "bijwerken van gebruiker is mislukt",
+		"failed to delete user":                                          "verwijderen van gebruiker is mislukt",
+		"failed to merge users":                                          "samenvoegen van gebruikers is mislukt",
+		"failed to erase user":                                           "wissen van gebruiker is mislukt",
+		"failed to sign erasure receipt":                                 "ondertekenen van het wisbewijs is mislukt",
+		"failed to import users":                                         "importeren van gebruikers is mislukt",
+		"failed to export users":                                         "exporteren van gebruikers is mislukt",
+		"failed to export user data":                                     "exporteren van gebruikersgegevens is mislukt",
+	},
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+)
+
+// requestValidator is shared by all handlers, as its messages are registered with the package's translators
+var requestValidator = newValidator()
+
+// newValidator creates the request validator, which reports fields by their JSON or query parameter
+// name and describes failures in every supported locale
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form"} {
+			name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+			if name != "" && name != "-" {
+				return name
+			}
+		}
+		return ""
+	})
+	if err := registerTranslations(v); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// writeProblem responds with an RFC 7807 problem document and stops the handler chain. detail is
+// translated into the request's language if it is in the message catalogue.
+func writeProblem(c *gin.Context, status int, code, detail string) {
+	trans := requestTranslator(c)
+	writeProblemDocument(c, trans, &models.Problem{Status: status, Code: code, Detail: translate(trans, detail)})
+}
+
+// writeRepositoryError responds to a repository error that the endpoint does not map itself.
+// Denials and missing users mean the same everywhere; any other error is reported with the
+// endpoint's fallback detail, so internal errors are never disclosed.
+func writeRepositoryError(c *gin.Context, err error, fallback string) {
+	switch {
+	case errors.Is(err, authz.ErrForbidden):
+		writeProblem(c, http.StatusForbidden, models.CodeForbidden, "insufficient permissions")
+	case strings.Contains(err.Error(), "user not found"):
+		writeProblem(c, http.StatusNotFound, models.CodeUserNotFound, "user not found")
+	default:
+		writeProblem(c, http.StatusInternalServerError, models.CodeInternal, fallback)
+	}
+}
+
+func writeProblemDocument(c *gin.Context, trans ut.Translator, problem *models.Problem) {
+	problem.Type = "about:blank"
+	problem.Title = http.StatusText(problem.Status)
+	problem.Instance = c.Request.URL.Path
+	problem.RequestID = c.GetString(requestIDKey)
+
+	c.Header("Content-Type", models.ProblemContentType)
+	c.Header("Content-Language", trans.Locale())
+	c.Header("Vary", "Accept-Language")
+	c.AbortWithStatusJSON(problem.Status, problem)
+}
+
+// writeBindError responds to a request body or query string that could not be decoded.
+// Values of the wrong JSON type are reported as field errors.
+func writeBindError(c *gin.Context, err error) {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		trans := requestTranslator(c)
+		message := translate(trans, typeMismatchMessage, typeErr.Field, typeErr.Type.String())
+		writeProblemDocument(c, trans, &models.Problem{
+			Status: http.StatusBadRequest,
+			Code:   models.CodeValidationFailed,
+			Detail: message,
+			Errors: []models.FieldError{{
+				Field:   typeErr.Field,
+				Rule:    "type",
+				Param:   typeErr.Type.String(),
+				Message: message,
+			}},
+		})
+		return
+	}
+
+	writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+}
+
+// writeValidationError responds with one field error per failed validation rule
+func writeValidationError(c *gin.Context, err error) {
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	trans := requestTranslator(c)
+	fieldErrors := make([]models.FieldError, 0, len(validationErrors))
+	details := make([]string, 0, len(validationErrors))
+	for _, fe := range validationErrors {
+		message := fe.Translate(trans)
+		if message == fe.Error() {
+			// No catalogue has the rule; avoid the validator's Go-style message
+			message = fmt.Sprintf("%s failed the %s rule", fe.Field(), fe.Tag())
+		}
+		fieldErrors = append(fieldErrors, models.FieldError{
+			Field:   fieldPath(fe.Namespace()),
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: message,
+		})
+		details = append(details, message)
+	}
+
+	writeProblemDocument(c, trans, &models.Problem{
+		Status: http.StatusBadRequest,
+		Code:   models.CodeValidationFailed,
+		Detail: strings.Join(details, "; "),
+		Errors: fieldErrors,
+	})
+}
+
+// fieldPath turns a validator namespace such as "CreateUserRequest.email" into the field's path in
+// the request. The leading struct name is dropped, as are embedded structs, which keep their Go name
+// because they are flattened in JSON and query strings.
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")
+	path := make([]string, 0, len(segments))
+	for _, segment := range segments[1:] {
+		if r := []rune(segment); len(r) > 0 && unicode.IsUpper(r[0]) {
+			continue
+		}
+		path = append(path, segment)
+	}
+	return strings.Join(path, ".")
+}
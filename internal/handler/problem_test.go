@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeProblem checks that a response is a problem document and decodes it
+func decodeProblem(t *testing.T, w *httptest.ResponseRecorder) models.Problem {
+	t.Helper()
+	assert.Equal(t, models.ProblemContentType, w.Header().Get("Content-Type"))
+
+	var problem models.Problem
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, w.Code, problem.Status)
+	assert.Equal(t, "about:blank", problem.Type)
+	assert.Equal(t, http.StatusText(w.Code), problem.Title)
+	assert.Equal(t, w.Header().Get(RequestIDHeader), problem.RequestID)
+	return problem
+}
+
+// TestProblem_ValidationErrors tests that every failed rule is reported with its JSON field name
+func TestProblem_ValidationErrors(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("POST", "/api/v1/users/", strings.NewReader(`{"email":"not-an-email","role":"owner"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, models.CodeValidationFailed, problem.Code)
+	assert.Equal(t, "/api/v1/users/", problem.Instance)
+	assert.Equal(t, []models.FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "full_name", Rule: "required", Message: "full_name is a required field"},
+		{Field: "role", Rule: "oneof", Param: "admin staff supplier", Message: "role must be one of [admin staff supplier]"},
+	}, problem.Errors)
+	mockRepo.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+// TestProblem_QueryValidationErrors tests that query parameters are reported by name, including
+// those of embedded request structs and list indexes
+func TestProblem_QueryValidationErrors(t *testing.T) {
+	handler, _ := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/stats?role=admin,owner&page_size=500", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, models.CodeValidationFailed, problem.Code)
+	require.Len(t, problem.Errors, 2)
+	assert.Equal(t, "role[1]", problem.Errors[0].Field)
+	assert.Equal(t, models.FieldError{Field: "page_size", Rule: "max", Param: "100", Message: "page_size must be 100 or less"}, problem.Errors[1])
+}
+
+// TestProblem_TypeError tests that JSON values of the wrong type are reported as field errors
+func TestProblem_TypeError(t *testing.T) {
+	handler, _ := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	req, _ := http.NewRequest("PATCH", "/api/v1/users/"+uuid.New().String(), strings.NewReader(`{"is_active":"yes"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	problem := decodeProblem(t, w)
+	assert.Equal(t, models.CodeValidationFailed, problem.Code)
+	assert.Equal(t, []models.FieldError{{Field: "is_active", Rule: "type", Param: "bool", Message: "is_active must be of type bool"}}, problem.Errors)
+}
+
+// TestProblem_Codes tests the stable codes of errors that are not about a single field
+func TestProblem_Codes(t *testing.T) {
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		method       string
+		path         string
+		body         string
+		setupMock    func(*MockUserRepository)
+		expectedCode int
+		problemCode  string
+	}{
+		{"MalformedJSON", "POST", "/api/v1/users/", `{"email":`, nil, http.StatusBadRequest, models.CodeInvalidRequest},
+		{"InvalidUserID", "GET", "/api/v1/users/not-a-uuid", "", nil, http.StatusBadRequest, models.CodeInvalidUserID},
+		{"UserNotFound", "GET", "/api/v1/users/" + userID.String(), "", func(m *MockUserRepository) {
+			m.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, errors.New("user not found"))
+		}, http.StatusNotFound, models.CodeUserNotFound},
+		{"EmailConflict", "PATCH", "/api/v1/users/" + userID.String(), `{"email":"taken@example.com"}`, func(m *MockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("duplicate key value violates unique constraint"))
+		}, http.StatusConflict, models.CodeEmailConflict},
+		{"ManagerCycle", "PATCH", "/api/v1/users/" + userID.String(), `{"manager_id":"` + uuid.New().String() + `"}`, func(m *MockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("manager assignment would create a cycle"))
+		}, http.StatusConflict, models.CodeManagerCycle},
+		{"UserMerged", "PATCH", "/api/v1/users/" + userID.String(), `{"full_name":"Jane Doe"}`, func(m *MockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("user has already been merged"))
+		}, http.StatusConflict, models.CodeUserMerged},
+		{"UserErased", "PATCH", "/api/v1/users/" + userID.String(), `{"full_name":"Jane Doe"}`, func(m *MockUserRepository) {
+			m.On("UpdateUser", mock.Anything, userID, mock.Anything).Return(nil, errors.New("user has already been erased"))
+		}, http.StatusGone, models.CodeUserAlreadyErased},
+		{"Forbidden", "GET", "/api/v1/users/" + userID.String(), "", func(m *MockUserRepository) {
+			m.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, fmt.Errorf("%w: supplier may not read user", authz.ErrForbidden))
+		}, http.StatusForbidden, models.CodeForbidden},
+		{"InternalError", "DELETE", "/api/v1/users/" + userID.String(), "", func(m *MockUserRepository) {
+			m.On("DeleteUser", mock.Anything, userID).Return(nil, errors.New("connection refused"))
+		}, http.StatusInternalServerError, models.CodeInternal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			if tt.setupMock != nil {
+				tt.setupMock(mockRepo)
+			}
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			problem := decodeProblem(t, w)
+			assert.Equal(t, tt.problemCode, problem.Code)
+			assert.NotEmpty(t, problem.Detail)
+			assert.Empty(t, problem.Errors)
+		})
+	}
+}
+
+// TestRequestID tests that client request IDs are kept when well-formed and replaced otherwise
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		kept      bool
+	}{
+		{"None", "", false},
+		{"WellFormed", "req-2026.10:abc_1", true},
+		{"Unsafe", "abc\r\nSet-Cookie: x", false},
+		{"TooLong", strings.Repeat("a", maxRequestIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _ := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/not-a-uuid", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			problem := decodeProblem(t, w)
+			if tt.kept {
+				assert.Equal(t, tt.requestID, problem.RequestID)
+			} else {
+				_, err := uuid.Parse(problem.RequestID)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
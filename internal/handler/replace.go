@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/patch"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ReplaceUser handles replacing every changeable field of a user; omitted or null optional fields are cleared
+func (h *UserHandler) ReplaceUser(c *gin.Context) {
+	userID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	var req models.ReplaceUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	h.replaceUser(c, userID, &req, nil)
+}
+
+// patchUser applies a merge patch or JSON patch to the user's current state and writes the result
+// back, provided nobody changed the user in between
+func (h *UserHandler) patchUser(c *gin.Context, userID uuid.UUID, contentType string) {
+	body, err := c.GetRawData()
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	current, err := h.userRepo.GetUserByID(c.Request.Context(), userID, nil)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to update user")
+		return
+	}
+
+	doc, err := json.Marshal(replacementOf(current))
+	if err != nil {
+		writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to update user")
+		return
+	}
+
+	var patched []byte
+	if contentType == patch.MergePatchContentType {
+		patched, err = patch.MergePatch(doc, body)
+	} else {
+		patched, err = patch.JSONPatch(doc, body)
+	}
+	if err != nil {
+		if strings.Contains(err.Error(), "test failed") {
+			writeProblem(c, http.StatusConflict, models.CodePatchTestFailed, err.Error())
+			return
+		}
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Only the fields of ReplaceUserRequest may be patched
+	var req models.ReplaceUserRequest
+	decoder := json.NewDecoder(bytes.NewReader(patched))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		writeBindError(c, fmt.Errorf("invalid patch result: %w", err))
+		return
+	}
+
+	h.replaceUser(c, userID, &req, &current.UpdatedAt)
+}
+
+// replaceUser validates a full replacement and writes it, responding with the updated user
+func (h *UserHandler) replaceUser(c *gin.Context, userID uuid.UUID, req *models.ReplaceUserRequest, unmodifiedSince *time.Time) {
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	updatedUser, err := h.userRepo.ReplaceUser(c.Request.Context(), userID, req, unmodifiedSince)
+	if err != nil {
+		writeUpdateError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updatedUser)
+}
+
+// replacementOf returns the changeable fields of a user
+func replacementOf(user *models.User) *models.ReplaceUserRequest {
+	isActive := user.IsActive
+	return &models.ReplaceUserRequest{
+		Email:     user.Email,
+		FullName:  user.FullName,
+		Phone:     user.Phone,
+		Role:      user.Role,
+		IsActive:  &isActive,
+		ManagerID: user.ManagerID,
+	}
+}
+
+// writeUpdateError maps an error from updating or replacing a user to a response
+func writeUpdateError(c *gin.Context, err error) {
+	errMsg := err.Error()
+	switch {
+	case strings.Contains(errMsg, "manager not found"):
+		writeProblem(c, http.StatusBadRequest, models.CodeManagerNotFound, "manager not found")
+	case strings.Contains(errMsg, "would create a cycle"):
+		writeProblem(c, http.StatusConflict, models.CodeManagerCycle, "manager assignment would create a cycle")
+	case strings.Contains(errMsg, "modified concurrently"):
+		writeProblem(c, http.StatusConflict, models.CodeConcurrentModification, "user was modified concurrently, retry the request")
+	case strings.Contains(errMsg, "no fields to update"):
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "no fields to update")
+	case strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists"):
+		writeProblem(c, http.StatusConflict, models.CodeEmailConflict, "email already exists")
+	case strings.Contains(errMsg, "already been merged"):
+		writeProblem(c, http.StatusConflict, models.CodeUserMerged, "user has already been merged")
+	case strings.Contains(errMsg, "already been erased"):
+		writeProblem(c, http.StatusGone, models.CodeUserAlreadyErased, "user has already been erased")
+	default:
+		writeRepositoryError(c, err, "failed to update user")
+	}
+}
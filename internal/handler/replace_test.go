@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// patchTestUser returns a stored user with every optional field set
+func patchTestUser() *models.User {
+	managerID := uuid.New()
+	return &models.User{
+		ID:        uuid.New(),
+		Email:     "jane@example.com",
+		FullName:  "Jane Doe",
+		Phone:     stringPtr("555-0100"),
+		Role:      "staff",
+		IsActive:  true,
+		ManagerID: &managerID,
+		CreatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt: time.Date(2026, 3, 1, 9, 30, 0, 123456000, time.UTC),
+	}
+}
+
+// sendPatch sends a PATCH request with the given content type
+func sendPatch(handler *UserHandler, userID uuid.UUID, contentType, body string) *httptest.ResponseRecorder {
+	router := setupTestRouter(handler)
+	req, _ := http.NewRequest("PATCH", fmt.Sprintf("/api/v1/users/%s", userID), strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// TestUpdateUser_MergePatch tests that null clears a field and other members are kept
+func TestUpdateUser_MergePatch(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	current := patchTestUser()
+
+	isActive := true
+	expected := &models.ReplaceUserRequest{
+		Email:    current.Email,
+		FullName: "Jane Smith",
+		Role:     current.Role,
+		IsActive: &isActive,
+	}
+	updated := &models.User{ID: current.ID, Email: current.Email, FullName: "Jane Smith", Role: current.Role, IsActive: true}
+
+	mockRepo.On("GetUserByID", mock.Anything, current.ID, []string(nil)).Return(current, nil)
+	mockRepo.On("ReplaceUser", mock.Anything, current.ID, expected, &current.UpdatedAt).Return(updated, nil)
+
+	w := sendPatch(handler, current.ID, "application/merge-patch+json", `{"full_name":"Jane Smith","phone":null,"manager_id":null}`)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.User
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Nil(t, response.Phone)
+	assert.Equal(t, "Jane Smith", response.FullName)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateUser_JSONPatch tests that tested and replaced values are applied to the current user
+func TestUpdateUser_JSONPatch(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	current := patchTestUser()
+
+	isActive := false
+	expected := &models.ReplaceUserRequest{
+		Email:     current.Email,
+		FullName:  current.FullName,
+		Role:      "admin",
+		IsActive:  &isActive,
+		ManagerID: current.ManagerID,
+	}
+
+	mockRepo.On("GetUserByID", mock.Anything, current.ID, []string(nil)).Return(current, nil)
+	mockRepo.On("ReplaceUser", mock.Anything, current.ID, expected, &current.UpdatedAt).Return(&models.User{ID: current.ID}, nil)
+
+	body := `[
+		{"op": "test", "path": "/role", "value": "staff"},
+		{"op": "replace", "path": "/role", "value": "admin"},
+		{"op": "replace", "path": "/is_active", "value": false},
+		{"op": "remove", "path": "/phone"}
+	]`
+	w := sendPatch(handler, current.ID, "application/json-patch+json", body)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestUpdateUser_PatchErrors tests failed tests, malformed patches and invalid results
+func TestUpdateUser_PatchErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+		errMsg       string
+	}{
+		{"TestFailed", "application/json-patch+json", `[{"op":"test","path":"/role","value":"admin"},{"op":"replace","path":"/role","value":"supplier"}]`, http.StatusConflict, "test failed"},
+		{"MalformedJSONPatch", "application/json-patch+json", `{"op":"replace"}`, http.StatusBadRequest, "expected an array of operations"},
+		{"MalformedMergePatch", "application/merge-patch+json", `{"full_name":`, http.StatusBadRequest, "invalid merge patch"},
+		{"ReadOnlyField", "application/merge-patch+json", `{"id":"` + uuid.NewString() + `"}`, http.StatusBadRequest, "unknown field"},
+		{"WrongType", "application/json-patch+json", `[{"op":"replace","path":"/is_active","value":"yes"}]`, http.StatusBadRequest, "is_active must be of type bool"},
+		{"ClearRequiredField", "application/merge-patch+json", `{"email":null}`, http.StatusBadRequest, "email is a required field"},
+		{"InvalidRole", "application/merge-patch+json", `{"role":"owner"}`, http.StatusBadRequest, "role must be one of"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			current := patchTestUser()
+			mockRepo.On("GetUserByID", mock.Anything, current.ID, []string(nil)).Return(current, nil)
+
+			w := sendPatch(handler, current.ID, tt.contentType, tt.body)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.errMsg)
+			mockRepo.AssertNotCalled(t, "ReplaceUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestUpdateUser_PatchNotFound tests patching a user that does not exist
+func TestUpdateUser_PatchNotFound(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	userID := uuid.New()
+
+	mockRepo.On("GetUserByID", mock.Anything, userID, []string(nil)).Return(nil, fmt.Errorf("user not found: sql: no rows in result set"))
+
+	w := sendPatch(handler, userID, "application/merge-patch+json", `{"full_name":"X"}`)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// TestUpdateUser_PatchConcurrentModification tests that a lost update is reported as a conflict
+func TestUpdateUser_PatchConcurrentModification(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	current := patchTestUser()
+
+	mockRepo.On("GetUserByID", mock.Anything, current.ID, []string(nil)).Return(current, nil)
+	mockRepo.On("ReplaceUser", mock.Anything, current.ID, mock.Anything, &current.UpdatedAt).Return(nil, fmt.Errorf("user was modified concurrently"))
+
+	w := sendPatch(handler, current.ID, "application/merge-patch+json", `{"full_name":"X"}`)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "modified concurrently")
+}
+
+// TestReplaceUser_Success tests that a full replacement clears omitted optional fields
+func TestReplaceUser_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	userID := uuid.New()
+	isActive := true
+	expected := &models.ReplaceUserRequest{Email: "jane@example.com", FullName: "Jane Doe", Role: "supplier", IsActive: &isActive}
+	mockRepo.On("ReplaceUser", mock.Anything, userID, expected, (*time.Time)(nil)).Return(&models.User{ID: userID, Email: "jane@example.com"}, nil)
+
+	body := `{"email":"jane@example.com","full_name":"Jane Doe","role":"supplier","is_active":true}`
+	req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/users/%s", userID), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestReplaceUser_Errors tests validation and repository errors of a full replacement
+func TestReplaceUser_Errors(t *testing.T) {
+	valid := `{"email":"jane@example.com","full_name":"Jane Doe","role":"staff","is_active":true,"manager_id":"` + uuid.NewString() + `"}`
+
+	tests := []struct {
+		name         string
+		body         string
+		repoErr      error
+		expectedCode int
+		errMsg       string
+	}{
+		{"MissingIsActive", `{"email":"jane@example.com","full_name":"Jane Doe","role":"staff"}`, nil, http.StatusBadRequest, "is_active is a required field"},
+		{"MissingEmail", `{"full_name":"Jane Doe","role":"staff","is_active":true}`, nil, http.StatusBadRequest, "email is a required field"},
+		{"InvalidJSON", `{"email":`, nil, http.StatusBadRequest, ""},
+		{"NotFound", valid, fmt.Errorf("user not found: sql: no rows in result set"), http.StatusNotFound, "user not found"},
+		{"ManagerNotFound", valid, fmt.Errorf("manager not found"), http.StatusBadRequest, "manager not found"},
+		{"Cycle", valid, fmt.Errorf("manager assignment would create a cycle"), http.StatusConflict, "would create a cycle"},
+		{"DuplicateEmail", valid, fmt.Errorf("failed to replace user: pq: duplicate key value violates unique constraint"), http.StatusConflict, "email already exists"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+			userID := uuid.New()
+
+			if tt.repoErr != nil {
+				mockRepo.On("ReplaceUser", mock.Anything, userID, mock.Anything, (*time.Time)(nil)).Return(nil, tt.repoErr)
+			}
+
+			req, _ := http.NewRequest("PUT", fmt.Sprintf("/api/v1/users/%s", userID), strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Contains(t, w.Body.String(), tt.errMsg)
+			if tt.repoErr == nil {
+				mockRepo.AssertNotCalled(t, "ReplaceUser", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that correlates a request with its logs and error responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey stores the request ID in the gin context
+const requestIDKey = "request_id"
+
+// maxRequestIDLength bounds the length of request IDs accepted from clients
+const maxRequestIDLength = 128
+
+// RequestID returns middleware that assigns every request an ID, echoed in the X-Request-ID
+// response header. A well-formed ID sent by the client or a proxy is kept; otherwise a UUID is generated.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+		c.Set(requestIDKey, requestID)
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+// validRequestID reports whether id is short and only uses characters that are safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
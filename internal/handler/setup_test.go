@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"time"
 
 	"github.com/GoodsChain/user/internal/models"
 	"github.com/gin-gonic/gin"
@@ -22,14 +23,30 @@ func (m *MockUserRepository) CreateUser(ctx context.Context, user *models.User)
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
-	args := m.Called(ctx, id)
+func (m *MockUserRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	args := m.Called(ctx, id, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error) {
+	args := m.Called(ctx, email, fields)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error) {
+	args := m.Called(ctx, ids, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
 	args := m.Called(ctx, id, updates)
 	if args.Get(0) == nil {
@@ -38,6 +55,14 @@ func (m *MockUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updat
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) ReplaceUser(ctx context.Context, id uuid.UUID, replacement *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error) {
+	args := m.Called(ctx, id, replacement, unmodifiedSince)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
 func (m *MockUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	args := m.Called(ctx, id)
 	if args.Get(0) == nil {
@@ -46,14 +71,90 @@ func (m *MockUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) (*mod
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
-func (m *MockUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams) (*models.GetUsersResponse, error) {
-	args := m.Called(ctx, filters, sort, pagination)
+func (m *MockUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	args := m.Called(ctx, filters, sort, pagination, fields)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.GetUsersResponse), args.Error(1)
 }
 
+func (m *MockUserRepository) GetDirectReports(ctx context.Context, managerID uuid.UUID) ([]models.User, error) {
+	args := m.Called(ctx, managerID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.User), args.Error(1)
+}
+
+func (m *MockUserRepository) GetSubordinates(ctx context.Context, managerID uuid.UUID, maxDepth int) ([]models.UserHierarchyEntry, error) {
+	args := m.Called(ctx, managerID, maxDepth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserHierarchyEntry), args.Error(1)
+}
+
+func (m *MockUserRepository) GetManagementChain(ctx context.Context, id uuid.UUID) ([]models.UserHierarchyEntry, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserHierarchyEntry), args.Error(1)
+}
+
+func (m *MockUserRepository) MergeUsers(ctx context.Context, sourceID uuid.UUID, req *models.MergeUsersRequest) (*models.MergeUsersResponse, error) {
+	args := m.Called(ctx, sourceID, req)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.MergeUsersResponse), args.Error(1)
+}
+
+func (m *MockUserRepository) EraseUser(ctx context.Context, id uuid.UUID) (*models.ErasureResult, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ErasureResult), args.Error(1)
+}
+
+func (m *MockUserRepository) ExportUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserDataExport), args.Error(1)
+}
+
+func (m *MockUserRepository) StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error {
+	args := m.Called(ctx, filters, sort, fn)
+	if users, ok := args.Get(0).([]models.User); ok {
+		for i := range users {
+			if err := fn(&users[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+func (m *MockUserRepository) ImportUsers(ctx context.Context, rows []models.ImportRow, upsert bool, dryRun bool) (*models.ImportResult, error) {
+	args := m.Called(ctx, rows, upsert, dryRun)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportResult), args.Error(1)
+}
+
+func (m *MockUserRepository) GetUserStats(ctx context.Context, filters *models.FilterParams, params *models.StatsParams) (*models.UserStats, error) {
+	args := m.Called(ctx, filters, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UserStats), args.Error(1)
+}
+
 // setupTestHandler creates a test handler with mock repository
 func setupTestHandler() (*UserHandler, *MockUserRepository) {
 	mockRepo := &MockUserRepository{}
@@ -65,14 +166,27 @@ func setupTestHandler() (*UserHandler, *MockUserRepository) {
 func setupTestRouter(handler *UserHandler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	r := gin.New()
+	r.Use(RequestID())
 	v1 := r.Group("/api/v1")
 	users := v1.Group("/users")
 	{
 		users.GET("/", handler.GetAllUsers)
-		users.POST("/", handler.CreateUser)
+		users.POST("/", handler.Idempotent(), handler.CreateUser)
+		users.GET("/export", handler.ExportUsers)
+		users.POST("/import", handler.Idempotent(), handler.ImportUsers)
+		users.GET("/stats", handler.GetUserStats)
+		users.GET("/by-email/:email", handler.GetUserByEmail)
+		users.POST("/batch-get", handler.BatchGetUsers)
 		users.GET("/:id", handler.GetUserByID)
-		users.PATCH("/:id", handler.UpdateUser)
-		users.DELETE("/:id", handler.DeleteUser)
+		users.PATCH("/:id", handler.Idempotent(), handler.UpdateUser)
+		users.PUT("/:id", handler.Idempotent(), handler.ReplaceUser)
+		users.DELETE("/:id", handler.Idempotent(), handler.DeleteUser)
+		users.GET("/:id/reports", handler.GetDirectReports)
+		users.GET("/:id/subordinates", handler.GetSubordinates)
+		users.GET("/:id/chain", handler.GetManagementChain)
+		users.POST("/:id/merge", handler.Idempotent(), handler.MergeUser)
+		users.POST("/:id/erase", handler.Idempotent(), handler.EraseUser)
+		users.GET("/:id/export", handler.ExportUserData)
 	}
 	return r
 }
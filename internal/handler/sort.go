@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+)
+
+// sortFields lists the fields users can be sorted by, and whether each one is nullable
+var sortFields = map[string]bool{
+	"id":         false,
+	"email":      false,
+	"full_name":  false,
+	"phone":      true,
+	"role":       false,
+	"is_active":  false,
+	"manager_id": true,
+	"created_at": false,
+	"updated_at": false,
+}
+
+// parseSort parses a sort parameter such as "role,-full_name,phone:nulls_last".
+// A "-" prefix sorts descending; ":nulls_first" or ":nulls_last" places NULLs of nullable fields.
+func parseSort(raw string) ([]models.SortField, error) {
+	var keys []models.SortField
+	seen := map[string]bool{}
+
+	for _, term := range strings.Split(raw, ",") {
+		term = strings.TrimSpace(term)
+		key := models.SortField{Order: "asc"}
+
+		if strings.HasPrefix(term, "-") {
+			key.Order = "desc"
+			term = term[1:]
+		}
+
+		term, nulls, hasNulls := strings.Cut(term, ":")
+		key.Field = term
+
+		nullable, ok := sortFields[key.Field]
+		if !ok {
+			return nil, fmt.Errorf("invalid sort field: %q", key.Field)
+		}
+		if seen[key.Field] {
+			return nil, fmt.Errorf("invalid sort: %s is listed more than once", key.Field)
+		}
+		seen[key.Field] = true
+
+		if hasNulls {
+			switch nulls {
+			case "nulls_first":
+				key.Nulls = "first"
+			case "nulls_last":
+				key.Nulls = "last"
+			default:
+				return nil, fmt.Errorf("invalid sort modifier %q, use nulls_first or nulls_last", nulls)
+			}
+			if !nullable {
+				return nil, fmt.Errorf("invalid sort: %s is never null", key.Field)
+			}
+		}
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
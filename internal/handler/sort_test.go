@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAllUsers_MultiColumnSort tests parsing of the sort parameter
+func TestGetAllUsers_MultiColumnSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		expected *models.SortParams
+	}{
+		{
+			name:     "LegacySortBy",
+			query:    "sort_by=email&sort_order=desc",
+			expected: &models.SortParams{Field: "email", Order: "desc"},
+		},
+		{
+			name:  "MultiColumn",
+			query: "sort=role,-full_name,created_at",
+			expected: &models.SortParams{Fields: []models.SortField{
+				{Field: "role", Order: "asc"},
+				{Field: "full_name", Order: "desc"},
+				{Field: "created_at", Order: "asc"},
+			}},
+		},
+		{
+			name:  "NullsOrdering",
+			query: "sort=-phone:nulls_last,manager_id:nulls_first&sort_by=email",
+			expected: &models.SortParams{Fields: []models.SortField{
+				{Field: "phone", Order: "desc", Nulls: "last"},
+				{Field: "manager_id", Order: "asc", Nulls: "first"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			expectedResponse := &models.GetUsersResponse{Data: []models.User{}, Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, TotalPages: 1}}
+			mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, tt.expected, mock.Anything, []string(nil)).Return(expectedResponse, nil)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetAllUsers_InvalidMultiColumnSort tests that malformed sort parameters are rejected
+func TestGetAllUsers_InvalidMultiColumnSort(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		errMsg string
+	}{
+		{"UnknownField", "sort=role,-password", `invalid sort field: "password"`},
+		{"EmptyTerm", "sort=role,,email", `invalid sort field: ""`},
+		{"Duplicate", "sort=role,-role", "role is listed more than once"},
+		{"UnknownModifier", "sort=phone:nulls_middle", `invalid sort modifier "nulls_middle"`},
+		{"NullsOnRequiredField", "sort=email:nulls_last", "email is never null"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Contains(t, response.Detail, tt.errMsg)
+
+			mockRepo.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
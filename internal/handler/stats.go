@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// statsCacheMaxEntries bounds the number of distinct queries whose statistics are cached
+const statsCacheMaxEntries = 1000
+
+// WithStatsCacheTTL caches user statistics for ttl; zero disables caching
+func WithStatsCacheTTL(ttl time.Duration) Option {
+	return func(h *UserHandler) {
+		if ttl > 0 {
+			h.statsCache = newStatsCache(ttl)
+		}
+	}
+}
+
+// statsCacheEntry is a cached statistics result and the time it expires
+type statsCacheEntry struct {
+	stats   *models.UserStats
+	expires time.Time
+}
+
+// statsCache is an in-memory TTL cache of statistics keyed by their query. A nil cache caches nothing.
+type statsCache struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]statsCacheEntry
+}
+
+func newStatsCache(ttl time.Duration) *statsCache {
+	return &statsCache{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[string]statsCacheEntry),
+	}
+}
+
+// get returns the cached statistics for key if they have not expired
+func (c *statsCache) get(key string) (*models.UserStats, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expires) {
+		return nil, false
+	}
+	return entry.stats, true
+}
+
+// set caches stats for key, dropping expired entries first
+func (c *statsCache) set(key string, stats *models.UserStats) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	if len(c.entries) >= statsCacheMaxEntries {
+		return
+	}
+	c.entries[key] = statsCacheEntry{stats: stats, expires: now.Add(c.ttl)}
+}
+
+// GetUserStats handles aggregating the users matching the list filters into counts and time series
+func (h *UserHandler) GetUserStats(c *gin.Context) {
+	var req models.GetUserStatsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+	splitListParams(&req.GetUsersRequest)
+
+	if err := h.validator.Struct(req); err != nil {
+		writeValidationError(c, err)
+		return
+	}
+
+	filters, _, _, err := h.parseQueryParams(&req.GetUsersRequest)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	// Buckets start at midnight in the same time zone plain dates are read in
+	loc, err := requestLocation(req.TZ)
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	params := &models.StatsParams{Interval: "day", TimeZone: loc.String()}
+	if req.Interval != nil {
+		params.Interval = *req.Interval
+	}
+
+	// Encode sorts parameters by name, so equivalent queries share an entry. Entries are kept per
+	// principal, so that a cached answer never skips the authorization applied by the repository.
+	key := c.Request.URL.Query().Encode()
+	if principal, ok := auth.FromContext(c.Request.Context()); ok {
+		key = principal.Key() + " " + key
+	}
+	if stats, ok := h.statsCache.get(key); ok {
+		c.JSON(http.StatusOK, stats)
+		return
+	}
+
+	stats, err := h.userRepo.GetUserStats(c.Request.Context(), filters, params)
+	if err != nil {
+		writeRepositoryError(c, err, "failed to retrieve user statistics")
+		return
+	}
+	h.statsCache.set(key, stats)
+
+	c.JSON(http.StatusOK, stats)
+}
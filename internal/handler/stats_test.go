@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserStats_Success tests that list filters and bucketing options reach the repository
+func TestGetUserStats_Success(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	role := "supplier"
+	expectedFilters := &models.FilterParams{Role: &role}
+	expectedParams := &models.StatsParams{Interval: "week", TimeZone: "Europe/Amsterdam"}
+	expectedStats := &models.UserStats{
+		Total:         2,
+		ByRole:        map[string]int{"supplier": 2},
+		ByStatus:      map[string]int{"active": 2, "inactive": 0},
+		ByEmailDomain: []models.DomainCount{{Domain: "acme.com", Count: 2}},
+		Interval:      "week",
+		TimeZone:      "Europe/Amsterdam",
+		Signups:       []models.StatsBucket{{Start: time.Date(2026, 1, 4, 23, 0, 0, 0, time.UTC), Count: 2}},
+		Deactivations: []models.StatsBucket{},
+	}
+	mockRepo.On("GetUserStats", mock.Anything, expectedFilters, expectedParams).Return(expectedStats, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/stats?role=supplier&interval=week&tz=Europe/Amsterdam", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.UserStats
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.Equal(t, 2, response.Total)
+	assert.Equal(t, map[string]int{"active": 2, "inactive": 0}, response.ByStatus)
+	assert.Len(t, response.Signups, 1)
+
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetUserStats_Defaults tests that statistics are bucketed by day in UTC by default
+func TestGetUserStats_Defaults(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	expectedParams := &models.StatsParams{Interval: "day", TimeZone: "UTC"}
+	mockRepo.On("GetUserStats", mock.Anything, mock.Anything, expectedParams).Return(&models.UserStats{}, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/users/stats", nil)
+	w := httptest.NewRecorder()
+
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockRepo.AssertExpectations(t)
+}
+
+// TestGetUserStats_Cache tests that results are served from the cache until the TTL passes
+func TestGetUserStats_Cache(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	handler := NewUserHandler(mockRepo, WithStatsCacheTTL(time.Minute))
+	router := setupTestRouter(handler)
+
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	handler.statsCache.now = func() time.Time { return now }
+
+	mockRepo.On("GetUserStats", mock.Anything, mock.Anything, mock.Anything).Return(&models.UserStats{Total: 7}, nil)
+
+	get := func(query string) {
+		req, _ := http.NewRequest("GET", "/api/v1/users/stats?"+query, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	get("role=staff&is_active=true")
+	get("is_active=true&role=staff") // Same query, different parameter order
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 1)
+
+	get("role=admin")
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 2)
+
+	now = now.Add(time.Minute)
+	get("role=staff&is_active=true")
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 3)
+}
+
+// TestGetUserStats_CachePerPrincipal tests that principals with the same subject do not share
+// cached statistics
+func TestGetUserStats_CachePerPrincipal(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	handler := NewUserHandler(mockRepo, WithStatsCacheTTL(time.Minute))
+	router := setupTestRouter(handler)
+
+	mockRepo.On("GetUserStats", mock.Anything, mock.Anything, mock.Anything).Return(&models.UserStats{Total: 7}, nil)
+
+	get := func(principal *auth.Principal) {
+		req, _ := http.NewRequest("GET", "/api/v1/users/stats", nil)
+		req = req.WithContext(auth.NewContext(req.Context(), principal))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	get(&auth.Principal{Subject: "erp-sync", Issuer: "https://issuer.example.com/"})
+	get(&auth.Principal{Subject: "erp-sync", Issuer: "https://other.example.com/"})
+	get(&auth.Principal{Subject: "erp-sync", Scopes: []string{auth.ScopeUsersRead}, Service: true})
+	get(&auth.Principal{Subject: "erp-sync", Scopes: []string{auth.ScopeUsersWrite}, Service: true})
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 4)
+
+	get(&auth.Principal{Subject: "erp-sync", Scopes: []string{auth.ScopeUsersRead}, Service: true})
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 4)
+}
+
+// TestGetUserStats_NoCache tests that every request reaches the repository when caching is disabled
+func TestGetUserStats_NoCache(t *testing.T) {
+	handler, mockRepo := setupTestHandler()
+	router := setupTestRouter(handler)
+
+	mockRepo.On("GetUserStats", mock.Anything, mock.Anything, mock.Anything).Return(&models.UserStats{}, nil)
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/api/v1/users/stats", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 2)
+}
+
+// TestGetUserStats_InvalidParams tests that invalid intervals and filters are rejected
+func TestGetUserStats_InvalidParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		errMsg string
+	}{
+		{"InvalidInterval", "interval=hour", "interval must be one of"},
+		{"InvalidRole", "role=owner", "role[0]"},
+		{"InvalidTZ", "tz=Nowhere/Special", `invalid tz: "Nowhere/Special"`},
+		{"InvalidFilter", "filter=password==x", `unknown field "password"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/stats?"+tt.query, nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Contains(t, response.Detail, tt.errMsg)
+
+			mockRepo.AssertNotCalled(t, "GetUserStats", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+// TestGetUserStats_RepositoryError tests that repository failures are not cached
+func TestGetUserStats_RepositoryError(t *testing.T) {
+	mockRepo := &MockUserRepository{}
+	handler := NewUserHandler(mockRepo, WithStatsCacheTTL(time.Minute))
+	router := setupTestRouter(handler)
+
+	mockRepo.On("GetUserStats", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("database error"))
+
+	for i := 0; i < 2; i++ {
+		req, _ := http.NewRequest("GET", "/api/v1/users/stats", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Contains(t, w.Body.String(), "failed to retrieve user statistics")
+	}
+
+	mockRepo.AssertNumberOfCalls(t, "GetUserStats", 2)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAllUsers_TimeRanges tests that date bounds become half-open ranges in the requested time zone
+func TestGetAllUsers_TimeRanges(t *testing.T) {
+	jakarta, err := time.LoadLocation("Asia/Jakarta")
+	require.NoError(t, err)
+	amsterdam, err := time.LoadLocation("Europe/Amsterdam")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		query        url.Values
+		expectedFrom time.Time
+		expectedTo   time.Time
+	}{
+		{
+			name:         "DefaultsToUTC",
+			query:        url.Values{"created_from": {"2026-01-01"}, "created_to": {"2026-01-31"}},
+			expectedFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			expectedTo:   time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:         "Jakarta",
+			query:        url.Values{"created_from": {"2026-01-01"}, "created_to": {"2026-01-31"}, "tz": {"Asia/Jakarta"}},
+			expectedFrom: time.Date(2026, 1, 1, 0, 0, 0, 0, jakarta),
+			expectedTo:   time.Date(2026, 2, 1, 0, 0, 0, 0, jakarta),
+		},
+		{
+			name:         "RotterdamAcrossDST",
+			query:        url.Values{"created_from": {"2026-03-29"}, "created_to": {"2026-03-29"}, "tz": {"Europe/Amsterdam"}},
+			expectedFrom: time.Date(2026, 3, 29, 0, 0, 0, 0, amsterdam),
+			expectedTo:   time.Date(2026, 3, 30, 0, 0, 0, 0, amsterdam),
+		},
+		{
+			name:         "RFC3339IgnoresTZ",
+			query:        url.Values{"created_from": {"2026-01-01T08:30:00+07:00"}, "created_to": {"2026-01-01T17:00:00Z"}, "tz": {"Europe/Amsterdam"}},
+			expectedFrom: time.Date(2026, 1, 1, 1, 30, 0, 0, time.UTC),
+			expectedTo:   time.Date(2026, 1, 1, 17, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			matchesRange := mock.MatchedBy(func(filters *models.FilterParams) bool {
+				return filters.CreatedFrom != nil && filters.CreatedFrom.Equal(tt.expectedFrom) &&
+					filters.CreatedTo != nil && filters.CreatedTo.Equal(tt.expectedTo)
+			})
+			expectedResponse := &models.GetUsersResponse{Data: []models.User{}, Pagination: models.PaginationMetadata{Page: 1, PageSize: 10, TotalPages: 1}}
+			mockRepo.On("GetAllUsers", mock.Anything, matchesRange, mock.Anything, mock.Anything, []string(nil)).Return(expectedResponse, nil)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+tt.query.Encode(), nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+// TestGetAllUsers_InvalidTimeRanges tests that bad time zones, dates and empty ranges are rejected
+func TestGetAllUsers_InvalidTimeRanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  url.Values
+		errMsg string
+	}{
+		{"UnknownTZ", url.Values{"created_from": {"2026-01-01"}, "tz": {"Mars/Olympus"}}, `invalid tz: "Mars/Olympus"`},
+		{"LocalTZ", url.Values{"created_from": {"2026-01-01"}, "tz": {"Local"}}, `invalid tz: "Local"`},
+		{"InvalidDatetime", url.Values{"updated_to": {"2026-01-01 10:00"}}, "invalid updated_to date format"},
+		{"EmptyRange", url.Values{"created_from": {"2026-02-01"}, "created_to": {"2026-01-31"}}, "created_from must be before created_to"},
+		{"EmptyUpdatedRange", url.Values{"updated_from": {"2026-01-01T10:00:00Z"}, "updated_to": {"2026-01-01T10:00:00Z"}}, "updated_from must be before updated_to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, mockRepo := setupTestHandler()
+			router := setupTestRouter(handler)
+
+			req, _ := http.NewRequest("GET", "/api/v1/users/?"+tt.query.Encode(), nil)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+
+			var response models.Problem
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			require.NoError(t, err)
+			assert.Contains(t, response.Detail, tt.errMsg)
+
+			mockRepo.AssertNotCalled(t, "GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -73,10 +73,10 @@ func TestUpdateUser_InvalidUUID(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "invalid user ID format", response["error"])
+	assert.Equal(t, "invalid user ID format", response.Detail)
 }
 
 // TestUpdateUser_MalformedJSON tests handling of malformed JSON
@@ -93,10 +93,10 @@ func TestUpdateUser_MalformedJSON(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "invalid character")
+	assert.Contains(t, response.Detail, "invalid character")
 }
 
 // TestUpdateUser_NoFieldsProvided tests error when no fields are provided
@@ -118,10 +118,10 @@ func TestUpdateUser_NoFieldsProvided(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "at least one field must be provided for update", response["error"])
+	assert.Equal(t, "at least one field must be provided for update", response.Detail)
 }
 
 // TestUpdateUser_ValidationError tests handling of validation errors
@@ -143,10 +143,10 @@ func TestUpdateUser_ValidationError(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "email") // Should contain validation error about email
+	assert.Contains(t, response.Detail, "email") // Should contain validation error about email
 }
 
 // TestUpdateUser_UserNotFound tests handling when user doesn't exist
@@ -170,10 +170,10 @@ func TestUpdateUser_UserNotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusNotFound, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "user not found", response["error"])
+	assert.Equal(t, "user not found", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -199,10 +199,10 @@ func TestUpdateUser_DuplicateEmail(t *testing.T) {
 
 	assert.Equal(t, http.StatusConflict, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "email already exists", response["error"])
+	assert.Equal(t, "email already exists", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -228,10 +228,10 @@ func TestUpdateUser_DatabaseError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Equal(t, "failed to update user", response["error"])
+	assert.Equal(t, "failed to update user", response.Detail)
 
 	mockRepo.AssertExpectations(t)
 }
@@ -302,10 +302,10 @@ func TestUpdateUser_InvalidRole(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "Role") // Should contain validation error about Role
+	assert.Contains(t, response.Detail, "role") // Should contain validation error about role
 }
 
 // TestUpdateUser_EmptyFullName tests validation of empty full name
@@ -327,10 +327,11 @@ func TestUpdateUser_EmptyFullName(t *testing.T) {
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 
-	var response map[string]string
+	var response models.Problem
 	err := json.Unmarshal(w.Body.Bytes(), &response)
 	require.NoError(t, err)
-	assert.Contains(t, response["error"], "min") // Should contain validation error about minimum length
+	require.Len(t, response.Errors, 1)
+	assert.Equal(t, models.FieldError{Field: "full_name", Rule: "min", Param: "1", Message: "full_name must be at least 1 character in length"}, response.Errors[0])
 }
 
 // TestUpdateUser_NullPhone tests updating phone to null
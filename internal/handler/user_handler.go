@@ -6,7 +6,10 @@ import (
 	"strings"
 	"time"
 
+	"github.com/GoodsChain/user/internal/filter"
 	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/patch"
+	"github.com/GoodsChain/user/internal/receipt"
 	"github.com/GoodsChain/user/internal/repository"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
@@ -15,42 +18,69 @@ import (
 
 // UserHandler handles HTTP requests related to users
 type UserHandler struct {
-	userRepo  repository.UserRepository
-	validator *validator.Validate
+	userRepo      repository.UserRepository
+	validator     *validator.Validate
+	receiptSigner *receipt.Signer
+	statsCache    *statsCache
+
+	idempotencyStore repository.IdempotencyStore
+	idempotencyTTL   time.Duration
+}
+
+// Option configures optional dependencies of a UserHandler
+type Option func(*UserHandler)
+
+// WithReceiptSigner sets the signer used for erasure receipts
+func WithReceiptSigner(signer *receipt.Signer) Option {
+	return func(h *UserHandler) {
+		h.receiptSigner = signer
+	}
 }
 
 // NewUserHandler creates a new instance of UserHandler
-func NewUserHandler(userRepo repository.UserRepository) *UserHandler {
-	return &UserHandler{
+func NewUserHandler(userRepo repository.UserRepository, opts ...Option) *UserHandler {
+	h := &UserHandler{
 		userRepo:  userRepo,
-		validator: validator.New(),
+		validator: requestValidator,
 	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
 }
 
 // CreateUser handles the creation of a new user
 func (h *UserHandler) CreateUser(c *gin.Context) {
 	var req models.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBindError(c, err)
 		return
 	}
 
 	if err := h.validator.Struct(req); err != nil {
-		validationErrors := err.(validator.ValidationErrors)
-		c.JSON(http.StatusBadRequest, gin.H{"error": validationErrors.Error()})
+		writeValidationError(c, err)
 		return
 	}
 
 	user := &models.User{
-		Email:    req.Email,
-		FullName: req.FullName,
-		Phone:    req.Phone,
-		Role:     req.Role,
+		Email:     req.Email,
+		FullName:  req.FullName,
+		Phone:     req.Phone,
+		Role:      req.Role,
+		ManagerID: req.ManagerID,
 	}
 
 	createdUser, err := h.userRepo.CreateUser(c.Request.Context(), user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errMsg := err.Error()
+		switch {
+		case strings.Contains(errMsg, "manager not found"):
+			writeProblem(c, http.StatusBadRequest, models.CodeManagerNotFound, "manager not found")
+		case strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists"):
+			writeProblem(c, http.StatusConflict, models.CodeEmailConflict, "email already exists")
+		default:
+			writeRepositoryError(c, err, "failed to create user")
+		}
 		return
 	}
 
@@ -63,74 +93,104 @@ func (h *UserHandler) GetUserByID(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	var req models.GetUserRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		writeBindError(c, err)
+		return
+	}
+
+	fields, err := parseFieldList(req.Fields, "field")
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
 		return
 	}
 
 	// Call repository to get user
-	user, err := h.userRepo.GetUserByID(c.Request.Context(), userID)
+	user, err := h.userRepo.GetUserByID(c.Request.Context(), userID, singleUserFields(fields))
 	if err != nil {
-		// Handle different error types
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "user not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		writeRepositoryError(c, err, "failed to retrieve user")
+		return
+	}
+
+	writeUser(c, user, fields)
+}
+
+// writeUser responds with a single user, limited to fields if any are given. Merged users
+// are answered with a redirect to the user they were merged into.
+func writeUser(c *gin.Context, user *models.User, fields []string) {
+	// Merged users permanently point at the user they were folded into
+	if user.MergedInto != nil {
+		location := fmt.Sprintf("/api/v1/users/%s", user.MergedInto)
+		c.Header("Location", location)
+		c.JSON(http.StatusMovedPermanently, gin.H{
+			"error":       "user has been merged",
+			"merged_into": user.MergedInto,
+			"location":    location,
+		})
+		return
+	}
+
+	if notModified(c, userETag(user, fields), user.UpdatedAt, true) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if fields != nil {
+		body, err := encodeOrderedJSON(user, fields)
+		if err != nil {
+			writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to retrieve user")
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve user"})
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
 		return
 	}
 
 	c.JSON(http.StatusOK, user)
 }
 
-// UpdateUser handles updating an existing user
+// UpdateUser handles updating an existing user. Plain JSON bodies update the fields they provide;
+// merge patches and JSON patches are applied to the user's current state.
 func (h *UserHandler) UpdateUser(c *gin.Context) {
 	// Extract and validate user ID from URL parameter
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
+		return
+	}
+
+	if contentType := c.ContentType(); contentType == patch.MergePatchContentType || contentType == patch.JSONPatchContentType {
+		h.patchUser(c, userID, contentType)
 		return
 	}
 
 	// Bind JSON request body
 	var req models.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBindError(c, err)
 		return
 	}
 
 	// Check if at least one field is provided for update
-	if req.Email == nil && req.FullName == nil && req.Phone == nil && req.Role == nil && req.IsActive == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one field must be provided for update"})
+	if req.Email == nil && req.FullName == nil && req.Phone == nil && req.Role == nil && req.IsActive == nil && req.ManagerID == nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, "at least one field must be provided for update")
 		return
 	}
 
 	// Validate provided fields
 	if err := h.validator.Struct(req); err != nil {
-		validationErrors := err.(validator.ValidationErrors)
-		c.JSON(http.StatusBadRequest, gin.H{"error": validationErrors.Error()})
+		writeValidationError(c, err)
 		return
 	}
 
 	// Call repository to update user
 	updatedUser, err := h.userRepo.UpdateUser(c.Request.Context(), userID, &req)
 	if err != nil {
-		// Handle different error types
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "user not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-			return
-		}
-		if strings.Contains(errMsg, "no fields to update") {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
-			return
-		}
-		if strings.Contains(errMsg, "duplicate key value") || strings.Contains(errMsg, "already exists") {
-			c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+		writeUpdateError(c, err)
 		return
 	}
 
@@ -143,20 +203,14 @@ func (h *UserHandler) DeleteUser(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := uuid.Parse(userIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user ID format"})
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidUserID, "invalid user ID format")
 		return
 	}
 
 	// Call repository to delete user
 	deletedUser, err := h.userRepo.DeleteUser(c.Request.Context(), userID)
 	if err != nil {
-		// Handle different error types
-		errMsg := err.Error()
-		if strings.Contains(errMsg, "user not found") {
-			c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete user"})
+		writeRepositoryError(c, err, "failed to delete user")
 		return
 	}
 
@@ -168,28 +222,56 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 	// Parse query parameters
 	var req models.GetUsersRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeBindError(c, err)
 		return
 	}
+	splitListParams(&req)
 
 	// Validate query parameters
 	if err := h.validator.Struct(req); err != nil {
-		validationErrors := err.(validator.ValidationErrors)
-		c.JSON(http.StatusBadRequest, gin.H{"error": validationErrors.Error()})
+		writeValidationError(c, err)
 		return
 	}
 
 	// Parse and convert parameters
 	filters, sort, pagination, err := h.parseQueryParams(&req)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
+		return
+	}
+
+	fields, err := parseFieldList(req.Fields, "field")
+	if err != nil {
+		writeProblem(c, http.StatusBadRequest, models.CodeInvalidRequest, err.Error())
 		return
 	}
 
+	// id and updated_at are always needed to derive the entity tag, even if they are not returned
+	selected := fields
+	if fields != nil {
+		selected = withField(withField(fields, "id"), "updated_at")
+	}
+
 	// Call repository to get users
-	response, err := h.userRepo.GetAllUsers(c.Request.Context(), filters, sort, pagination)
+	response, err := h.userRepo.GetAllUsers(c.Request.Context(), filters, sort, pagination, selected)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve users"})
+		writeRepositoryError(c, err, "failed to retrieve users")
+		return
+	}
+
+	// Lists only honour If-None-Match: a user leaving the page does not move the newest updated_at
+	if notModified(c, listETag(c.Request.URL.Query().Encode(), response), lastModified(response.Data), false) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	if fields != nil {
+		data, err := sparseUsers(response.Data, fields)
+		if err != nil {
+			writeProblem(c, http.StatusInternalServerError, models.CodeInternal, "failed to retrieve users")
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"data": data, "pagination": response.Pagination})
 		return
 	}
 
@@ -200,53 +282,109 @@ func (h *UserHandler) GetAllUsers(c *gin.Context) {
 func (h *UserHandler) parseQueryParams(req *models.GetUsersRequest) (*models.FilterParams, *models.SortParams, *models.PaginationParams, error) {
 	// Build FilterParams
 	filters := &models.FilterParams{
-		Role:        req.Role,
 		IsActive:    req.IsActive,
 		Search:      req.Search,
 		EmailDomain: req.EmailDomain,
 	}
 
-	// Parse date strings to time.Time
-	if req.CreatedFrom != nil && *req.CreatedFrom != "" {
-		createdFrom, err := time.Parse("2006-01-02", *req.CreatedFrom)
+	// Plain dates are interpreted in the requested time zone
+	loc, err := requestLocation(req.TZ)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Parse date strings to time.Time; ranges are half-open, [from, to)
+	if filters.CreatedFrom, err = parseTimeBound("created_from", req.CreatedFrom, loc, false); err != nil {
+		return nil, nil, nil, err
+	}
+	if filters.CreatedTo, err = parseTimeBound("created_to", req.CreatedTo, loc, true); err != nil {
+		return nil, nil, nil, err
+	}
+	if filters.UpdatedFrom, err = parseTimeBound("updated_from", req.UpdatedFrom, loc, false); err != nil {
+		return nil, nil, nil, err
+	}
+	if filters.UpdatedTo, err = parseTimeBound("updated_to", req.UpdatedTo, loc, true); err != nil {
+		return nil, nil, nil, err
+	}
+	if filters.CreatedFrom != nil && filters.CreatedTo != nil && !filters.CreatedFrom.Before(*filters.CreatedTo) {
+		return nil, nil, nil, fmt.Errorf("invalid created range: created_from must be before created_to")
+	}
+	if filters.UpdatedFrom != nil && filters.UpdatedTo != nil && !filters.UpdatedFrom.Before(*filters.UpdatedTo) {
+		return nil, nil, nil, fmt.Errorf("invalid updated range: updated_from must be before updated_to")
+	}
+
+	// A single role or manager keeps the plain equality filters; lists, nulls and negations become conditions
+	roles, managerIDs := req.Role, req.ManagerID
+	if len(roles) == 1 {
+		filters.Role = &roles[0]
+		roles = nil
+	}
+
+	if len(managerIDs) == 1 && managerIDs[0] != nullFilterValue {
+		managerID, err := uuid.Parse(managerIDs[0])
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("invalid created_from date format, use YYYY-MM-DD: %w", err)
+			return nil, nil, nil, fmt.Errorf("invalid manager_id format: %w", err)
 		}
-		filters.CreatedFrom = &createdFrom
+		filters.ManagerID = &managerID
+		managerIDs = nil
+	}
+
+	listFilters := []struct {
+		field  string
+		values []string
+		negate bool
+	}{
+		{"role", roles, false},
+		{"role", req.RoleNot, true},
+		{"id", req.ID, false},
+		{"id", req.IDNot, true},
+		{"phone", req.Phone, false},
+		{"phone", req.PhoneNot, true},
+		{"manager_id", managerIDs, false},
+		{"manager_id", req.ManagerIDNot, true},
+	}
+	for _, list := range listFilters {
+		if len(list.values) == 0 {
+			continue
+		}
+		condition, err := parseFilterCondition(list.field, list.values, list.negate)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		filters.Conditions = append(filters.Conditions, condition)
 	}
 
-	if req.CreatedTo != nil && *req.CreatedTo != "" {
-		createdTo, err := time.Parse("2006-01-02", *req.CreatedTo)
+	for _, raw := range req.Or {
+		group, err := parseOrGroup(raw)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("invalid created_to date format, use YYYY-MM-DD: %w", err)
+			return nil, nil, nil, err
 		}
-		// Set to end of day
-		createdTo = createdTo.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-		filters.CreatedTo = &createdTo
+		filters.AnyOf = append(filters.AnyOf, group)
 	}
 
-	if req.UpdatedFrom != nil && *req.UpdatedFrom != "" {
-		updatedFrom, err := time.Parse("2006-01-02", *req.UpdatedFrom)
+	if req.Filter != nil && *req.Filter != "" {
+		expression, err := filter.Parse(*req.Filter)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("invalid updated_from date format, use YYYY-MM-DD: %w", err)
+			return nil, nil, nil, err
 		}
-		filters.UpdatedFrom = &updatedFrom
+		if err := filter.Check(expression, models.UserFilterSchema); err != nil {
+			return nil, nil, nil, err
+		}
+		filters.Expression = filter.InLocation(expression, models.UserFilterSchema, loc)
 	}
 
-	if req.UpdatedTo != nil && *req.UpdatedTo != "" {
-		updatedTo, err := time.Parse("2006-01-02", *req.UpdatedTo)
+	if req.UnderManager != nil && *req.UnderManager != "" {
+		underManager, err := uuid.Parse(*req.UnderManager)
 		if err != nil {
-			return nil, nil, nil, fmt.Errorf("invalid updated_to date format, use YYYY-MM-DD: %w", err)
+			return nil, nil, nil, fmt.Errorf("invalid under_manager format: %w", err)
 		}
-		// Set to end of day
-		updatedTo = updatedTo.Add(23*time.Hour + 59*time.Minute + 59*time.Second)
-		filters.UpdatedTo = &updatedTo
+		filters.UnderManager = &underManager
 	}
 
 	// Build SortParams with defaults
 	sortField := "created_at"
 	sortOrder := "asc"
-	
+
 	if req.SortBy != nil {
 		sortField = *req.SortBy
 	}
@@ -259,6 +397,14 @@ func (h *UserHandler) parseQueryParams(req *models.GetUsersRequest) (*models.Fil
 		Order: sortOrder,
 	}
 
+	if req.Sort != nil && *req.Sort != "" {
+		keys, err := parseSort(*req.Sort)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sort = &models.SortParams{Fields: keys}
+	}
+
 	// Build PaginationParams with defaults
 	page := 1
 	pageSize := 10
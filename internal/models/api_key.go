@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a long-lived credential of a service principal, such as a batch job or a partner
+// integration. Only a hash of the secret is stored; the prefix identifies the key in listings
+// and logs.
+type APIKey struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	Name       string     `json:"name" db:"name"`
+	Service    string     `json:"service" db:"service"` // The service principal the key authenticates
+	Prefix     string     `json:"prefix" db:"prefix"`   // The leading, non-secret part of the key
+	KeyHash    []byte     `json:"-" db:"key_hash"`
+	Scopes     []string   `json:"scopes" db:"-"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at" db:"expires_at"`     // Nil for keys that do not expire
+	LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"` // Updated at most once a minute
+	RevokedAt  *time.Time `json:"revoked_at" db:"revoked_at"`
+}
+
+// CreateAPIKeyRequest represents the request body for creating an API key
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Service   string     `json:"service" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,max=20,dive,min=1,max=64,excludesall= "`
+	ExpiresAt *time.Time `json:"expires_at"` // Must be in the future
+}
+
+// CreateAPIKeyResponse represents a created API key, including the secret key, which is not
+// stored and cannot be retrieved again
+type CreateAPIKeyResponse struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// ListAPIKeysRequest represents the query parameters for listing API keys
+type ListAPIKeysRequest struct {
+	Service        *string `form:"service"`
+	IncludeRevoked bool    `form:"include_revoked"` // Revoked keys are left out by default
+}
+
+// ListAPIKeysResponse represents the response for listing API keys
+type ListAPIKeysResponse struct {
+	APIKeys []APIKey `json:"api_keys"`
+}
@@ -0,0 +1,49 @@
+package models
+
+// ProblemContentType is the media type of error responses
+const ProblemContentType = "application/problem+json"
+
+// Error codes identify the kind of problem in a response. Unlike the detail message they are
+// stable and meant to be matched by clients.
+const (
+	CodeInvalidRequest           = "invalid_request"             // Malformed body, query or path parameter
+	CodeUnauthenticated          = "unauthenticated"             // The request has no valid credentials
+	CodeForbidden                = "forbidden"                   // The caller may not make the request
+	CodeValidationFailed         = "validation_failed"           // One or more fields break a validation rule, see errors
+	CodeInvalidUserID            = "invalid_user_id"             // The user ID in the path is not a UUID
+	CodeUserNotFound             = "user_not_found"              // The user in the path does not exist
+	CodeManagerNotFound          = "manager_not_found"           // The assigned manager does not exist
+	CodeManagerCycle             = "manager_cycle"               // The manager assignment would create a cycle
+	CodeEmailConflict            = "email_conflict"              // Another user already has the email
+	CodeConcurrentModification   = "concurrent_modification"     // The user changed while the request was processed
+	CodePatchTestFailed          = "patch_test_failed"           // A JSON Patch test operation did not match
+	CodeInvalidMerge             = "invalid_merge"               // The users cannot be merged
+	CodeMergeTargetNotFound      = "merge_target_not_found"      // The user to merge into does not exist
+	CodeUserAlreadyErased        = "user_already_erased"         // The user's personal data was already erased
+	CodeUserMerged               = "user_merged"                 // The user was merged into another and takes no changes
+	CodeAPIKeyNotFound           = "api_key_not_found"           // The API key in the path does not exist
+	CodeIdempotencyKeyReused     = "idempotency_key_reused"      // The Idempotency-Key was used for a different request
+	CodeIdempotencyKeyInProgress = "idempotency_key_in_progress" // The first request with the Idempotency-Key is still running
+	CodeInternal                 = "internal_error"              // The server failed to handle the request
+)
+
+// Problem is an RFC 7807 problem details document, extended with a stable error code,
+// the request ID and, for validation problems, the fields that failed
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one field that failed validation
+type FieldError struct {
+	Field   string `json:"field"`           // JSON or query parameter name, e.g. "email" or "ids[3]"
+	Rule    string `json:"rule"`            // Failed rule, e.g. "required", "oneof" or "type"
+	Param   string `json:"param,omitempty"` // Rule parameter, e.g. "admin staff supplier" for oneof
+	Message string `json:"message"`
+}
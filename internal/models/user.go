@@ -1,38 +1,63 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
+	"github.com/GoodsChain/user/internal/filter"
 	"github.com/google/uuid"
 )
 
+// Roles of users, which decide what they may do through the API
+const (
+	RoleAdmin    = "admin"
+	RoleStaff    = "staff"
+	RoleSupplier = "supplier"
+)
+
 // User represents the user model in the database
 type User struct {
-	ID        uuid.UUID  `json:"id" db:"id"`
-	Email     string     `json:"email" db:"email" validate:"required,email"`
-	FullName  string     `json:"full_name" db:"full_name" validate:"required"`
-	Phone     *string    `json:"phone" db:"phone"` // Use pointer for nullable fields
-	Role      string     `json:"role" db:"role" validate:"required,oneof=admin staff supplier"`
-	IsActive  bool       `json:"is_active" db:"is_active"`
-	CreatedAt time.Time  `json:"created_at" db:"created_at"`
-	UpdatedAt time.Time  `json:"updated_at" db:"updated_at"`
+	ID         uuid.UUID  `json:"id" db:"id"`
+	Email      string     `json:"email" db:"email" validate:"required,email"`
+	FullName   string     `json:"full_name" db:"full_name" validate:"required"`
+	Phone      *string    `json:"phone" db:"phone"` // Use pointer for nullable fields
+	Role       string     `json:"role" db:"role" validate:"required,oneof=admin staff supplier"`
+	IsActive   bool       `json:"is_active" db:"is_active"`
+	ManagerID  *uuid.UUID `json:"manager_id" db:"manager_id"`             // Nullable self-reference to the user's manager
+	MergedInto *uuid.UUID `json:"merged_into,omitempty" db:"merged_into"` // Set once the user has been merged into another
+	ErasedAt   *time.Time `json:"erased_at,omitempty" db:"erased_at"`     // Set once the user's personal data has been erased
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
 }
 
 // CreateUserRequest represents the request body for creating a new user
 type CreateUserRequest struct {
-	Email    string  `json:"email" validate:"required,email"`
-	FullName string  `json:"full_name" validate:"required"`
-	Phone    *string `json:"phone"`
-	Role     string  `json:"role" validate:"required,oneof=admin staff supplier"`
+	Email     string     `json:"email" validate:"required,email"`
+	FullName  string     `json:"full_name" validate:"required"`
+	Phone     *string    `json:"phone"`
+	Role      string     `json:"role" validate:"required,oneof=admin staff supplier"`
+	ManagerID *uuid.UUID `json:"manager_id"`
 }
 
 // UpdateUserRequest represents the request body for updating an existing user
 type UpdateUserRequest struct {
-	Email    *string `json:"email,omitempty" validate:"omitempty,email"`
-	FullName *string `json:"full_name,omitempty" validate:"omitempty,min=1"`
-	Phone    *string `json:"phone,omitempty"`
-	Role     *string `json:"role,omitempty" validate:"omitempty,oneof=admin staff supplier"`
-	IsActive *bool   `json:"is_active,omitempty"`
+	Email     *string    `json:"email,omitempty" validate:"omitempty,email"`
+	FullName  *string    `json:"full_name,omitempty" validate:"omitempty,min=1"`
+	Phone     *string    `json:"phone,omitempty"`
+	Role      *string    `json:"role,omitempty" validate:"omitempty,oneof=admin staff supplier"`
+	IsActive  *bool      `json:"is_active,omitempty"`
+	ManagerID *uuid.UUID `json:"manager_id,omitempty"`
+}
+
+// ReplaceUserRequest represents every field a client can change, used as the body of a full
+// replacement and as the document merge patches and JSON patches are applied to
+type ReplaceUserRequest struct {
+	Email     string     `json:"email" validate:"required,email"`
+	FullName  string     `json:"full_name" validate:"required,min=1"`
+	Phone     *string    `json:"phone"`
+	Role      string     `json:"role" validate:"required,oneof=admin staff supplier"`
+	IsActive  *bool      `json:"is_active" validate:"required"`
+	ManagerID *uuid.UUID `json:"manager_id"`
 }
 
 // FilterParams represents the filtering parameters for user queries
@@ -41,16 +66,54 @@ type FilterParams struct {
 	IsActive     *bool      `json:"is_active,omitempty"`
 	Search       *string    `json:"search,omitempty"`
 	EmailDomain  *string    `json:"email_domain,omitempty"`
-	CreatedFrom  *time.Time `json:"created_from,omitempty"`
-	CreatedTo    *time.Time `json:"created_to,omitempty"`
-	UpdatedFrom  *time.Time `json:"updated_from,omitempty"`
-	UpdatedTo    *time.Time `json:"updated_to,omitempty"`
+	CreatedFrom  *time.Time `json:"created_from,omitempty"`  // Inclusive
+	CreatedTo    *time.Time `json:"created_to,omitempty"`    // Exclusive
+	UpdatedFrom  *time.Time `json:"updated_from,omitempty"`  // Inclusive
+	UpdatedTo    *time.Time `json:"updated_to,omitempty"`    // Exclusive
+	ManagerID    *uuid.UUID `json:"manager_id,omitempty"`    // Direct reports of this manager
+	UnderManager *uuid.UUID `json:"under_manager,omitempty"` // Everyone in this manager's subtree
+
+	Conditions []FilterCondition   `json:"conditions,omitempty"` // All must match
+	AnyOf      [][]FilterCondition `json:"any_of,omitempty"`     // At least one condition of every group must match
+	Expression filter.Node         `json:"expression,omitempty"` // Checked against UserFilterSchema
+}
+
+// UserFilterSchema describes the user fields available to filter expressions
+var UserFilterSchema = filter.Schema{
+	"id":          {Type: filter.UUID},
+	"email":       {Type: filter.String},
+	"full_name":   {Type: filter.String},
+	"phone":       {Type: filter.String, Nullable: true},
+	"role":        {Type: filter.String},
+	"is_active":   {Type: filter.Bool},
+	"manager_id":  {Type: filter.UUID, Nullable: true},
+	"merged_into": {Type: filter.UUID, Nullable: true},
+	"erased_at":   {Type: filter.Time, Nullable: true},
+	"created_at":  {Type: filter.Time},
+	"updated_at":  {Type: filter.Time},
+}
+
+// FilterCondition matches a column against a list of values, or against NULL
+type FilterCondition struct {
+	Field  string   `json:"field"`
+	Values []string `json:"values,omitempty"`  // Matches if the column equals any of these
+	IsNull bool     `json:"is_null,omitempty"` // Matches NULL instead of Values
+	Negate bool     `json:"negate,omitempty"`  // Inverts the match
 }
 
-// SortParams represents the sorting parameters for user queries
+// SortParams represents the sorting parameters for user queries.
+// Fields takes precedence; Field and Order describe a single-column sort.
 type SortParams struct {
-	Field string `json:"field" validate:"required,oneof=id email full_name role is_active created_at updated_at"`
-	Order string `json:"order" validate:"required,oneof=asc desc"`
+	Field  string      `json:"field,omitempty" validate:"omitempty,oneof=id email full_name role is_active created_at updated_at"`
+	Order  string      `json:"order,omitempty" validate:"omitempty,oneof=asc desc"`
+	Fields []SortField `json:"fields,omitempty"`
+}
+
+// SortField is one key of a multi-column sort
+type SortField struct {
+	Field string `json:"field"`
+	Order string `json:"order"`           // asc or desc
+	Nulls string `json:"nulls,omitempty"` // first or last; only for nullable fields
 }
 
 // PaginationParams represents the pagination parameters for user queries
@@ -78,21 +141,208 @@ type GetUsersResponse struct {
 
 // GetUsersRequest represents the request parameters for getting users
 type GetUsersRequest struct {
-	// Filtering
-	Role        *string `form:"role" validate:"omitempty,oneof=admin staff supplier"`
-	IsActive    *bool   `form:"is_active"`
-	Search      *string `form:"search"`
-	EmailDomain *string `form:"email_domain"`
-	CreatedFrom *string `form:"created_from"` // Will be parsed to time.Time
-	CreatedTo   *string `form:"created_to"`   // Will be parsed to time.Time
-	UpdatedFrom *string `form:"updated_from"` // Will be parsed to time.Time
-	UpdatedTo   *string `form:"updated_to"`   // Will be parsed to time.Time
-	
+	// Filtering; list filters accept repeated or comma-separated values, and a "!" suffix negates them
+	Role         []string `form:"role" validate:"omitempty,dive,oneof=admin staff supplier"`
+	RoleNot      []string `form:"role!" validate:"omitempty,dive,oneof=admin staff supplier"`
+	ID           []string `form:"id"`     // Will be parsed to uuid.UUID
+	IDNot        []string `form:"id!"`    // Will be parsed to uuid.UUID
+	Phone        []string `form:"phone"`  // "null" matches users without a phone
+	PhoneNot     []string `form:"phone!"` // "null" matches users with a phone
+	IsActive     *bool    `form:"is_active"`
+	Search       *string  `form:"search"`
+	EmailDomain  *string  `form:"email_domain"`
+	CreatedFrom  *string  `form:"created_from"`  // YYYY-MM-DD or RFC 3339, inclusive
+	CreatedTo    *string  `form:"created_to"`    // YYYY-MM-DD (whole day included) or RFC 3339, exclusive
+	UpdatedFrom  *string  `form:"updated_from"`  // YYYY-MM-DD or RFC 3339, inclusive
+	UpdatedTo    *string  `form:"updated_to"`    // YYYY-MM-DD (whole day included) or RFC 3339, exclusive
+	TZ           *string  `form:"tz"`            // IANA time zone for plain dates, e.g. Asia/Jakarta; defaults to UTC
+	ManagerID    []string `form:"manager_id"`    // Will be parsed to uuid.UUID; "null" matches users without a manager
+	ManagerIDNot []string `form:"manager_id!"`   // Will be parsed to uuid.UUID
+	UnderManager *string  `form:"under_manager"` // Will be parsed to uuid.UUID
+	Or           []string `form:"or"`            // OR group of "field=values" terms separated by "|"
+	Filter       *string  `form:"filter"`        // RSQL/FIQL expression, e.g. role==staff;created_at=ge=2026-01-01
+
 	// Sorting
 	SortBy    *string `form:"sort_by" validate:"omitempty,oneof=id email full_name role is_active created_at updated_at"`
 	SortOrder *string `form:"sort_order" validate:"omitempty,oneof=asc desc"`
-	
+	Sort      *string `form:"sort"` // e.g. role,-full_name,phone:nulls_last; takes precedence over sort_by
+
 	// Pagination
 	Page     *int `form:"page" validate:"omitempty,min=1"`
 	PageSize *int `form:"page_size" validate:"omitempty,min=1,max=100"`
+
+	// Sparse fieldset
+	Fields *string `form:"fields"` // Comma-separated list of user fields to return
+}
+
+// GetUserRequest represents the query parameters for retrieving a single user
+type GetUserRequest struct {
+	Fields *string `form:"fields"` // Comma-separated list of user fields to return
+}
+
+// BatchGetUsersRequest represents the request body for fetching several users by ID
+type BatchGetUsersRequest struct {
+	IDs []uuid.UUID `json:"ids" validate:"required,min=1,max=500"`
+}
+
+// BatchGetUsersResponse represents the response for fetching several users by ID
+type BatchGetUsersResponse struct {
+	Data    []User      `json:"data"`    // Found users, in request order
+	Missing []uuid.UUID `json:"missing"` // Requested IDs with no user
+}
+
+// UserListResponse represents an unpaginated list of users
+type UserListResponse struct {
+	Data []User `json:"data"`
+}
+
+// UserHierarchyEntry represents a user within a management hierarchy together with
+// their distance from the user the hierarchy was requested for
+type UserHierarchyEntry struct {
+	User
+	Depth int `json:"depth" db:"depth"`
+}
+
+// GetUserHierarchyResponse represents the response for hierarchy queries
+type GetUserHierarchyResponse struct {
+	Data []UserHierarchyEntry `json:"data"`
+}
+
+// GetUserHierarchyRequest represents the query parameters for subtree queries
+type GetUserHierarchyRequest struct {
+	MaxDepth *int `form:"max_depth" validate:"omitempty,min=1,max=20"`
+}
+
+// MergeUsersRequest represents the request body for merging a duplicate user into a surviving one
+type MergeUsersRequest struct {
+	TargetID uuid.UUID `json:"target_id" validate:"required"`
+	// Keep selects, per field, whether the surviving value comes from the "source" or the "target".
+	// Fields that are not listed keep the target's value.
+	Keep map[string]string `json:"keep" validate:"omitempty,dive,keys,oneof=email full_name phone role,endkeys,oneof=source target"`
+}
+
+// MergeUsersResponse represents the result of a merge
+type MergeUsersResponse struct {
+	Target User `json:"target"`
+	Source User `json:"source"`
+}
+
+// ErasureResult describes what an erasure removed
+type ErasureResult struct {
+	UserID                 uuid.UUID   `json:"user_id"`
+	ErasedUserIDs          []uuid.UUID `json:"erased_user_ids"` // The user plus any accounts merged into them
+	ErasedFields           []string    `json:"erased_fields"`
+	HistoryEntriesScrubbed int64       `json:"history_entries_scrubbed"`
+	ErasedAt               time.Time   `json:"erased_at"`
+}
+
+// ErasureReceipt is the signed confirmation of an erasure handed to the data subject
+type ErasureReceipt struct {
+	ReceiptID uuid.UUID `json:"receipt_id"`
+	ErasureResult
+	Algorithm string `json:"algorithm"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// UserDataExport represents everything the service stores about a user
+type UserDataExport struct {
+	UserID      uuid.UUID                    `json:"user_id"`
+	GeneratedAt time.Time                    `json:"generated_at"`
+	Tables      map[string][]json.RawMessage `json:"tables"` // Rows keyed by table name
+}
+
+// ExportUserDataRequest represents the query parameters for a subject access export
+type ExportUserDataRequest struct {
+	Format *string `form:"format" validate:"omitempty,oneof=json zip"`
+}
+
+// ExportUsersRequest represents the query parameters for streaming a filtered user list
+type ExportUsersRequest struct {
+	GetUsersRequest
+	Format  *string `form:"format" validate:"omitempty,oneof=csv ndjson"`
+	Columns *string `form:"columns"` // Comma-separated list of columns to include
+}
+
+// ImportUsersRequest represents the query parameters for a CSV import
+type ImportUsersRequest struct {
+	DryRun  *bool   `form:"dry_run"`
+	Mode    *string `form:"mode" validate:"omitempty,oneof=create upsert"`
+	Mapping *string `form:"mapping"` // JSON object mapping CSV headers to user fields
+}
+
+// ImportRow is a parsed CSV row ready to be written
+type ImportRow struct {
+	Row  int // 1-based data row number, excluding the header
+	User CreateUserRequest
+}
+
+// ImportRowResult reports what happened to one CSV row
+type ImportRowResult struct {
+	Row    int        `json:"row"`
+	Email  string     `json:"email,omitempty"`
+	Status string     `json:"status"` // created, updated, would_create, would_update, invalid, failed or rolled_back
+	UserID *uuid.UUID `json:"user_id,omitempty"`
+	Errors []string   `json:"errors,omitempty"`
+}
+
+// ImportResult represents the outcome of writing import rows
+type ImportResult struct {
+	Rows      []ImportRowResult `json:"rows"`
+	Committed bool              `json:"committed"`
+}
+
+// ImportUsersResponse represents the response for a CSV import
+type ImportUsersResponse struct {
+	DryRun    bool              `json:"dry_run"`
+	Mode      string            `json:"mode"`
+	Committed bool              `json:"committed"`
+	Summary   map[string]int    `json:"summary"` // Row counts by status
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// GetUserStatsRequest represents the query parameters for user statistics
+type GetUserStatsRequest struct {
+	GetUsersRequest
+	Interval *string `form:"interval" validate:"omitempty,oneof=day week month"` // Time series bucket size, defaults to day
+}
+
+// StatsParams represents how user statistics are bucketed over time
+type StatsParams struct {
+	Interval string // day, week or month
+	TimeZone string // IANA time zone in which buckets start
+}
+
+// DomainCount is the number of users with a given email domain
+type DomainCount struct {
+	Domain string `json:"domain" db:"domain"`
+	Count  int    `json:"count" db:"count"`
+}
+
+// StatsBucket is the number of users counted in one time series bucket
+type StatsBucket struct {
+	Start time.Time `json:"start" db:"bucket"`
+	Count int       `json:"count" db:"count"`
+}
+
+// UserStats represents aggregated counts over the users matching a filter
+type UserStats struct {
+	Total         int            `json:"total"`
+	ByRole        map[string]int `json:"by_role"`
+	ByStatus      map[string]int `json:"by_status"`       // Keyed by active and inactive
+	ByEmailDomain []DomainCount  `json:"by_email_domain"` // Most common first
+	Interval      string         `json:"interval"`
+	TimeZone      string         `json:"time_zone"`
+	Signups       []StatsBucket  `json:"signups"`       // Buckets without signups are omitted
+	Deactivations []StatsBucket  `json:"deactivations"` // Buckets without deactivations are omitted
+	GeneratedAt   time.Time      `json:"generated_at"`
+}
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it has finished, its response
+type IdempotencyRecord struct {
+	Key         string    `db:"key"`
+	Fingerprint string    `db:"fingerprint"` // Hash of the method, URL, content type and body
+	StatusCode  *int      `db:"status_code"` // Nil while the request is in progress
+	ContentType *string   `db:"content_type"`
+	Body        []byte    `db:"body"`
+	ExpiresAt   time.Time `db:"expires_at"`
 }
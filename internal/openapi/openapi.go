@@ -0,0 +1,41 @@
+// Package openapi describes the HTTP API as an OpenAPI 3.1 document.
+//
+// The document is built from the request and response models, so field names, types and the
+// constraints of their validate tags cannot drift from what the handlers accept. The generated
+// document is committed as openapi.json and served as is; a test fails when it is out of date.
+package openapi
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Spec is the committed OpenAPI document
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed docs.html
+var docsPage []byte
+
+// Generate builds the OpenAPI document and encodes it the way openapi.json is stored
+func Generate() ([]byte, error) {
+	spec, err := json.MarshalIndent(Build(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(spec, '\n'), nil
+}
+
+// ServeSpec handles requests for the OpenAPI document
+func ServeSpec(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", Spec)
+}
+
+// ServeDocs handles requests for the Swagger UI page rendering the OpenAPI document
+func ServeDocs(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", docsPage)
+}
@@ -0,0 +1,134 @@
+package openapi
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "rewrite openapi.json from the models")
+
+// TestSpec_UpToDate tests that the committed document matches the one built from the models.
+// Run `make openapi` after changing a model or route to regenerate it.
+func TestSpec_UpToDate(t *testing.T) {
+	generated, err := Generate()
+	require.NoError(t, err)
+
+	if *update {
+		require.NoError(t, os.WriteFile("openapi.json", generated, 0o644))
+		return
+	}
+	assert.Equal(t, string(generated), string(Spec), "openapi.json is out of date; run `make openapi`")
+}
+
+// TestSchema_Constraints tests that validate tags become JSON Schema keywords
+func TestSchema_Constraints(t *testing.T) {
+	g := newSchemaGenerator()
+	g.of(models.CreateUserRequest{})
+	g.of(models.ReplaceUserRequest{})
+	g.of(models.BatchGetUsersRequest{})
+	g.of(models.MergeUsersRequest{})
+
+	create := g.components["CreateUserRequest"]
+	assert.Equal(t, []string{"email", "full_name", "role"}, create.Required)
+	assert.Equal(t, "email", create.Properties["email"].Format)
+	assert.Equal(t, []string{"admin", "staff", "supplier"}, create.Properties["role"].Enum)
+	assert.Equal(t, []string{"string", "null"}, create.Properties["phone"].Type)
+	assert.Equal(t, []string{"string", "null"}, create.Properties["manager_id"].Type)
+
+	replace := g.components["ReplaceUserRequest"]
+	assert.Contains(t, replace.Required, "is_active")
+	assert.Equal(t, "boolean", replace.Properties["is_active"].Type, "a required pointer does not accept null")
+	assert.Equal(t, 1, *replace.Properties["full_name"].MinLength)
+
+	ids := g.components["BatchGetUsersRequest"].Properties["ids"]
+	assert.Equal(t, 1, *ids.MinItems)
+	assert.Equal(t, 500, *ids.MaxItems)
+	assert.Equal(t, "uuid", ids.Items.Format)
+
+	keep := g.components["MergeUsersRequest"].Properties["keep"]
+	assert.Equal(t, []string{"email", "full_name", "phone", "role"}, keep.PropertyNames.Enum)
+	assert.Equal(t, []string{"source", "target"}, keep.AdditionalProperties.Enum)
+	assert.NotContains(t, g.components["MergeUsersRequest"].Required, "keep")
+}
+
+// TestSchema_Embedded tests that embedded structs are combined with allOf
+func TestSchema_Embedded(t *testing.T) {
+	g := newSchemaGenerator()
+	g.of(models.UserHierarchyEntry{})
+
+	entry := g.components["UserHierarchyEntry"]
+	require.Len(t, entry.AllOf, 2)
+	assert.Equal(t, "#/components/schemas/User", entry.AllOf[0].Ref)
+	assert.Contains(t, entry.AllOf[1].Properties, "depth")
+	assert.Contains(t, g.components, "User")
+}
+
+// TestQueryParameters tests that query parameters include those of embedded request structs
+func TestQueryParameters(t *testing.T) {
+	parameters := newSchemaGenerator().queryParameters(models.ExportUsersRequest{})
+
+	byName := map[string]*Parameter{}
+	for _, parameter := range parameters {
+		byName[parameter.Name] = parameter
+	}
+	require.Contains(t, byName, "role")
+	assert.Equal(t, []string{"admin", "staff", "supplier"}, byName["role"].Schema.Items.Enum)
+	assert.Equal(t, 100, *byName["page_size"].Schema.Maximum)
+	assert.Equal(t, []string{"csv", "ndjson"}, byName["format"].Schema.Enum)
+	assert.Equal(t, "integer", byName["page"].Schema.Type, "missing query parameters are never null")
+}
+
+// TestSpec_ValidJSON tests that the served document is valid JSON and references only known schemas
+func TestSpec_ValidJSON(t *testing.T) {
+	var document Document
+	require.NoError(t, json.Unmarshal(Spec, &document))
+	assert.Equal(t, Version, document.OpenAPI)
+
+	var refs []string
+	var collect func(*Schema)
+	collect = func(s *Schema) {
+		if s == nil {
+			return
+		}
+		if s.Ref != "" {
+			refs = append(refs, s.Ref)
+		}
+		collect(s.Items)
+		collect(s.AdditionalProperties)
+		for _, property := range s.Properties {
+			collect(property)
+		}
+		for _, sub := range append(s.AllOf, s.AnyOf...) {
+			collect(sub)
+		}
+	}
+	for _, item := range document.Paths {
+		for _, operation := range item {
+			if operation.RequestBody != nil {
+				for _, media := range operation.RequestBody.Content {
+					collect(media.Schema)
+				}
+			}
+			for _, response := range operation.Responses {
+				for _, media := range response.Content {
+					collect(media.Schema)
+				}
+			}
+		}
+	}
+	for _, schema := range document.Components.Schemas {
+		collect(schema)
+	}
+
+	require.NotEmpty(t, refs)
+	for _, ref := range refs {
+		name := ref[len("#/components/schemas/"):]
+		assert.Contains(t, document.Components.Schemas, name)
+	}
+}
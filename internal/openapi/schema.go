@@ -0,0 +1,271 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	uuidType       = reflect.TypeOf(uuid.UUID{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemaGenerator derives JSON Schemas from Go types. Named structs become components and are
+// referenced by name; constraints come from the same validate tags the handlers enforce.
+type schemaGenerator struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		components: make(map[string]*Schema),
+		types:      make(map[string]reflect.Type),
+	}
+}
+
+// of returns the schema of the type of v
+func (g *schemaGenerator) of(v interface{}) *Schema {
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) schema(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case uuidType:
+		return &Schema{Type: "string", Format: "uuid"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(g.schema(t.Elem()))
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t)
+		}
+		return g.component(t)
+	case reflect.Interface:
+		return &Schema{}
+	}
+	panic(fmt.Sprintf("openapi: unsupported type %s", t))
+}
+
+// component registers a named struct under its Go name and returns a reference to it
+func (g *schemaGenerator) component(t reflect.Type) *Schema {
+	ref := &Schema{Ref: "#/components/schemas/" + t.Name()}
+	if existing, ok := g.types[t.Name()]; ok {
+		if existing != t {
+			panic(fmt.Sprintf("openapi: %s and %s share the schema name %s", existing, t, t.Name()))
+		}
+		return ref
+	}
+
+	// Register the name first so recursive types refer to themselves
+	g.types[t.Name()] = t
+	g.components[t.Name()] = g.object(t)
+	return ref
+}
+
+// object describes the JSON encoding of a struct. Embedded structs are flattened by encoding/json,
+// so they are combined with the struct's own properties using allOf.
+func (g *schemaGenerator) object(t reflect.Type) *Schema {
+	own := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	var embedded []*Schema
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			embedded = append(embedded, g.schema(field.Type))
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema, required := g.field(field)
+		if !required && field.Tag.Get("validate") == "" && !strings.Contains(options, "omitempty") {
+			// Without a validate tag, fields that are always encoded are always present
+			required = field.Type.Kind() != reflect.Pointer && field.Type != rawMessageType
+		}
+		own.Properties[name] = schema
+		if required {
+			own.Required = append(own.Required, name)
+		}
+	}
+
+	if len(embedded) == 0 {
+		return own
+	}
+	return &Schema{AllOf: append(embedded, own)}
+}
+
+// field returns the schema of a struct field constrained by its validate tag, and whether the
+// tag requires the field
+func (g *schemaGenerator) field(field reflect.StructField) (*Schema, bool) {
+	rules := strings.Split(field.Tag.Get("validate"), ",")
+	required := false
+	for _, rule := range rules {
+		required = required || rule == "required"
+	}
+
+	t := field.Type
+	if required && t.Kind() == reflect.Pointer {
+		// A required pointer rejects null
+		t = t.Elem()
+	}
+	schema := g.schema(t)
+	applyRules(schema, rules)
+	return schema, required
+}
+
+// applyRules translates validate rules into schema keywords. Rules after dive apply to the items of
+// a slice or the values of a map, and rules between keys and endkeys to the keys of a map.
+func applyRules(schema *Schema, rules []string) {
+	target, dived := schema, schema
+	for _, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "dive":
+			if schema.Items != nil {
+				dived = schema.Items
+			} else if schema.AdditionalProperties != nil {
+				dived = schema.AdditionalProperties
+			}
+			target = dived
+		case "keys":
+			schema.PropertyNames = &Schema{Type: "string"}
+			target = schema.PropertyNames
+		case "endkeys":
+			target = dived
+		case "email":
+			target.Format = "email"
+		case "uuid":
+			target.Format = "uuid"
+		case "oneof":
+			target.Enum = strings.Fields(param)
+		case "min", "max":
+			limit, err := strconv.Atoi(param)
+			if err != nil {
+				panic(fmt.Sprintf("openapi: invalid %s rule", rule))
+			}
+			setLimit(target, name == "min", limit)
+		}
+	}
+}
+
+// setLimit applies a min or max rule, which bounds the length of strings, the size of arrays and
+// the value of numbers
+func setLimit(schema *Schema, lower bool, limit int) {
+	var bound **int
+	switch baseType(schema) {
+	case "string":
+		bound = pick(lower, &schema.MinLength, &schema.MaxLength)
+	case "array":
+		bound = pick(lower, &schema.MinItems, &schema.MaxItems)
+	case "integer", "number":
+		bound = pick(lower, &schema.Minimum, &schema.Maximum)
+	default:
+		return
+	}
+	*bound = &limit
+}
+
+func pick(lower bool, min, max **int) **int {
+	if lower {
+		return min
+	}
+	return max
+}
+
+// baseType returns the type of a schema, ignoring null
+func baseType(schema *Schema) string {
+	switch t := schema.Type.(type) {
+	case string:
+		return t
+	case []string:
+		return t[0]
+	}
+	return ""
+}
+
+// nullable allows null in addition to the values of schema
+func nullable(schema *Schema) *Schema {
+	switch {
+	case schema.Ref != "":
+		return &Schema{AnyOf: []*Schema{schema, {Type: "null"}}}
+	case baseType(schema) != "":
+		schema.Type = []string{baseType(schema), "null"}
+	}
+	return schema
+}
+
+// queryParameters describes the query parameters bound to a request struct by its form tags.
+// Embedded structs contribute their parameters, as gin binds them too.
+func (g *schemaGenerator) queryParameters(v interface{}) []*Parameter {
+	return g.formParameters(reflect.TypeOf(v))
+}
+
+func (g *schemaGenerator) formParameters(t reflect.Type) []*Parameter {
+	var parameters []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			parameters = append(parameters, g.formParameters(field.Type)...)
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("form"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		// A missing query parameter is nil; it is never null
+		field.Type = indirect(field.Type)
+		schema, required := g.field(field)
+		parameters = append(parameters, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: parameterDescriptions[name],
+			Required:    required,
+			Schema:      schema,
+		})
+	}
+	return parameters
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
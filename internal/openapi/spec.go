@@ -0,0 +1,428 @@
+package openapi
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/patch"
+)
+
+// parameterDescriptions documents query parameters whose meaning is not evident from their schema
+var parameterDescriptions = map[string]string{
+	"role":          "Roles to match; repeat the parameter or separate values with commas",
+	"role!":         "Roles to exclude",
+	"id":            "User IDs to match",
+	"id!":           "User IDs to exclude",
+	"phone":         `Phone numbers to match; "null" matches users without a phone`,
+	"phone!":        `Phone numbers to exclude; "null" excludes users without a phone`,
+	"search":        "Case-insensitive substring of the email or full name",
+	"email_domain":  "Email domain to match",
+	"created_from":  "Start of the creation range, inclusive; a YYYY-MM-DD date or RFC 3339 datetime",
+	"created_to":    "End of the creation range, exclusive; a YYYY-MM-DD date includes that whole day",
+	"updated_from":  "Start of the update range, inclusive; a YYYY-MM-DD date or RFC 3339 datetime",
+	"updated_to":    "End of the update range, exclusive; a YYYY-MM-DD date includes that whole day",
+	"tz":            "IANA time zone in which plain dates are interpreted, e.g. Asia/Jakarta; defaults to UTC",
+	"manager_id":    `IDs of the managers whose direct reports match; "null" matches users without a manager`,
+	"manager_id!":   "IDs of the managers whose direct reports are excluded",
+	"under_manager": "ID of the manager whose whole reporting subtree matches",
+	"or":            `OR group of field=value terms separated by "|", e.g. role=admin|phone!=null`,
+	"filter":        "RSQL/FIQL expression, e.g. role==supplier;created_at=ge=2026-01-01",
+	"sort":          "Comma-separated sort keys, e.g. role,-full_name,phone:nulls_last; takes precedence over sort_by",
+	"fields":        "Comma-separated list of user fields to return; the others are omitted from the response",
+	"max_depth":     "Maximum number of levels below the user; defaults to 20",
+	"columns":       "Comma-separated list of columns to include",
+	"dry_run":       "Report what each row would do without writing anything",
+	"mode":          "create fails on existing emails; upsert updates the users matched by email",
+	"mapping":       "JSON object mapping CSV headers to user fields",
+	"interval":      "Size of the time series buckets; defaults to day",
+}
+
+// specBuilder assembles the operations of the document from the request and response models
+type specBuilder struct {
+	schemas *schemaGenerator
+	paths   map[string]PathItem
+}
+
+// Build describes every route registered under /api/v1 by router.SetupRouter
+func Build() *Document {
+	b := &specBuilder{schemas: newSchemaGenerator(), paths: make(map[string]PathItem)}
+	b.users()
+	b.apiKeys()
+
+	return &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "GoodsChain User Service",
+			Description: "Manages GoodsChain users, their reporting lines and their personal data. Errors are RFC 7807 problem documents.",
+			Version:     "1.0.0",
+		},
+		Paths: b.paths,
+		Components: Components{
+			Schemas: b.schemas.components,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearer": {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+					Description:  "An RS256, ES256 or EdDSA access token from the configured issuer, for the service's audience",
+				},
+				"apiKey": {
+					Type:        "apiKey",
+					In:          "header",
+					Name:        "Authorization",
+					Description: "A service API key, sent as `ApiKey <key>`",
+				},
+			},
+		},
+		Security: []SecurityRequirement{{"bearer": {}}, {"apiKey": {}}},
+	}
+}
+
+func (b *specBuilder) users() {
+	user := b.schemas.of(models.User{})
+	list := append(b.schemas.queryParameters(models.GetUsersRequest{}), ifNoneMatch)
+	single := append(b.schemas.queryParameters(models.GetUserRequest{}), ifNoneMatch, ifModifiedSince)
+
+	b.add("GET", "/api/v1/users/", &Operation{
+		OperationID: "listUsers",
+		Summary:     "List users",
+		Parameters:  list,
+		Responses: b.responses(
+			http.StatusOK, cached(jsonResponse("A page of users", b.schemas.of(models.GetUsersResponse{}))),
+			http.StatusNotModified, notModified,
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("POST", "/api/v1/users/", &Operation{
+		OperationID: "createUser",
+		Summary:     "Create a user",
+		Parameters:  []*Parameter{idempotencyKey},
+		RequestBody: jsonBody(b.schemas.of(models.CreateUserRequest{})),
+		Responses: b.idempotent(
+			http.StatusCreated, jsonResponse("The created user", user),
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/export", &Operation{
+		OperationID: "exportUsers",
+		Summary:     "Stream the users matching a filter as CSV or NDJSON",
+		Parameters:  b.schemas.queryParameters(models.ExportUsersRequest{}),
+		Responses: b.responses(
+			http.StatusOK, &Response{
+				Description: "The matching users, one per line",
+				Headers:     map[string]*Header{"Content-Disposition": {Schema: &Schema{Type: "string"}}},
+				Content: map[string]*MediaType{
+					"text/csv":             {Schema: &Schema{Type: "string"}},
+					"application/x-ndjson": {Schema: &Schema{Type: "string"}},
+				},
+			},
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("POST", "/api/v1/users/import", &Operation{
+		OperationID: "importUsers",
+		Summary:     "Import users from CSV",
+		Parameters:  append(b.schemas.queryParameters(models.ImportUsersRequest{}), idempotencyKey),
+		RequestBody: &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"text/csv": {Schema: &Schema{Type: "string"}},
+				"multipart/form-data": {Schema: &Schema{
+					Type:       "object",
+					Properties: map[string]*Schema{"file": {Type: "string", Format: "binary"}},
+					Required:   []string{"file"},
+				}},
+			},
+		},
+		Responses: b.idempotent(
+			http.StatusOK, jsonResponse("The outcome of every row", b.schemas.of(models.ImportUsersResponse{})),
+			http.StatusUnprocessableEntity, jsonResponse("Rows were invalid or failed; nothing was written", b.schemas.of(models.ImportUsersResponse{})),
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/stats", &Operation{
+		OperationID: "getUserStats",
+		Summary:     "Count the users matching a filter",
+		Parameters:  b.schemas.queryParameters(models.GetUserStatsRequest{}),
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("Counts and time series of the matching users", b.schemas.of(models.UserStats{})),
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/by-email/{email}", &Operation{
+		OperationID: "getUserByEmail",
+		Summary:     "Get a user by email",
+		Description: "The email is matched case-insensitively and ignoring surrounding whitespace.",
+		Parameters:  append([]*Parameter{{Name: "email", In: "path", Required: true, Schema: &Schema{Type: "string"}}}, single...),
+		Responses: b.responses(
+			http.StatusOK, cached(jsonResponse("The user", user)),
+			http.StatusNotModified, notModified,
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+	b.add("POST", "/api/v1/users/batch-get", &Operation{
+		OperationID: "batchGetUsers",
+		Summary:     "Get up to 500 users by ID",
+		Parameters:  b.schemas.queryParameters(models.GetUserRequest{}),
+		RequestBody: jsonBody(b.schemas.of(models.BatchGetUsersRequest{})),
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("The users found, in request order, and the IDs without a user", b.schemas.of(models.BatchGetUsersResponse{})),
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+
+	b.add("GET", "/api/v1/users/{id}", &Operation{
+		OperationID: "getUser",
+		Summary:     "Get a user by ID",
+		Parameters:  append([]*Parameter{userID}, single...),
+		Responses: b.responses(
+			http.StatusOK, cached(jsonResponse("The user", user)),
+			http.StatusMovedPermanently, &Response{
+				Description: "The user has been merged into the user at Location",
+				Headers:     map[string]*Header{"Location": {Schema: &Schema{Type: "string"}}},
+				Content: jsonContent(&Schema{
+					Type: "object",
+					Properties: map[string]*Schema{
+						"error":       {Type: "string"},
+						"merged_into": {Type: "string", Format: "uuid"},
+						"location":    {Type: "string"},
+					},
+					Required: []string{"error", "merged_into", "location"},
+				}),
+			},
+			http.StatusNotModified, notModified,
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+	b.add("PATCH", "/api/v1/users/{id}", &Operation{
+		OperationID: "updateUser",
+		Summary:     "Update a user",
+		Description: "application/json updates the fields given, application/merge-patch+json (RFC 7396) can also clear them with null, and application/json-patch+json (RFC 6902) applies a list of operations.",
+		Parameters:  []*Parameter{userID, idempotencyKey},
+		RequestBody: &RequestBody{
+			Required: true,
+			Content: map[string]*MediaType{
+				"application/json":          {Schema: b.schemas.of(models.UpdateUserRequest{})},
+				patch.MergePatchContentType: {Schema: &Schema{Type: "object"}},
+				patch.JSONPatchContentType:  {Schema: b.schemas.of([]patch.Operation{})},
+			},
+		},
+		Responses: b.idempotent(
+			http.StatusOK, jsonResponse("The updated user", user),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone, http.StatusInternalServerError,
+		),
+	})
+	b.add("PUT", "/api/v1/users/{id}", &Operation{
+		OperationID: "replaceUser",
+		Summary:     "Replace a user",
+		Description: "Omitted optional fields are cleared.",
+		Parameters:  []*Parameter{userID, idempotencyKey},
+		RequestBody: jsonBody(b.schemas.of(models.ReplaceUserRequest{})),
+		Responses: b.idempotent(
+			http.StatusOK, jsonResponse("The replaced user", user),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusGone, http.StatusInternalServerError,
+		),
+	})
+	b.add("DELETE", "/api/v1/users/{id}", &Operation{
+		OperationID: "deleteUser",
+		Summary:     "Delete a user",
+		Parameters:  []*Parameter{userID, idempotencyKey},
+		Responses: b.idempotent(
+			http.StatusOK, jsonResponse("The deleted user", user),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/{id}/reports", &Operation{
+		OperationID: "getDirectReports",
+		Summary:     "Get a user's direct reports",
+		Parameters:  []*Parameter{userID},
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("The direct reports", b.schemas.of(models.UserListResponse{})),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/{id}/subordinates", &Operation{
+		OperationID: "getSubordinates",
+		Summary:     "Get a user's reporting subtree",
+		Parameters:  append([]*Parameter{userID}, b.schemas.queryParameters(models.GetUserHierarchyRequest{})...),
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("Everyone below the user with their depth", b.schemas.of(models.GetUserHierarchyResponse{})),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/{id}/chain", &Operation{
+		OperationID: "getManagementChain",
+		Summary:     "Get a user's management chain",
+		Parameters:  []*Parameter{userID},
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("The user's managers up to the root, nearest first", b.schemas.of(models.GetUserHierarchyResponse{})),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+	b.add("POST", "/api/v1/users/{id}/merge", &Operation{
+		OperationID: "mergeUser",
+		Summary:     "Merge a duplicate user into another user",
+		Parameters:  []*Parameter{userID, idempotencyKey},
+		RequestBody: jsonBody(b.schemas.of(models.MergeUsersRequest{})),
+		Responses: b.idempotent(
+			http.StatusOK, jsonResponse("The surviving and the merged user", b.schemas.of(models.MergeUsersResponse{})),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError,
+		),
+	})
+	b.add("POST", "/api/v1/users/{id}/erase", &Operation{
+		OperationID: "eraseUser",
+		Summary:     "Erase a user's personal data",
+		Parameters:  []*Parameter{userID, idempotencyKey},
+		Responses: b.idempotent(
+			http.StatusOK, jsonResponse("The signed erasure receipt", b.schemas.of(models.ErasureReceipt{})),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusInternalServerError,
+		),
+	})
+	b.add("GET", "/api/v1/users/{id}/export", &Operation{
+		OperationID: "exportUserData",
+		Summary:     "Export everything stored about a user",
+		Parameters:  append([]*Parameter{userID}, b.schemas.queryParameters(models.ExportUserDataRequest{})...),
+		Responses: b.responses(
+			http.StatusOK, &Response{
+				Description: "The export as JSON, or as a ZIP archive holding it",
+				Headers:     map[string]*Header{"Content-Disposition": {Schema: &Schema{Type: "string"}}},
+				Content: map[string]*MediaType{
+					"application/json": {Schema: b.schemas.of(models.UserDataExport{})},
+					"application/zip":  {Schema: &Schema{Type: "string", Format: "binary"}},
+				},
+			},
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+}
+
+func (b *specBuilder) apiKeys() {
+	apiKey := b.schemas.of(models.APIKey{})
+
+	b.add("GET", "/api/v1/api-keys/", &Operation{
+		OperationID: "listAPIKeys",
+		Summary:     "List API keys",
+		Description: "Admins only; API keys need the api-keys:manage scope. Secrets are never returned.",
+		Tags:        []string{"api-keys"},
+		Parameters:  b.schemas.queryParameters(models.ListAPIKeysRequest{}),
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("The API keys, newest first", b.schemas.of(models.ListAPIKeysResponse{})),
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("POST", "/api/v1/api-keys/", &Operation{
+		OperationID: "createAPIKey",
+		Summary:     "Create an API key",
+		Description: "Admins only; API keys need the api-keys:manage scope. The response is the only time the secret key is returned; only its hash is stored.",
+		Tags:        []string{"api-keys"},
+		RequestBody: jsonBody(b.schemas.of(models.CreateAPIKeyRequest{})),
+		Responses: b.responses(
+			http.StatusCreated, jsonResponse("The created API key and its secret", b.schemas.of(models.CreateAPIKeyResponse{})),
+			http.StatusBadRequest, http.StatusInternalServerError,
+		),
+	})
+	b.add("DELETE", "/api/v1/api-keys/{id}", &Operation{
+		OperationID: "revokeAPIKey",
+		Summary:     "Revoke an API key",
+		Description: "Admins only; API keys need the api-keys:manage scope. Revoking a revoked key keeps the time of the first revocation.",
+		Tags:        []string{"api-keys"},
+		Parameters:  []*Parameter{apiKeyID},
+		Responses: b.responses(
+			http.StatusOK, jsonResponse("The revoked API key", apiKey),
+			http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError,
+		),
+	})
+}
+
+// add registers an operation, tagged as a users operation unless it has tags; path uses OpenAPI
+// {param} templates. Every operation requires authentication and is subject to authorization, so
+// each can respond with 401 and 403.
+func (b *specBuilder) add(method, path string, operation *Operation) {
+	if operation.Tags == nil {
+		operation.Tags = []string{"users"}
+	}
+	for status, response := range b.responses(http.StatusUnauthorized, http.StatusForbidden) {
+		operation.Responses[status] = response
+	}
+	if b.paths[path] == nil {
+		b.paths[path] = PathItem{}
+	}
+	b.paths[path][strings.ToLower(method)] = operation
+}
+
+// responses builds the responses of an operation from status codes, each followed by its response
+// unless it is an error, which is described by a problem document
+func (b *specBuilder) responses(entries ...interface{}) map[string]*Response {
+	problem := b.schemas.of(models.Problem{})
+	responses := make(map[string]*Response)
+	for i := 0; i < len(entries); i++ {
+		status := entries[i].(int)
+		if i+1 < len(entries) {
+			if response, ok := entries[i+1].(*Response); ok {
+				responses[strconv.Itoa(status)] = response
+				i++
+				continue
+			}
+		}
+		responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{models.ProblemContentType: {Schema: problem}},
+		}
+	}
+	return responses
+}
+
+// idempotent adds the responses of the Idempotency-Key middleware to an operation's responses
+func (b *specBuilder) idempotent(entries ...interface{}) map[string]*Response {
+	responses := b.responses(entries...)
+	for status, response := range b.responses(http.StatusBadRequest, http.StatusConflict, http.StatusUnprocessableEntity, http.StatusInternalServerError) {
+		if _, ok := responses[status]; !ok {
+			responses[status] = response
+		}
+	}
+	return responses
+}
+
+var (
+	userID   = &Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}}
+	apiKeyID = &Parameter{Name: "id", In: "path", Required: true, Schema: &Schema{Type: "string", Format: "uuid"}}
+
+	idempotencyKey = &Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "Replays the stored response of an earlier request with the same key instead of repeating it",
+		Schema:      &Schema{Type: "string", MaxLength: intPtr(255)},
+	}
+
+	ifNoneMatch     = &Parameter{Name: "If-None-Match", In: "header", Schema: &Schema{Type: "string"}}
+	ifModifiedSince = &Parameter{Name: "If-Modified-Since", In: "header", Schema: &Schema{Type: "string"}}
+
+	notModified = &Response{Description: "The representation matches the validators in the request"}
+)
+
+func intPtr(v int) *int {
+	return &v
+}
+
+func jsonContent(schema *Schema) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: schema}}
+}
+
+func jsonBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: jsonContent(schema)}
+}
+
+func jsonResponse(description string, schema *Schema) *Response {
+	return &Response{Description: description, Content: jsonContent(schema)}
+}
+
+// cached adds the ETag and Last-Modified validators to a response
+func cached(response *Response) *Response {
+	response.Headers = map[string]*Header{
+		"ETag":          {Schema: &Schema{Type: "string"}},
+		"Last-Modified": {Schema: &Schema{Type: "string"}},
+	}
+	return response
+}
@@ -0,0 +1,127 @@
+package patch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestMergePatch tests the examples from RFC 7396 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		doc      string
+		patch    string
+		expected string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			result, err := MergePatch([]byte(tt.doc), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+// TestMergePatch_Invalid tests that malformed patches are rejected
+func TestMergePatch_Invalid(t *testing.T) {
+	_, err := MergePatch([]byte(`{}`), []byte(`{"a":`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid merge patch")
+
+	_, err = MergePatch([]byte(`{}`), []byte(`{} {}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid merge patch")
+}
+
+// TestJSONPatch tests each operation, including examples from RFC 6902 appendix A
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		doc      string
+		patch    string
+		expected string
+	}{
+		{"AddMember", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"foo":"bar","baz":"qux"}`},
+		{"AddArrayElement", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"AppendArrayElement", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+		{"RemoveMember", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"RemoveArrayElement", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"Replace", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"Move", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"MoveArrayElement", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"Copy", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"TestThenReplace", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2},{"op":"replace","path":"/baz","value":null}]`, `{"baz":null,"foo":["a",2,"c"]}`},
+		{"EscapedPointer", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10},{"op":"remove","path":"/~1"}]`, `{"~1":10}`},
+		{"ReplaceRoot", `{"a":1}`, `[{"op":"replace","path":"","value":[1]}]`, `[1]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(result))
+		})
+	}
+}
+
+// TestJSONPatch_Errors tests that invalid operations and failed tests are reported
+func TestJSONPatch_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		patch  string
+		errMsg string
+	}{
+		{"NotAnArray", `{}`, `{"op":"add"}`, "expected an array of operations"},
+		{"UnknownOp", `{}`, `[{"op":"frobnicate","path":"/a"}]`, `unknown op "frobnicate"`},
+		{"MissingPath", `{}`, `[{"op":"add","value":1}]`, "missing path"},
+		{"MissingValue", `{}`, `[{"op":"add","path":"/a"}]`, "missing value"},
+		{"MissingFrom", `{"a":1}`, `[{"op":"copy","path":"/b"}]`, "missing from"},
+		{"RelativePath", `{}`, `[{"op":"add","path":"a","value":1}]`, "must be empty or start with /"},
+		{"RemoveMissing", `{"a":1}`, `[{"op":"remove","path":"/b"}]`, `path not found: "b"`},
+		{"ReplaceMissing", `{"a":1}`, `[{"op":"replace","path":"/b","value":2}]`, `path not found: "b"`},
+		{"AddToMissingParent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`, `path not found: "a"`},
+		{"IndexOutOfBounds", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":1}]`, "out of bounds"},
+		{"LeadingZeroIndex", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`, `invalid array index "01"`},
+		{"MoveIntoChild", `{"a":{"b":{}}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`, "cannot move a value into itself"},
+		{"TestMismatch", `{"a":"b"}`, `[{"op":"test","path":"/a","value":"c"}]`, "test failed"},
+		{"TestMissing", `{"a":"b"}`, `[{"op":"test","path":"/c","value":"b"}]`, "test failed"},
+		{"TestTypeMismatch", `{"a":"1"}`, `[{"op":"test","path":"/a","value":1}]`, "test failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := JSONPatch([]byte(tt.doc), []byte(tt.patch))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+// TestJSONPatch_Atomic tests that a failing operation leaves no partial result
+func TestJSONPatch_Atomic(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	result, err := JSONPatch(doc, []byte(`[{"op":"replace","path":"/a","value":2},{"op":"test","path":"/a","value":3}]`))
+
+	require.Error(t, err)
+	assert.Nil(t, result)
+	assert.Equal(t, `{"a":1}`, string(doc))
+}
@@ -0,0 +1,44 @@
+// Package receipt signs and verifies the receipts handed out to data subjects
+package receipt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// Algorithm identifies the signature scheme used by Signer
+const Algorithm = "HMAC-SHA256"
+
+// Signer produces and checks HMAC-SHA256 signatures over JSON-encoded receipts
+type Signer struct {
+	key []byte
+}
+
+// NewSigner creates a new Signer using the given secret key
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// Sign returns the base64url-encoded signature of the JSON encoding of payload
+func (s *Signer) Sign(payload interface{}) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode receipt: %w", err)
+	}
+
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(data)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Verify reports whether signature is a valid signature of payload
+func (s *Signer) Verify(payload interface{}, signature string) (bool, error) {
+	expected, err := s.Sign(payload)
+	if err != nil {
+		return false, err
+	}
+	return hmac.Equal([]byte(expected), []byte(signature)), nil
+}
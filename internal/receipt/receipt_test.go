@@ -0,0 +1,37 @@
+package receipt
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSigner_SignAndVerify tests that a signature verifies against the same payload
+func TestSigner_SignAndVerify(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	payload := map[string]string{"user_id": "123"}
+
+	signature, err := signer.Sign(payload)
+	require.NoError(t, err)
+	assert.NotEmpty(t, signature)
+
+	valid, err := signer.Verify(payload, signature)
+	require.NoError(t, err)
+	assert.True(t, valid)
+}
+
+// TestSigner_VerifyTampered tests that modified payloads or foreign keys fail verification
+func TestSigner_VerifyTampered(t *testing.T) {
+	signer := NewSigner([]byte("secret"))
+	signature, err := signer.Sign(map[string]string{"user_id": "123"})
+	require.NoError(t, err)
+
+	valid, err := signer.Verify(map[string]string{"user_id": "456"}, signature)
+	require.NoError(t, err)
+	assert.False(t, valid)
+
+	valid, err = NewSigner([]byte("other")).Verify(map[string]string{"user_id": "123"}, signature)
+	require.NoError(t, err)
+	assert.False(t, valid)
+}
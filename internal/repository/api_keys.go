@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// APIKeyRepository stores the API keys of service principals
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error)
+	// RevokeAPIKey marks a key as revoked, keeping the time of an earlier revocation
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error)
+	TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error
+}
+
+// apiKeyColumns lists the columns selected for an API key
+const apiKeyColumns = "id, name, service, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at"
+
+// apiKeyRow scans an api_keys row, whose scopes are a text array
+type apiKeyRow struct {
+	models.APIKey
+	Scopes pq.StringArray `db:"scopes"`
+}
+
+func (r *apiKeyRow) toModel() *models.APIKey {
+	key := r.APIKey
+	key.Scopes = []string(r.Scopes)
+	return &key
+}
+
+// postgresAPIKeyRepository implements APIKeyRepository for PostgreSQL
+type postgresAPIKeyRepository struct {
+	db *sqlx.DB
+}
+
+// NewPostgresAPIKeyRepository creates a new instance of postgresAPIKeyRepository
+func NewPostgresAPIKeyRepository(db *sqlx.DB) APIKeyRepository {
+	return &postgresAPIKeyRepository{db: db}
+}
+
+// CreateAPIKey inserts a new API key
+func (r *postgresAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (id, name, service, prefix, key_hash, scopes, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING ` + apiKeyColumns
+
+	var row apiKeyRow
+	err := r.db.GetContext(ctx, &row, query,
+		uuid.New(), key.Name, key.Service, key.Prefix, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate key") {
+			return nil, fmt.Errorf("api key prefix already exists: %w", err)
+		}
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+	return row.toModel(), nil
+}
+
+// GetAPIKeyByPrefix retrieves the key with the given prefix, including revoked and expired keys
+func (r *postgresAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	var row apiKeyRow
+	query := "SELECT " + apiKeyColumns + " FROM api_keys WHERE prefix = $1"
+	if err := r.db.GetContext(ctx, &row, query, prefix); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to get api key: %w", err)
+	}
+	return row.toModel(), nil
+}
+
+// ListAPIKeys retrieves the keys, newest first
+func (r *postgresAPIKeyRepository) ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error) {
+	query := `
+		SELECT ` + apiKeyColumns + ` FROM api_keys
+		WHERE ($1::text IS NULL OR service = $1) AND ($2 OR revoked_at IS NULL)
+		ORDER BY created_at DESC, id`
+
+	var rows []apiKeyRow
+	if err := r.db.SelectContext(ctx, &rows, query, req.Service, req.IncludeRevoked); err != nil {
+		return nil, fmt.Errorf("failed to list api keys: %w", err)
+	}
+
+	keys := make([]models.APIKey, 0, len(rows))
+	for i := range rows {
+		keys = append(keys, *rows[i].toModel())
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks a key as revoked
+func (r *postgresAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	var row apiKeyRow
+	query := "UPDATE api_keys SET revoked_at = COALESCE(revoked_at, NOW()) WHERE id = $1 RETURNING " + apiKeyColumns
+	if err := r.db.GetContext(ctx, &row, query, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	return row.toModel(), nil
+}
+
+// TouchAPIKey records when a key was last used
+func (r *postgresAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	if _, err := r.db.ExecContext(ctx, "UPDATE api_keys SET last_used_at = $1 WHERE id = $2", usedAt, id); err != nil {
+		return fmt.Errorf("failed to update api key last use: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var apiKeyColumnNames = []string{"id", "name", "service", "prefix", "key_hash", "scopes", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+func setupMockAPIKeyRepository(t *testing.T) (*sql.DB, sqlmock.Sqlmock, APIKeyRepository) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return db, mock, NewPostgresAPIKeyRepository(sqlx.NewDb(db, "postgres"))
+}
+
+func apiKeyRows(id uuid.UUID, revokedAt interface{}) *sqlmock.Rows {
+	createdAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	return sqlmock.NewRows(apiKeyColumnNames).
+		AddRow(id, "nightly sync", "erp-sync", "gcu_0123456789ab", []byte{1, 2, 3}, "{users:read,users:write}", createdAt, nil, nil, revokedAt)
+}
+
+// TestCreateAPIKey tests that keys are inserted with their scopes as an array
+func TestCreateAPIKey(t *testing.T) {
+	db, mock, repo := setupMockAPIKeyRepository(t)
+	defer db.Close()
+
+	id := uuid.New()
+	expiresAt := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`INSERT INTO api_keys \(id, name, service, prefix, key_hash, scopes, expires_at\)\s+VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\)\s+RETURNING id, name, service, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at`).
+		WithArgs(sqlmock.AnyArg(), "nightly sync", "erp-sync", "gcu_0123456789ab", []byte{1, 2, 3}, pq.Array([]string{"users:read", "users:write"}), &expiresAt).
+		WillReturnRows(apiKeyRows(id, nil))
+
+	key, err := repo.CreateAPIKey(context.Background(), &models.APIKey{
+		Name:      "nightly sync",
+		Service:   "erp-sync",
+		Prefix:    "gcu_0123456789ab",
+		KeyHash:   []byte{1, 2, 3},
+		Scopes:    []string{"users:read", "users:write"},
+		ExpiresAt: &expiresAt,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, id, key.ID)
+	assert.Equal(t, []string{"users:read", "users:write"}, key.Scopes)
+	assert.Equal(t, []byte{1, 2, 3}, key.KeyHash)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestCreateAPIKey_DuplicatePrefix tests that a prefix collision is reported
+func TestCreateAPIKey_DuplicatePrefix(t *testing.T) {
+	db, mock, repo := setupMockAPIKeyRepository(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`INSERT INTO api_keys`).
+		WillReturnError(errors.New(`pq: duplicate key value violates unique constraint "api_keys_prefix_key"`))
+
+	_, err := repo.CreateAPIKey(context.Background(), &models.APIKey{Prefix: "gcu_0123456789ab"})
+
+	assert.ErrorContains(t, err, "api key prefix already exists")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAPIKeyByPrefix tests lookups of existing and unknown prefixes
+func TestGetAPIKeyByPrefix(t *testing.T) {
+	db, mock, repo := setupMockAPIKeyRepository(t)
+	defer db.Close()
+
+	id := uuid.New()
+	query := `SELECT id, name, service, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at FROM api_keys WHERE prefix = \$1`
+	mock.ExpectQuery(query).WithArgs("gcu_0123456789ab").WillReturnRows(apiKeyRows(id, nil))
+	mock.ExpectQuery(query).WithArgs("gcu_unknown").WillReturnError(sql.ErrNoRows)
+
+	key, err := repo.GetAPIKeyByPrefix(context.Background(), "gcu_0123456789ab")
+	require.NoError(t, err)
+	assert.Equal(t, "erp-sync", key.Service)
+	assert.Nil(t, key.RevokedAt)
+
+	_, err = repo.GetAPIKeyByPrefix(context.Background(), "gcu_unknown")
+	assert.EqualError(t, err, "api key not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestListAPIKeys tests that the service filter and revoked flag are passed to the query
+func TestListAPIKeys(t *testing.T) {
+	db, mock, repo := setupMockAPIKeyRepository(t)
+	defer db.Close()
+
+	service := "erp-sync"
+	query := `SELECT id, name, service, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at FROM api_keys\s+WHERE \(\$1::text IS NULL OR service = \$1\) AND \(\$2 OR revoked_at IS NULL\)\s+ORDER BY created_at DESC, id`
+	mock.ExpectQuery(query).WithArgs(&service, true).WillReturnRows(apiKeyRows(uuid.New(), time.Now()))
+	mock.ExpectQuery(query).WithArgs(nil, false).WillReturnRows(sqlmock.NewRows(apiKeyColumnNames))
+
+	keys, err := repo.ListAPIKeys(context.Background(), &models.ListAPIKeysRequest{Service: &service, IncludeRevoked: true})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].RevokedAt)
+
+	keys, err = repo.ListAPIKeys(context.Background(), &models.ListAPIKeysRequest{})
+	require.NoError(t, err)
+	assert.NotNil(t, keys, "no keys is an empty list")
+	assert.Empty(t, keys)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestRevokeAPIKey tests revoking existing and unknown keys
+func TestRevokeAPIKey(t *testing.T) {
+	db, mock, repo := setupMockAPIKeyRepository(t)
+	defer db.Close()
+
+	id := uuid.New()
+	query := `UPDATE api_keys SET revoked_at = COALESCE\(revoked_at, NOW\(\)\) WHERE id = \$1 RETURNING id, name, service, prefix, key_hash, scopes, created_at, expires_at, last_used_at, revoked_at`
+	mock.ExpectQuery(query).WithArgs(id).WillReturnRows(apiKeyRows(id, time.Now()))
+	mock.ExpectQuery(query).WithArgs(id).WillReturnError(sql.ErrNoRows)
+
+	key, err := repo.RevokeAPIKey(context.Background(), id)
+	require.NoError(t, err)
+	assert.NotNil(t, key.RevokedAt)
+
+	_, err = repo.RevokeAPIKey(context.Background(), id)
+	assert.EqualError(t, err, "api key not found")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestTouchAPIKey tests that the last use is recorded
+func TestTouchAPIKey(t *testing.T) {
+	db, mock, repo := setupMockAPIKeyRepository(t)
+	defer db.Close()
+
+	id := uuid.New()
+	usedAt := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	mock.ExpectExec(`UPDATE api_keys SET last_used_at = \$1 WHERE id = \$2`).
+		WithArgs(usedAt, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	assert.NoError(t, repo.TouchAPIKey(context.Background(), id, usedAt))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
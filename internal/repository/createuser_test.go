@@ -34,8 +34,8 @@ func TestCreateUser_Success(t *testing.T) {
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 
 	// Set up mock expectation
-	mock.ExpectQuery(`INSERT INTO users \(id, email, full_name, phone, role, is_active, created_at, updated_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id, email, full_name, phone, role, is_active, created_at, updated_at`).
-		WithArgs(sqlmock.AnyArg(), "test@example.com", "John Doe", &phone, "admin", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectQuery(`INSERT INTO users \(id, email, full_name, phone, role, is_active, manager_id\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\) RETURNING id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at`).
+		WithArgs(sqlmock.AnyArg(), "test@example.com", "John Doe", &phone, "admin", true, nil).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(expectedID, "test@example.com", "John Doe", &phone, "admin", true, expectedTime, expectedTime))
 
@@ -58,8 +58,6 @@ func TestCreateUser_Success(t *testing.T) {
 	// Verify that the input user was modified with generated values
 	assert.NotEqual(t, uuid.Nil, inputUser.ID)
 	assert.True(t, inputUser.IsActive)
-	assert.False(t, inputUser.CreatedAt.IsZero())
-	assert.False(t, inputUser.UpdatedAt.IsZero())
 
 	// Verify all expectations were met
 	err = mock.ExpectationsWereMet()
@@ -84,8 +82,8 @@ func TestCreateUser_SuccessMinimalFields(t *testing.T) {
 
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 
-	mock.ExpectQuery(`INSERT INTO users \(id, email, full_name, phone, role, is_active, created_at, updated_at\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7, \$8\) RETURNING id, email, full_name, phone, role, is_active, created_at, updated_at`).
-		WithArgs(sqlmock.AnyArg(), "minimal@example.com", "Jane Doe", nil, "staff", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+	mock.ExpectQuery(`INSERT INTO users \(id, email, full_name, phone, role, is_active, manager_id\) VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\) RETURNING id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at`).
+		WithArgs(sqlmock.AnyArg(), "minimal@example.com", "Jane Doe", nil, "staff", true, nil).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(expectedID, "minimal@example.com", "Jane Doe", nil, "staff", true, expectedTime, expectedTime))
 
@@ -118,7 +116,7 @@ func TestCreateUser_DatabaseError(t *testing.T) {
 
 	// Simulate a database error (e.g., unique constraint violation)
 	mock.ExpectQuery(`INSERT INTO users`).
-		WithArgs(sqlmock.AnyArg(), "error@example.com", "Error User", nil, "admin", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "error@example.com", "Error User", nil, "admin", true, nil).
 		WillReturnError(sql.ErrConnDone)
 
 	ctx := context.Background()
@@ -146,7 +144,7 @@ func TestCreateUser_ScanError(t *testing.T) {
 	// Return invalid data that will cause scanning to fail
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 	mock.ExpectQuery(`INSERT INTO users`).
-		WithArgs(sqlmock.AnyArg(), "scan@example.com", "Scan User", nil, "supplier", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "scan@example.com", "Scan User", nil, "supplier", true, nil).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow("invalid-uuid", "scan@example.com", "Scan User", nil, "supplier", true, "invalid-time", "invalid-time"))
 
@@ -175,7 +173,7 @@ func TestCreateUser_NoRowsReturned(t *testing.T) {
 	// Return empty result set
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 	mock.ExpectQuery(`INSERT INTO users`).
-		WithArgs(sqlmock.AnyArg(), "norows@example.com", "No Rows User", nil, "admin", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "norows@example.com", "No Rows User", nil, "admin", true, nil).
 		WillReturnRows(sqlmock.NewRows(columns)) // Empty rows
 
 	ctx := context.Background()
@@ -207,7 +205,7 @@ func TestCreateUser_ContextCancelled(t *testing.T) {
 	// The query should not be executed due to cancelled context
 	// but we still need to set up the expectation in case it does get called
 	mock.ExpectQuery(`INSERT INTO users`).
-		WithArgs(sqlmock.AnyArg(), "context@example.com", "Context User", nil, "staff", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "context@example.com", "Context User", nil, "staff", true, nil).
 		WillReturnError(context.Canceled)
 
 	result, err := repo.CreateUser(ctx, inputUser)
@@ -231,7 +229,7 @@ func TestCreateUser_UniqueConstraintViolation(t *testing.T) {
 
 	// Simulate unique constraint violation (email already exists)
 	mock.ExpectQuery(`INSERT INTO users`).
-		WithArgs(sqlmock.AnyArg(), "duplicate@example.com", "Duplicate User", nil, "admin", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "duplicate@example.com", "Duplicate User", nil, "admin", true, nil).
 		WillReturnError(sql.ErrNoRows) // This simulates a constraint violation
 
 	ctx := context.Background()
@@ -265,7 +263,7 @@ func TestCreateUser_AllRoles(t *testing.T) {
 			columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 
 			mock.ExpectQuery(`INSERT INTO users`).
-				WithArgs(sqlmock.AnyArg(), role+"@example.com", role+" User", nil, role, true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+				WithArgs(sqlmock.AnyArg(), role+"@example.com", role+" User", nil, role, true, nil).
 				WillReturnRows(sqlmock.NewRows(columns).
 					AddRow(expectedID, role+"@example.com", role+" User", nil, role, true, expectedTime, expectedTime))
 
@@ -282,7 +280,7 @@ func TestCreateUser_AllRoles(t *testing.T) {
 	}
 }
 
-// TestCreateUser_VerifyGeneratedFields tests that UUIDs and timestamps are properly generated
+// TestCreateUser_VerifyGeneratedFields tests that UUIDs are generated and timestamps are left to the database
 func TestCreateUser_VerifyGeneratedFields(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
 	defer db.Close()
@@ -298,7 +296,7 @@ func TestCreateUser_VerifyGeneratedFields(t *testing.T) {
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 
 	mock.ExpectQuery(`INSERT INTO users`).
-		WithArgs(sqlmock.AnyArg(), "generated@example.com", "Generated User", nil, "admin", true, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs(sqlmock.AnyArg(), "generated@example.com", "Generated User", nil, "admin", true, nil).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(expectedID, "generated@example.com", "Generated User", nil, "admin", true, expectedTime, expectedTime))
 
@@ -320,10 +318,10 @@ func TestCreateUser_VerifyGeneratedFields(t *testing.T) {
 	assert.True(t, originalUpdatedAt.IsZero())
 	assert.False(t, originalIsActive)
 
-	// Verify input user was modified with generated values
+	// Verify input user was modified with generated values; timestamps come from the database
 	assert.NotEqual(t, uuid.Nil, inputUser.ID)
-	assert.False(t, inputUser.CreatedAt.IsZero())
-	assert.False(t, inputUser.UpdatedAt.IsZero())
+	assert.True(t, inputUser.CreatedAt.IsZero())
+	assert.True(t, inputUser.UpdatedAt.IsZero())
 	assert.True(t, inputUser.IsActive)
 
 	// Verify returned user has expected values
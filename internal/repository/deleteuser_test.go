@@ -23,7 +23,7 @@ func TestDeleteUser_Success(t *testing.T) {
 
 	// Expect user selection query
 	selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(selectColumns).
 			AddRow(userID, "delete@example.com", "Delete User", &phone, "admin", true, expectedTime, expectedTime))
@@ -57,7 +57,7 @@ func TestDeleteUser_UserNotFound(t *testing.T) {
 	userID := uuid.New()
 
 	// Expect user selection query to fail
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnError(sql.ErrNoRows)
 
@@ -80,7 +80,7 @@ func TestDeleteUser_DatabaseErrorOnSelect(t *testing.T) {
 	userID := uuid.New()
 
 	// Expect user selection query to fail with database error
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnError(sql.ErrConnDone)
 
@@ -105,7 +105,7 @@ func TestDeleteUser_DatabaseErrorOnDelete(t *testing.T) {
 
 	// Expect successful user selection
 	selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(selectColumns).
 			AddRow(userID, "delete@example.com", "Delete User", nil, "admin", true, expectedTime, expectedTime))
@@ -136,7 +136,7 @@ func TestDeleteUser_NoRowsAffected(t *testing.T) {
 
 	// Expect successful user selection
 	selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(selectColumns).
 			AddRow(userID, "delete@example.com", "Delete User", nil, "admin", true, expectedTime, expectedTime))
@@ -167,7 +167,7 @@ func TestDeleteUser_RowsAffectedError(t *testing.T) {
 
 	// Expect successful user selection
 	selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(selectColumns).
 			AddRow(userID, "delete@example.com", "Delete User", nil, "admin", true, expectedTime, expectedTime))
@@ -198,7 +198,7 @@ func TestDeleteUser_ScanError(t *testing.T) {
 
 	// Return invalid data that will cause scanning to fail
 	selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(selectColumns).
 			AddRow("invalid-uuid", "delete@example.com", "Delete User", nil, "admin", true, "invalid-time", "invalid-time"))
@@ -226,7 +226,7 @@ func TestDeleteUser_ContextCancelled(t *testing.T) {
 	cancel() // Cancel immediately
 
 	// The query should not be executed due to cancelled context
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnError(context.Canceled)
 
@@ -270,7 +270,7 @@ func TestDeleteUser_AllUserTypes(t *testing.T) {
 
 			// Expect user selection query
 			selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-			mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+			mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 				WithArgs(userID).
 				WillReturnRows(sqlmock.NewRows(selectColumns).
 					AddRow(userID, email, fullName, phone, tc.role, tc.isActive, expectedTime, expectedTime))
@@ -308,7 +308,7 @@ func TestDeleteUser_ConcurrentDeletion(t *testing.T) {
 
 	// First query finds the user
 	selectColumns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(selectColumns).
 			AddRow(userID, "concurrent@example.com", "Concurrent User", nil, "admin", true, expectedTime, expectedTime))
@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// erasedFullName replaces the full name of erased users
+const erasedFullName = "Erased User"
+
+// erasedFields lists the user columns overwritten by an erasure
+var erasedFields = []string{"email", "full_name", "phone"}
+
+// erasureScrubs remove personal data about the erased users ($1) from tables other than users.
+// Every table that can hold personal data must have an entry here.
+var erasureScrubs = []string{
+	"UPDATE user_history SET details = jsonb_build_object('scrubbed', TRUE) WHERE user_id = ANY($1)",
+}
+
+// EraseUser irreversibly replaces the personal data of a user, and of any accounts merged into them,
+// with placeholders while keeping their IDs
+func (r *postgresUserRepository) EraseUser(ctx context.Context, id uuid.UUID) (*models.ErasureResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var user models.User
+	lockQuery := "SELECT " + userColumns + " FROM users WHERE id = $1 FOR UPDATE"
+	if err := tx.GetContext(ctx, &user, lockQuery, id); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	if user.ErasedAt != nil {
+		return nil, fmt.Errorf("user has already been erased")
+	}
+
+	ids, err := mergedAccountIDs(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	idArray := pq.Array(uuidStrings(ids))
+
+	eraseQuery := `
+		UPDATE users
+		SET email = 'erased+' || id::text || '@invalid', full_name = $2, phone = NULL,
+			is_active = FALSE, erased_at = $3, updated_at = $3
+		WHERE id = ANY($1)
+	`
+	if _, err := tx.ExecContext(ctx, eraseQuery, idArray, erasedFullName, now); err != nil {
+		return nil, fmt.Errorf("failed to erase user: %w", err)
+	}
+
+	var scrubbed int64
+	for _, statement := range erasureScrubs {
+		result, err := tx.ExecContext(ctx, statement, idArray)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scrub personal data: %w", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		scrubbed += rowsAffected
+	}
+
+	// Stored idempotent responses may repeat the erased data; they are only kept for replays, so drop them
+	purgeResponsesQuery := `
+		DELETE FROM idempotency_keys
+		WHERE EXISTS (SELECT 1 FROM unnest($1::text[]) AS erased(id) WHERE position(convert_to(erased.id, 'UTF8') IN body) > 0)
+	`
+	if _, err := tx.ExecContext(ctx, purgeResponsesQuery, idArray); err != nil {
+		return nil, fmt.Errorf("failed to purge idempotent responses: %w", err)
+	}
+
+	erasure := &models.ErasureResult{
+		UserID:                 id,
+		ErasedUserIDs:          ids,
+		ErasedFields:           erasedFields,
+		HistoryEntriesScrubbed: scrubbed,
+		ErasedAt:               now,
+	}
+
+	if err := recordHistory(ctx, tx, id, historyActionErased, erasure); err != nil {
+		return nil, err
+	}
+	if err := recordEvent(ctx, tx, id, eventUserErased, erasure); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit erasure: %w", err)
+	}
+
+	return erasure, nil
+}
+
+// mergedAccountIDs returns the user's ID together with the IDs of every account merged into them,
+// directly or through other merged accounts; they all belong to the same person
+func mergedAccountIDs(ctx context.Context, q sqlx.QueryerContext, id uuid.UUID) ([]uuid.UUID, error) {
+	mergedQuery := `
+		WITH RECURSIVE merged AS (
+			SELECT id FROM users WHERE id = $1
+			UNION
+			SELECT u.id FROM users u JOIN merged m ON u.merged_into = m.id
+		)
+		SELECT id FROM merged
+	`
+	var ids []uuid.UUID
+	if err := sqlx.SelectContext(ctx, q, &ids, mergedQuery, id); err != nil {
+		return nil, fmt.Errorf("failed to get merged accounts: %w", err)
+	}
+	return ids, nil
+}
+
+// uuidStrings converts UUIDs to strings for use with pq.Array
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, len(ids))
+	for i, id := range ids {
+		result[i] = id.String()
+	}
+	return result
+}
@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestEraseUser_Success tests erasing a user together with an account merged into them
+func TestEraseUser_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	mergedID := uuid.New()
+	expectedTime := time.Now()
+	phone := "1234567890"
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "merged_into", "erased_at", "created_at", "updated_at"}
+	ids := pq.Array([]string{userID.String(), mergedID.String()})
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(userID, "jane@example.com", "Jane Doe", &phone, "staff", true, nil, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`WITH RECURSIVE merged AS .* SELECT id FROM merged`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID).AddRow(mergedID))
+	mock.ExpectExec(`UPDATE users SET email = 'erased\+' \|\| id::text \|\| '@invalid', full_name = \$2, phone = NULL, is_active = FALSE, erased_at = \$3, updated_at = \$3 WHERE id = ANY\(\$1\)`).
+		WithArgs(ids, erasedFullName, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE user_history SET details = jsonb_build_object\('scrubbed', TRUE\) WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE EXISTS \(SELECT 1 FROM unnest\(\$1::text\[\]\) AS erased\(id\) WHERE position\(convert_to\(erased.id, 'UTF8'\) IN body\) > 0\)`).
+		WithArgs(ids).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO user_history`).
+		WithArgs(userID, "erased", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO user_events \(user_id, event_type, payload\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs(userID, "user.erased", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	result, err := repo.EraseUser(ctx, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, result.UserID)
+	assert.ElementsMatch(t, []uuid.UUID{userID, mergedID}, result.ErasedUserIDs)
+	assert.Equal(t, []string{"email", "full_name", "phone"}, result.ErasedFields)
+	assert.Equal(t, int64(3), result.HistoryEntriesScrubbed)
+	assert.False(t, result.ErasedAt.IsZero())
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestEraseUser_UserNotFound tests error when the user doesn't exist
+func TestEraseUser_UserNotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.EraseUser(ctx, userID)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "user not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestEraseUser_AlreadyErased tests that erasing twice is rejected
+func TestEraseUser_AlreadyErased(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	expectedTime := time.Now()
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "merged_into", "erased_at", "created_at", "updated_at"}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(userID, "erased+x@invalid", erasedFullName, nil, "staff", false, nil, nil, expectedTime, expectedTime, expectedTime))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.EraseUser(ctx, userID)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "already been erased")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Event types written to the user_events outbox
+const (
+	eventUserErased = "user.erased"
+)
+
+// recordEvent writes an event to the user_events outbox for asynchronous publishing
+func recordEvent(ctx context.Context, exec sqlx.ExecerContext, userID uuid.UUID, eventType string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode event payload: %w", err)
+	}
+
+	query := "INSERT INTO user_events (user_id, event_type, payload) VALUES ($1, $2, $3)"
+	if _, err := exec.ExecContext(ctx, query, userID, eventType, data); err != nil {
+		return fmt.Errorf("failed to record event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// personalDataExporter selects the rows one table holds about a person, one JSON object per row.
+// $1 is a text array of the user's ID and the IDs of the accounts merged into them.
+type personalDataExporter struct {
+	Table string
+	Query string
+}
+
+// personalDataExporters lists every table included in a subject access export.
+// Every table created by a migration must have an entry here.
+var personalDataExporters = []personalDataExporter{
+	{
+		Table: "users",
+		Query: "SELECT row_to_json(t) FROM (SELECT " + userColumns + " FROM users WHERE id = ANY($1) ORDER BY created_at ASC, id ASC) t",
+	},
+	{
+		Table: "user_history",
+		Query: "SELECT row_to_json(t) FROM (SELECT id, user_id, action, details, created_at FROM user_history WHERE user_id = ANY($1) ORDER BY id ASC) t",
+	},
+	{
+		Table: "user_events",
+		Query: "SELECT row_to_json(t) FROM (SELECT id, user_id, event_type, payload, created_at, published_at FROM user_events WHERE user_id = ANY($1) ORDER BY id ASC) t",
+	},
+}
+
+// tablesNotExported lists the tables left out of subject access exports. Every other table must
+// have an exporter.
+var tablesNotExported = []string{
+	"api_keys", // Credentials of service principals
+	// Short-lived stored responses, such as import reports and batch lookups, that also hold other
+	// users' data. Those mentioning a user are deleted when the user is erased.
+	"idempotency_keys",
+}
+
+// ExportUserData collects every row the service stores about a user
+func (r *postgresUserRepository) ExportUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error) {
+	exists, err := r.userExists(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	// Accounts merged into the user belong to the same person, so their rows are exported too
+	ids, err := mergedAccountIDs(ctx, r.db, id)
+	if err != nil {
+		return nil, err
+	}
+	idArray := pq.Array(uuidStrings(ids))
+
+	export := &models.UserDataExport{
+		UserID:      id,
+		GeneratedAt: time.Now(),
+		Tables:      make(map[string][]json.RawMessage, len(personalDataExporters)),
+	}
+
+	for _, exporter := range personalDataExporters {
+		var rows []string
+		if err := r.db.SelectContext(ctx, &rows, exporter.Query, idArray); err != nil {
+			return nil, fmt.Errorf("failed to export %s: %w", exporter.Table, err)
+		}
+
+		tableRows := make([]json.RawMessage, len(rows))
+		for i, row := range rows {
+			tableRows[i] = json.RawMessage(row)
+		}
+		export.Tables[exporter.Table] = tableRows
+	}
+
+	return export, nil
+}
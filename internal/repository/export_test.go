@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestPersonalDataExporters_CoverAllTables tests that every table created by a migration has an
+// exporter or is declared to be left out of exports
+func TestPersonalDataExporters_CoverAllTables(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "db", "migrations", "*.up.sql"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	registered := map[string]bool{}
+	for _, exporter := range personalDataExporters {
+		registered[exporter.Table] = true
+	}
+	for _, table := range tablesNotExported {
+		registered[table] = true
+	}
+
+	createTable := regexp.MustCompile(`(?i)CREATE TABLE\s+(?:IF NOT EXISTS\s+)?(\w+)`)
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+
+		for _, match := range createTable.FindAllStringSubmatch(string(content), -1) {
+			assert.True(t, registered[match[1]], "table %s created in %s has no personal data exporter", match[1], filepath.Base(file))
+		}
+	}
+}
+
+// TestExportUserData_Success tests collecting rows from every registered table, for the user and
+// the accounts merged into them
+func TestExportUserData_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	mergedID := uuid.New()
+	ids := pq.Array([]string{userID.String(), mergedID.String()})
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+	mock.ExpectQuery(`WITH RECURSIVE merged AS`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID).AddRow(mergedID))
+	mock.ExpectQuery(`SELECT row_to_json\(t\) FROM \(SELECT .* FROM users WHERE id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).
+			AddRow(`{"id":"` + userID.String() + `","email":"jane@example.com"}`).
+			AddRow(`{"id":"` + mergedID.String() + `","email":"merged+` + mergedID.String() + `@invalid"}`))
+	mock.ExpectQuery(`SELECT row_to_json\(t\) FROM \(SELECT id, user_id, action, details, created_at FROM user_history WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).
+			AddRow(`{"id":1,"user_id":"` + mergedID.String() + `","action":"merged_into"}`).
+			AddRow(`{"id":2,"user_id":"` + userID.String() + `","action":"merged_from"}`))
+	mock.ExpectQuery(`SELECT row_to_json\(t\) FROM \(SELECT id, user_id, event_type, payload, created_at, published_at FROM user_events WHERE user_id = ANY\(\$1\)`).
+		WithArgs(ids).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}))
+
+	ctx := context.Background()
+	result, err := repo.ExportUserData(ctx, userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, result.UserID)
+	require.Len(t, result.Tables["users"], 2)
+	assert.JSONEq(t, `{"id":"`+userID.String()+`","email":"jane@example.com"}`, string(result.Tables["users"][0]))
+	assert.Len(t, result.Tables["user_history"], 2)
+	assert.NotNil(t, result.Tables["user_events"])
+	assert.Empty(t, result.Tables["user_events"])
+	assert.NotContains(t, result.Tables, "idempotency_keys")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestExportUserData_LeavesOutStoredResponses tests that stored idempotent responses, which can
+// mention other users, are not exported
+func TestExportUserData_LeavesOutStoredResponses(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	otherID := uuid.New()
+	ids := pq.Array([]string{userID.String()})
+	emptyRows := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"row_to_json"}) }
+
+	mock.MatchExpectationsInOrder(false)
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+	mock.ExpectQuery(`WITH RECURSIVE merged AS`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+	mock.ExpectQuery(`FROM users WHERE id = ANY\(\$1\)`).WithArgs(ids).WillReturnRows(emptyRows())
+	mock.ExpectQuery(`FROM user_history WHERE user_id = ANY\(\$1\)`).WithArgs(ids).WillReturnRows(emptyRows())
+	mock.ExpectQuery(`FROM user_events WHERE user_id = ANY\(\$1\)`).WithArgs(ids).WillReturnRows(emptyRows())
+	// A batch lookup of both users, which an exporter of idempotency_keys would match
+	mock.ExpectQuery(`idempotency_keys`).
+		WillReturnRows(sqlmock.NewRows([]string{"row_to_json"}).
+			AddRow(`{"key":"key-1","body":{"data":[{"id":"` + userID.String() + `"},{"id":"` + otherID.String() + `","email":"other@example.com"}]}}`))
+
+	result, err := repo.ExportUserData(context.Background(), userID)
+	require.NoError(t, err)
+
+	exported, err := json.Marshal(result)
+	require.NoError(t, err)
+	assert.NotContains(t, string(exported), otherID.String())
+	assert.NotContains(t, string(exported), "other@example.com")
+}
+
+// TestExportUserData_UserNotFound tests error when the user doesn't exist
+func TestExportUserData_UserNotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(userID).
+		WillReturnError(sql.ErrNoRows)
+
+	ctx := context.Background()
+	result, err := repo.ExportUserData(ctx, userID)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "user not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
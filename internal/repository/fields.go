@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+)
+
+// selectColumns returns the column list for a sparse fieldset, or userColumns when no fields are requested.
+// Every field must be one of userColumns.
+func selectColumns(fields []string) (string, error) {
+	if len(fields) == 0 {
+		return userColumns, nil
+	}
+
+	allowed := map[string]bool{}
+	for _, column := range strings.Split(userColumns, ", ") {
+		allowed[column] = true
+	}
+
+	for _, field := range fields {
+		if !allowed[field] {
+			return "", fmt.Errorf("invalid field: %q", field)
+		}
+	}
+
+	return strings.Join(fields, ", "), nil
+}
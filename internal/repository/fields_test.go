@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserByID_SparseFields tests that only the requested columns are selected
+func TestGetUserByID_SparseFields(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT id, full_name, role FROM users WHERE id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name", "role"}).
+			AddRow(userID, "Sparse User", "staff"))
+
+	result, err := repo.GetUserByID(context.Background(), userID, []string{"id", "full_name", "role"})
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, result.ID)
+	assert.Equal(t, "Sparse User", result.FullName)
+	assert.Equal(t, "staff", result.Role)
+	assert.Empty(t, result.Email)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetUserByID_InvalidField tests that unknown fields never reach the database
+func TestGetUserByID_InvalidField(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	result, err := repo.GetUserByID(context.Background(), uuid.New(), []string{"id", "password; DROP TABLE users"})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "invalid field")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAllUsers_SparseFields tests that list queries select only the requested columns
+func TestGetAllUsers_SparseFields(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(`SELECT id, email FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
+		WithArgs(10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "email"}).AddRow(userID, "sparse@example.com"))
+
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+	result, err := repo.GetAllUsers(context.Background(), nil, nil, pagination, []string{"id", "email"})
+
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, userID, result.Data[0].ID)
+	assert.Equal(t, "sparse@example.com", result.Data[0].Email)
+	assert.Equal(t, 1, result.Pagination.Total)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAllUsers_InvalidField tests that unknown fields are rejected before querying
+func TestGetAllUsers_InvalidField(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+	result, err := repo.GetAllUsers(context.Background(), nil, nil, pagination, []string{"nickname"})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "invalid field")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/lib/pq"
+)
+
+// filterColumns lists the columns FilterCondition may refer to, and whether each one is nullable
+var filterColumns = map[string]bool{
+	"id":          false,
+	"email":       false,
+	"full_name":   false,
+	"phone":       true,
+	"role":        false,
+	"is_active":   false,
+	"manager_id":  true,
+	"merged_into": true,
+}
+
+// buildCondition renders a FilterCondition as SQL, numbering its placeholders after argCount.
+// It returns the SQL and the arguments it adds.
+func buildCondition(condition models.FilterCondition, argCount int) (string, []interface{}, error) {
+	nullable, ok := filterColumns[condition.Field]
+	if !ok {
+		return "", nil, fmt.Errorf("invalid filter field: %q", condition.Field)
+	}
+	column := condition.Field
+
+	if condition.IsNull {
+		if !nullable {
+			return "", nil, fmt.Errorf("invalid filter: %s cannot be null", column)
+		}
+		if condition.Negate {
+			return column + " IS NOT NULL", nil, nil
+		}
+		return column + " IS NULL", nil, nil
+	}
+
+	if len(condition.Values) == 0 {
+		return "", nil, fmt.Errorf("invalid filter: no values for %s", column)
+	}
+
+	var clause string
+	var arg interface{}
+	switch {
+	case len(condition.Values) == 1 && condition.Negate:
+		clause, arg = fmt.Sprintf("%s <> $%d", column, argCount+1), condition.Values[0]
+	case len(condition.Values) == 1:
+		clause, arg = fmt.Sprintf("%s = $%d", column, argCount+1), condition.Values[0]
+	case condition.Negate:
+		clause, arg = fmt.Sprintf("%s <> ALL($%d)", column, argCount+1), pq.Array(condition.Values)
+	default:
+		clause, arg = fmt.Sprintf("%s = ANY($%d)", column, argCount+1), pq.Array(condition.Values)
+	}
+
+	// NULL is not equal to any excluded value, so a negated match keeps it
+	if condition.Negate && nullable {
+		clause = fmt.Sprintf("(%s IS NULL OR %s)", column, clause)
+	}
+
+	return clause, []interface{}{arg}, nil
+}
+
+// buildAnyOf renders an OR group of conditions, numbering its placeholders after argCount
+func buildAnyOf(group []models.FilterCondition, argCount int) (string, []interface{}, error) {
+	var parts []string
+	var args []interface{}
+	for _, condition := range group {
+		part, partArgs, err := buildCondition(condition, argCount+len(args))
+		if err != nil {
+			return "", nil, err
+		}
+		parts = append(parts, part)
+		args = append(args, partArgs...)
+	}
+	if len(parts) == 0 {
+		return "", nil, fmt.Errorf("invalid filter: empty OR group")
+	}
+	return "(" + strings.Join(parts, " OR ") + ")", args, nil
+}
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/filter"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAllUsers_WithConditions tests list, negated and null conditions
+func TestGetAllUsers_WithConditions(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	excludedID := uuid.New()
+	expectedTime := time.Now()
+
+	where := `WHERE role = ANY\(\$1\) AND id <> \$2 AND phone IS NULL`
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users `+where).
+		WithArgs(`{"admin","staff"}`, excludedID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
+	mock.ExpectQuery(`SELECT `+userColumns+` FROM users `+where+` ORDER BY created_at ASC, id ASC LIMIT \$3 OFFSET \$4`).
+		WithArgs(`{"admin","staff"}`, excludedID.String(), 10, 0).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(userID, "staff@example.com", "Staff User", nil, "staff", true, expectedTime, expectedTime))
+
+	filters := &models.FilterParams{
+		Conditions: []models.FilterCondition{
+			{Field: "role", Values: []string{"admin", "staff"}},
+			{Field: "id", Values: []string{excludedID.String()}, Negate: true},
+			{Field: "phone", IsNull: true},
+		},
+	}
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+
+	result, err := repo.GetAllUsers(context.Background(), filters, nil, pagination, nil)
+
+	require.NoError(t, err)
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, userID, result.Data[0].ID)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAllUsers_WithAnyOf tests that OR groups are combined with the other filters
+func TestGetAllUsers_WithAnyOf(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	isActive := true
+	where := `WHERE is_active = \$1 AND \(role = \$2 OR manager_id IS NOT NULL\) AND \(\(phone IS NULL OR phone <> ALL\(\$3\)\) OR is_active = \$4\)`
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users `+where).
+		WithArgs(true, "admin", `{"111","222"}`, "false").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT `+userColumns+` FROM users `+where+` ORDER BY created_at ASC, id ASC LIMIT \$5 OFFSET \$6`).
+		WithArgs(true, "admin", `{"111","222"}`, "false", 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	filters := &models.FilterParams{
+		IsActive: &isActive,
+		AnyOf: [][]models.FilterCondition{
+			{
+				{Field: "role", Values: []string{"admin"}},
+				{Field: "manager_id", IsNull: true, Negate: true},
+			},
+			{
+				{Field: "phone", Values: []string{"111", "222"}, Negate: true},
+				{Field: "is_active", Values: []string{"false"}},
+			},
+		},
+	}
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+
+	result, err := repo.GetAllUsers(context.Background(), filters, nil, pagination, nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Data)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAllUsers_InvalidCondition tests that conditions outside the whitelist never reach the database
+func TestGetAllUsers_InvalidCondition(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition models.FilterCondition
+		errMsg    string
+	}{
+		{"UnknownField", models.FilterCondition{Field: "role; DROP TABLE users", Values: []string{"x"}}, "invalid filter field"},
+		{"NullOnRequiredColumn", models.FilterCondition{Field: "email", IsNull: true}, "cannot be null"},
+		{"NoValues", models.FilterCondition{Field: "role"}, "no values"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, repo := setupMockDB(t)
+			defer db.Close()
+
+			filters := &models.FilterParams{Conditions: []models.FilterCondition{tt.condition}}
+			pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+
+			result, err := repo.GetAllUsers(context.Background(), filters, nil, pagination, nil)
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), tt.errMsg)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestGetAllUsers_WithExpression tests that filter expressions are compiled after the other filters
+func TestGetAllUsers_WithExpression(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	role := "supplier"
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	where := `WHERE role = \$1 AND \(email ILIKE \$2 OR created_at >= \$3\)`
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users `+where).
+		WithArgs(role, "%@acme.com", from).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT `+userColumns+` FROM users `+where+` ORDER BY created_at ASC, id ASC LIMIT \$4 OFFSET \$5`).
+		WithArgs(role, "%@acme.com", from, 10, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	expression, err := filter.Parse("email=like=*@acme.com,created_at=ge=2026-01-01")
+	require.NoError(t, err)
+
+	filters := &models.FilterParams{Role: &role, Expression: expression}
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+
+	result, err := repo.GetAllUsers(context.Background(), filters, nil, pagination, nil)
+
+	require.NoError(t, err)
+	assert.Empty(t, result.Data)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestGetAllUsers_InvalidExpression tests that ill-typed expressions never reach the database
+func TestGetAllUsers_InvalidExpression(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	expression, err := filter.Parse("nickname==bob")
+	require.NoError(t, err)
+
+	filters := &models.FilterParams{Expression: expression}
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+
+	result, err := repo.GetAllUsers(context.Background(), filters, nil, pagination, nil)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "unknown field")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
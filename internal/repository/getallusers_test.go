@@ -31,7 +31,7 @@ func TestGetAllUsers_Success(t *testing.T) {
 
 	// Expect data query
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(user1ID, "user1@example.com", "User One", &phone, "admin", true, expectedTime, expectedTime).
@@ -42,7 +42,7 @@ func TestGetAllUsers_Success(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -93,7 +93,7 @@ func TestGetAllUsers_WithFilters(t *testing.T) {
 
 	// Expect data query with filters
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE role = \$1 AND is_active = \$2 AND \(LOWER\(full_name\) LIKE LOWER\(\$3\) OR LOWER\(email\) LIKE LOWER\(\$3\)\) ORDER BY created_at ASC LIMIT \$4 OFFSET \$5`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE role = \$1 AND is_active = \$2 AND \(LOWER\(full_name\) LIKE LOWER\(\$3\) OR LOWER\(email\) LIKE LOWER\(\$3\)\) ORDER BY created_at ASC, id ASC LIMIT \$4 OFFSET \$5`).
 		WithArgs(role, isActive, "%"+search+"%", 10, 0).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, "john@example.com", "John Doe", nil, "admin", true, expectedTime, expectedTime))
@@ -107,7 +107,7 @@ func TestGetAllUsers_WithFilters(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -131,13 +131,13 @@ func TestGetAllUsers_WithTimeFilters(t *testing.T) {
 	createdTo := time.Now()
 
 	// Expect count query with time filters
-	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE created_at >= \$1 AND created_at <= \$2`).
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE created_at >= \$1 AND created_at < \$2`).
 		WithArgs(createdFrom, createdTo).
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
 
 	// Expect data query with time filters
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE created_at >= \$1 AND created_at <= \$2 ORDER BY created_at ASC LIMIT \$3 OFFSET \$4`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE created_at >= \$1 AND created_at < \$2 ORDER BY created_at ASC, id ASC LIMIT \$3 OFFSET \$4`).
 		WithArgs(createdFrom, createdTo, 10, 0).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, "recent@example.com", "Recent User", nil, "staff", true, expectedTime, expectedTime))
@@ -150,7 +150,7 @@ func TestGetAllUsers_WithTimeFilters(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -169,10 +169,10 @@ func TestGetAllUsers_WithSorting(t *testing.T) {
 		sortOrder string
 		expectedQuery string
 	}{
-		{"SortByEmailDesc", "email", "desc", "ORDER BY email DESC"},
-		{"SortByNameAsc", "full_name", "asc", "ORDER BY full_name ASC"},
-		{"SortByRoleDesc", "role", "desc", "ORDER BY role DESC"},
-		{"SortByCreatedAtAsc", "created_at", "asc", "ORDER BY created_at ASC"},
+		{"SortByEmailDesc", "email", "desc", "ORDER BY email DESC, id ASC"},
+		{"SortByNameAsc", "full_name", "asc", "ORDER BY full_name ASC, id ASC"},
+		{"SortByRoleDesc", "role", "desc", "ORDER BY role DESC, id ASC"},
+		{"SortByCreatedAtAsc", "created_at", "asc", "ORDER BY created_at ASC, id ASC"},
 	}
 
 	for _, tc := range testCases {
@@ -189,7 +189,7 @@ func TestGetAllUsers_WithSorting(t *testing.T) {
 
 			// Expect data query with specific sorting
 			columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-			mock.ExpectQuery(fmt.Sprintf(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users %s LIMIT \$1 OFFSET \$2`, tc.expectedQuery)).
+			mock.ExpectQuery(fmt.Sprintf(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users %s LIMIT \$1 OFFSET \$2`, tc.expectedQuery)).
 				WithArgs(10, 0).
 				WillReturnRows(sqlmock.NewRows(columns).
 					AddRow(userID, "test@example.com", "Test User", nil, "admin", true, expectedTime, expectedTime))
@@ -199,7 +199,7 @@ func TestGetAllUsers_WithSorting(t *testing.T) {
 			pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 			ctx := context.Background()
-			result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+			result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 			require.NoError(t, err)
 			assert.NotNil(t, result)
@@ -226,7 +226,7 @@ func TestGetAllUsers_WithPagination(t *testing.T) {
 
 	// Expect data query for page 2 (offset 5, limit 5)
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 		WithArgs(5, 5).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, "page2@example.com", "Page Two User", nil, "staff", true, expectedTime, expectedTime))
@@ -236,7 +236,7 @@ func TestGetAllUsers_WithPagination(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 2, PageSize: 5, Offset: 5}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -265,7 +265,7 @@ func TestGetAllUsers_EmptyResult(t *testing.T) {
 
 	// Expect data query returning empty set
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnRows(sqlmock.NewRows(columns))
 
@@ -274,7 +274,7 @@ func TestGetAllUsers_EmptyResult(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -302,7 +302,7 @@ func TestGetAllUsers_CountError(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -322,7 +322,7 @@ func TestGetAllUsers_DataQueryError(t *testing.T) {
 		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
 
 	// Expect data query to fail
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnError(sql.ErrConnDone)
 
@@ -331,7 +331,7 @@ func TestGetAllUsers_DataQueryError(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -352,7 +352,7 @@ func TestGetAllUsers_ScanError(t *testing.T) {
 
 	// Expect data query with invalid data that will cause scanning to fail
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow("invalid-uuid", "scan@example.com", "Scan User", nil, "admin", true, "invalid-time", "invalid-time"))
@@ -362,7 +362,7 @@ func TestGetAllUsers_ScanError(t *testing.T) {
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -386,13 +386,13 @@ func TestGetAllUsers_NilParameters(t *testing.T) {
 
 	// Expect data query with default sorting (nil sort params)
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 		WithArgs(10, 0).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, "nil@example.com", "Nil Test User", nil, "admin", true, expectedTime, expectedTime))
 
 	ctx := context.Background()
-	result, err := repo.GetAllUsers(ctx, nil, nil, &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0})
+	result, err := repo.GetAllUsers(ctx, nil, nil, &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -420,7 +420,7 @@ func TestGetAllUsers_ContextCancelled(t *testing.T) {
 	sort := &models.SortParams{Field: "created_at", Order: "asc"}
 	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
 
-	result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -456,7 +456,7 @@ func TestGetAllUsers_EdgeCasePagination(t *testing.T) {
 
 			// Expect data query (may return empty for zero total)
 			columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-			mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users ORDER BY created_at ASC LIMIT \$1 OFFSET \$2`).
+			mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users ORDER BY created_at ASC, id ASC LIMIT \$1 OFFSET \$2`).
 				WithArgs(tc.pageSize, (tc.page-1)*tc.pageSize).
 				WillReturnRows(sqlmock.NewRows(columns))
 
@@ -469,7 +469,7 @@ func TestGetAllUsers_EdgeCasePagination(t *testing.T) {
 			}
 
 			ctx := context.Background()
-			result, err := repo.GetAllUsers(ctx, filters, sort, pagination)
+			result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
 
 			require.NoError(t, err)
 			assert.NotNil(t, result)
@@ -23,13 +23,13 @@ func TestGetUserByID_Success(t *testing.T) {
 
 	// Expect get user query
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, "get@example.com", "Get User", &phone, "admin", true, expectedTime, expectedTime))
 
 	ctx := context.Background()
-	result, err := repo.GetUserByID(ctx, userID)
+	result, err := repo.GetUserByID(ctx, userID, nil)
 
 	require.NoError(t, err)
 	assert.NotNil(t, result)
@@ -54,12 +54,12 @@ func TestGetUserByID_UserNotFound(t *testing.T) {
 	userID := uuid.New()
 
 	// Expect get user query to fail
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnError(sql.ErrNoRows)
 
 	ctx := context.Background()
-	result, err := repo.GetUserByID(ctx, userID)
+	result, err := repo.GetUserByID(ctx, userID, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -77,12 +77,12 @@ func TestGetUserByID_DatabaseError(t *testing.T) {
 	userID := uuid.New()
 
 	// Expect get user query to fail with database error
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnError(sql.ErrConnDone)
 
 	ctx := context.Background()
-	result, err := repo.GetUserByID(ctx, userID)
+	result, err := repo.GetUserByID(ctx, userID, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -101,13 +101,13 @@ func TestGetUserByID_ScanError(t *testing.T) {
 
 	// Return invalid data that will cause scanning to fail
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow("invalid-uuid", "scan@example.com", "Scan User", nil, "admin", true, "invalid-time", "invalid-time"))
 
 	ctx := context.Background()
-	result, err := repo.GetUserByID(ctx, userID)
+	result, err := repo.GetUserByID(ctx, userID, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -129,11 +129,11 @@ func TestGetUserByID_ContextCancelled(t *testing.T) {
 	cancel() // Cancel immediately
 
 	// The query should not be executed due to cancelled context
-	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 		WithArgs(userID).
 		WillReturnError(context.Canceled)
 
-	result, err := repo.GetUserByID(ctx, userID)
+	result, err := repo.GetUserByID(ctx, userID, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -173,13 +173,13 @@ func TestGetUserByID_AllUserTypes(t *testing.T) {
 
 			// Expect get user query
 			columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-			mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = \$1`).
+			mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE id = \$1`).
 				WithArgs(userID).
 				WillReturnRows(sqlmock.NewRows(columns).
 					AddRow(userID, email, fullName, phone, tc.role, tc.isActive, expectedTime, expectedTime))
 
 			ctx := context.Background()
-			result, err := repo.GetUserByID(ctx, userID)
+			result, err := repo.GetUserByID(ctx, userID, nil)
 
 			require.NoError(t, err)
 			assert.NotNil(t, result)
@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// maxHierarchyDepth bounds every recursive walk of the management hierarchy
+const maxHierarchyDepth = 20
+
+// GetDirectReports retrieves the users whose manager is the given user
+func (r *postgresUserRepository) GetDirectReports(ctx context.Context, managerID uuid.UUID) ([]models.User, error) {
+	exists, err := r.userExists(ctx, managerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	query := "SELECT " + userColumns + " FROM users WHERE manager_id = $1 ORDER BY full_name ASC, id ASC"
+
+	reports := []models.User{}
+	if err := r.db.SelectContext(ctx, &reports, query, managerID); err != nil {
+		return nil, fmt.Errorf("failed to get direct reports: %w", err)
+	}
+
+	return reports, nil
+}
+
+// GetSubordinates retrieves everyone below the given user in the hierarchy, up to maxDepth levels down
+func (r *postgresUserRepository) GetSubordinates(ctx context.Context, managerID uuid.UUID, maxDepth int) ([]models.UserHierarchyEntry, error) {
+	if maxDepth < 1 || maxDepth > maxHierarchyDepth {
+		maxDepth = maxHierarchyDepth
+	}
+
+	exists, err := r.userExists(ctx, managerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	query := fmt.Sprintf(`
+		WITH RECURSIVE subtree AS (
+			SELECT %s, 1 AS depth FROM users WHERE manager_id = $1
+			UNION ALL
+			SELECT %s, s.depth + 1 FROM users u JOIN subtree s ON u.manager_id = s.id WHERE s.depth < $2
+		)
+		SELECT %s, depth FROM subtree
+		ORDER BY depth ASC, full_name ASC, id ASC
+	`, userColumns, qualifiedUserColumns("u"), userColumns)
+
+	entries := []models.UserHierarchyEntry{}
+	if err := r.db.SelectContext(ctx, &entries, query, managerID, maxDepth); err != nil {
+		return nil, fmt.Errorf("failed to get subordinates: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetManagementChain retrieves the managers above the given user, nearest first, up to the root
+func (r *postgresUserRepository) GetManagementChain(ctx context.Context, id uuid.UUID) ([]models.UserHierarchyEntry, error) {
+	exists, err := r.userExists(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check user: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	query := fmt.Sprintf(`
+		WITH RECURSIVE chain AS (
+			SELECT %s, 0 AS depth FROM users WHERE id = $1
+			UNION ALL
+			SELECT %s, c.depth + 1 FROM users u JOIN chain c ON u.id = c.manager_id WHERE c.depth < $2
+		)
+		SELECT %s, depth FROM chain
+		WHERE depth > 0
+		ORDER BY depth ASC
+	`, userColumns, qualifiedUserColumns("u"), userColumns)
+
+	chain := []models.UserHierarchyEntry{}
+	if err := r.db.SelectContext(ctx, &chain, query, id, maxHierarchyDepth); err != nil {
+		return nil, fmt.Errorf("failed to get management chain: %w", err)
+	}
+
+	return chain, nil
+}
+
+// hierarchyLockKey identifies the transaction-level advisory lock held while manager assignments
+// are checked and written
+const hierarchyLockKey = 0x75736572
+
+// checkManagerAssignment verifies that managerID exists and that making it the manager
+// of userID would not introduce a cycle into the hierarchy. Two assignments can each be valid
+// alone and close a loop together, and the check reads a whole chain rather than rows it could
+// lock, so it takes a lock that serializes manager assignments until tx ends.
+func (r *postgresUserRepository) checkManagerAssignment(ctx context.Context, tx *sqlx.Tx, userID, managerID uuid.UUID) error {
+	if userID == managerID {
+		return fmt.Errorf("manager assignment would create a cycle")
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", hierarchyLockKey); err != nil {
+		return fmt.Errorf("failed to lock hierarchy: %w", err)
+	}
+
+	// Walk up from the proposed manager; UNION (not UNION ALL) guarantees termination
+	// even if the existing data already contains a cycle
+	query := `
+		WITH RECURSIVE chain AS (
+			SELECT id, manager_id FROM users WHERE id = $1
+			UNION
+			SELECT u.id, u.manager_id FROM users u JOIN chain c ON u.id = c.manager_id
+		)
+		SELECT COUNT(*) AS found, COALESCE(BOOL_OR(id = $2), FALSE) AS cycle FROM chain
+	`
+
+	var result struct {
+		Found int  `db:"found"`
+		Cycle bool `db:"cycle"`
+	}
+	if err := tx.GetContext(ctx, &result, query, managerID, userID); err != nil {
+		return fmt.Errorf("failed to check manager: %w", err)
+	}
+	if result.Found == 0 {
+		return fmt.Errorf("manager not found")
+	}
+	if result.Cycle {
+		return fmt.Errorf("manager assignment would create a cycle")
+	}
+
+	return nil
+}
+
+// userExists reports whether a user with the given ID exists
+func (r *postgresUserRepository) userExists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var found uuid.UUID
+	err := r.db.GetContext(ctx, &found, "SELECT id FROM users WHERE id = $1", id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// qualifiedUserColumns returns userColumns with every column prefixed by the given table alias
+func qualifiedUserColumns(alias string) string {
+	columns := strings.Split(userColumns, ", ")
+	for i, column := range columns {
+		columns[i] = alias + "." + column
+	}
+	return strings.Join(columns, ", ")
+}
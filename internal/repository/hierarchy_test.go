@@ -0,0 +1,314 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetDirectReports_Success tests retrieval of a manager's direct reports
+func TestGetDirectReports_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	managerID := uuid.New()
+	reportID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(managerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(managerID))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at"}
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE manager_id = \$1 ORDER BY full_name ASC, id ASC`).
+		WithArgs(managerID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(reportID, "report@example.com", "Report User", nil, "staff", true, managerID, expectedTime, expectedTime))
+
+	ctx := context.Background()
+	result, err := repo.GetDirectReports(ctx, managerID)
+
+	require.NoError(t, err)
+	require.Len(t, result, 1)
+	assert.Equal(t, reportID, result[0].ID)
+	require.NotNil(t, result[0].ManagerID)
+	assert.Equal(t, managerID, *result[0].ManagerID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetDirectReports_UserNotFound tests error when the manager doesn't exist
+func TestGetDirectReports_UserNotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	managerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(managerID).
+		WillReturnError(sql.ErrNoRows)
+
+	ctx := context.Background()
+	result, err := repo.GetDirectReports(ctx, managerID)
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "user not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetSubordinates_Success tests recursive subtree retrieval with a depth limit
+func TestGetSubordinates_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	managerID := uuid.New()
+	directID := uuid.New()
+	indirectID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(managerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(managerID))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at", "depth"}
+	mock.ExpectQuery(`WITH RECURSIVE subtree AS \(.*WHERE manager_id = \$1 UNION ALL .*JOIN subtree s ON u.manager_id = s.id WHERE s.depth < \$2 \) SELECT .*, depth FROM subtree ORDER BY depth ASC`).
+		WithArgs(managerID, 2).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(directID, "direct@example.com", "Direct Report", nil, "staff", true, managerID, expectedTime, expectedTime, 1).
+			AddRow(indirectID, "indirect@example.com", "Indirect Report", nil, "supplier", true, directID, expectedTime, expectedTime, 2))
+
+	ctx := context.Background()
+	result, err := repo.GetSubordinates(ctx, managerID, 2)
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, directID, result[0].ID)
+	assert.Equal(t, 1, result[0].Depth)
+	assert.Equal(t, indirectID, result[1].ID)
+	assert.Equal(t, 2, result[1].Depth)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetSubordinates_DepthClamped tests that out-of-range depths fall back to the maximum
+func TestGetSubordinates_DepthClamped(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	managerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(managerID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(managerID))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at", "depth"}
+	mock.ExpectQuery(`WITH RECURSIVE subtree AS`).
+		WithArgs(managerID, maxHierarchyDepth).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	ctx := context.Background()
+	result, err := repo.GetSubordinates(ctx, managerID, 1000)
+
+	require.NoError(t, err)
+	assert.Empty(t, result)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetManagementChain_Success tests walking the chain of managers up to the root
+func TestGetManagementChain_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	managerID := uuid.New()
+	rootID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at", "depth"}
+	mock.ExpectQuery(`WITH RECURSIVE chain AS \(.*WHERE id = \$1 UNION ALL .*JOIN chain c ON u.id = c.manager_id WHERE c.depth < \$2 \) SELECT .*, depth FROM chain WHERE depth > 0 ORDER BY depth ASC`).
+		WithArgs(userID, maxHierarchyDepth).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(managerID, "manager@example.com", "Manager", nil, "staff", true, rootID, expectedTime, expectedTime, 1).
+			AddRow(rootID, "root@example.com", "Root", nil, "admin", true, nil, expectedTime, expectedTime, 2))
+
+	ctx := context.Background()
+	result, err := repo.GetManagementChain(ctx, userID)
+
+	require.NoError(t, err)
+	require.Len(t, result, 2)
+	assert.Equal(t, managerID, result[0].ID)
+	assert.Equal(t, rootID, result[1].ID)
+	assert.Nil(t, result[1].ManagerID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestUpdateUser_ManagerCycle tests that assigning a subordinate as manager is rejected
+func TestUpdateUser_ManagerCycle(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	subordinateID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WITH RECURSIVE chain AS .* SELECT COUNT\(\*\) AS found, COALESCE\(BOOL_OR\(id = \$2\), FALSE\) AS cycle FROM chain`).
+		WithArgs(subordinateID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(3, true))
+
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.UpdateUser(ctx, userID, &models.UpdateUserRequest{ManagerID: &subordinateID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "would create a cycle")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestUpdateUser_ManagerSelf tests that a user cannot become their own manager
+func TestUpdateUser_ManagerSelf(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.UpdateUser(ctx, userID, &models.UpdateUserRequest{ManagerID: &userID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "would create a cycle")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestUpdateUser_ManagerSuccess tests assigning a valid manager
+func TestUpdateUser_ManagerSuccess(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	managerID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+		WithArgs(managerID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(1, false))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at"}
+	mock.ExpectQuery(`UPDATE users SET manager_id = \$1, updated_at = NOW\(\) WHERE id = \$2 RETURNING`).
+		WithArgs(managerID, userID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(userID, "user@example.com", "User", nil, "staff", true, managerID, expectedTime, expectedTime))
+
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	result, err := repo.UpdateUser(ctx, userID, &models.UpdateUserRequest{ManagerID: &managerID})
+
+	require.NoError(t, err)
+	require.NotNil(t, result.ManagerID)
+	assert.Equal(t, managerID, *result.ManagerID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestCreateUser_ManagerNotFound tests that creating a user under a missing manager fails
+func TestCreateUser_ManagerNotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	managerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+		WithArgs(managerID).
+		WillReturnError(sql.ErrNoRows)
+
+	ctx := context.Background()
+	result, err := repo.CreateUser(ctx, &models.User{
+		Email:     "new@example.com",
+		FullName:  "New User",
+		Role:      "staff",
+		ManagerID: &managerID,
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "manager not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetAllUsers_UnderManagerFilter tests filtering by everyone under a manager
+func TestGetAllUsers_UnderManagerFilter(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	managerID := uuid.New()
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE id IN \( WITH RECURSIVE subtree AS \(.*manager_id = \$1.*\) SELECT id FROM subtree \)`).
+		WithArgs(managerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at"}
+	mock.ExpectQuery(`SELECT .* FROM users WHERE id IN \( WITH RECURSIVE subtree AS .* ORDER BY created_at ASC, id ASC LIMIT \$2 OFFSET \$3`).
+		WithArgs(managerID, 10, 0).
+		WillReturnRows(sqlmock.NewRows(columns))
+
+	filters := &models.FilterParams{UnderManager: &managerID}
+	sort := &models.SortParams{Field: "created_at", Order: "asc"}
+	pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+
+	ctx := context.Background()
+	result, err := repo.GetAllUsers(ctx, filters, sort, pagination, nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, result.Pagination.Total)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
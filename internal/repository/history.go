@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// History actions recorded in user_history
+const (
+	historyActionMergedInto = "merged_into"
+	historyActionMergedFrom = "merged_from"
+	historyActionErased     = "erased"
+)
+
+// recordHistory appends an entry to a user's history
+func recordHistory(ctx context.Context, exec sqlx.ExecerContext, userID uuid.UUID, action string, details interface{}) error {
+	payload, err := json.Marshal(details)
+	if err != nil {
+		return fmt.Errorf("failed to encode history details: %w", err)
+	}
+
+	query := "INSERT INTO user_history (user_id, action, details) VALUES ($1, $2, $3)"
+	if _, err := exec.ExecContext(ctx, query, userID, action, payload); err != nil {
+		return fmt.Errorf("failed to record history: %w", err)
+	}
+
+	return nil
+}
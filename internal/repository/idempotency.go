@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/jmoiron/sqlx"
+)
+
+// IdempotencyStore records requests made with an Idempotency-Key and their responses
+type IdempotencyStore interface {
+	// Begin claims key for a request with the given fingerprint for ttl. If the key is already
+	// claimed and has not expired, it returns the existing record and false instead.
+	Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, bool, error)
+	// Complete stores the response of the request that claimed key
+	Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error
+	// Release gives up a claim without storing a response, so the request can be retried
+	Release(ctx context.Context, key string) error
+	// PurgeExpired deletes expired records, returning how many were removed
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// postgresIdempotencyStore implements IdempotencyStore for PostgreSQL
+type postgresIdempotencyStore struct {
+	db *sqlx.DB
+}
+
+// NewPostgresIdempotencyStore creates a new instance of postgresIdempotencyStore
+func NewPostgresIdempotencyStore(db *sqlx.DB) IdempotencyStore {
+	return &postgresIdempotencyStore{db: db}
+}
+
+// Begin claims key, taking over an expired record if there is one
+func (s *postgresIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, ttl time.Duration) (*models.IdempotencyRecord, bool, error) {
+	claimQuery := `
+		INSERT INTO idempotency_keys (key, fingerprint, expires_at)
+		VALUES ($1, $2, NOW() + $3 * INTERVAL '1 second')
+		ON CONFLICT (key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL, body = NULL,
+				created_at = NOW(), expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= NOW()
+		RETURNING key
+	`
+	// The existing record can be released between the two queries, so try to claim it once more
+	for attempt := 0; attempt < 2; attempt++ {
+		var claimed string
+		err := s.db.GetContext(ctx, &claimed, claimQuery, key, fingerprint, ttl.Seconds())
+		if err == nil {
+			return nil, true, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to claim idempotency key: %w", err)
+		}
+
+		var record models.IdempotencyRecord
+		selectQuery := "SELECT key, fingerprint, status_code, content_type, body, expires_at FROM idempotency_keys WHERE key = $1"
+		err = s.db.GetContext(ctx, &record, selectQuery, key)
+		if err == nil {
+			return &record, false, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+	}
+
+	return nil, false, fmt.Errorf("failed to claim idempotency key: %q is contended", key)
+}
+
+// Complete stores the response for key
+func (s *postgresIdempotencyStore) Complete(ctx context.Context, key string, statusCode int, contentType string, body []byte) error {
+	query := "UPDATE idempotency_keys SET status_code = $1, content_type = $2, body = $3 WHERE key = $4"
+	if _, err := s.db.ExecContext(ctx, query, statusCode, contentType, body, key); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release deletes the claim on key if no response was stored for it
+func (s *postgresIdempotencyStore) Release(ctx context.Context, key string) error {
+	query := "DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL"
+	if _, err := s.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes every expired record
+func (s *postgresIdempotencyStore) PurgeExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
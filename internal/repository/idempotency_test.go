@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	claimIdempotencyKeyQuery  = `INSERT INTO idempotency_keys \(key, fingerprint, expires_at\)\s+VALUES \(\$1, \$2, NOW\(\) \+ \$3 \* INTERVAL '1 second'\)\s+ON CONFLICT \(key\) DO UPDATE.*WHERE idempotency_keys.expires_at <= NOW\(\)\s+RETURNING key`
+	selectIdempotencyKeyQuery = `SELECT key, fingerprint, status_code, content_type, body, expires_at FROM idempotency_keys WHERE key = \$1`
+)
+
+func setupMockIdempotencyStore(t *testing.T) (*sql.DB, sqlmock.Sqlmock, IdempotencyStore) {
+	db, mock, err := sqlmock.New()
+	require.NoError(t, err)
+
+	return db, mock, NewPostgresIdempotencyStore(sqlx.NewDb(db, "postgres"))
+}
+
+// TestIdempotencyBegin_Claimed tests that an unused key is claimed for the ttl
+func TestIdempotencyBegin_Claimed(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	mock.ExpectQuery(claimIdempotencyKeyQuery).
+		WithArgs("key-1", "abc", float64(86400)).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("key-1"))
+
+	record, claimed, err := store.Begin(context.Background(), "key-1", "abc", 24*time.Hour)
+
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.Nil(t, record)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestIdempotencyBegin_Existing tests that a key in use returns its stored record
+func TestIdempotencyBegin_Existing(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	expiresAt := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(claimIdempotencyKeyQuery).
+		WithArgs("key-1", "abc", float64(60)).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(selectIdempotencyKeyQuery).
+		WithArgs("key-1").
+		WillReturnRows(sqlmock.NewRows([]string{"key", "fingerprint", "status_code", "content_type", "body", "expires_at"}).
+			AddRow("key-1", "abc", 201, "application/json", []byte(`{"id":"1"}`), expiresAt))
+
+	record, claimed, err := store.Begin(context.Background(), "key-1", "abc", time.Minute)
+
+	require.NoError(t, err)
+	assert.False(t, claimed)
+	assert.Equal(t, "abc", record.Fingerprint)
+	assert.Equal(t, 201, *record.StatusCode)
+	assert.Equal(t, "application/json", *record.ContentType)
+	assert.Equal(t, `{"id":"1"}`, string(record.Body))
+	assert.Equal(t, expiresAt, record.ExpiresAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestIdempotencyBegin_ReleasedInBetween tests that a key released between the two queries is claimed again
+func TestIdempotencyBegin_ReleasedInBetween(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	mock.ExpectQuery(claimIdempotencyKeyQuery).WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(selectIdempotencyKeyQuery).WithArgs("key-1").WillReturnError(sql.ErrNoRows)
+	mock.ExpectQuery(claimIdempotencyKeyQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"key"}).AddRow("key-1"))
+
+	_, claimed, err := store.Begin(context.Background(), "key-1", "abc", time.Minute)
+
+	assert.NoError(t, err)
+	assert.True(t, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestIdempotencyBegin_DatabaseError tests that claim failures are reported
+func TestIdempotencyBegin_DatabaseError(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	mock.ExpectQuery(claimIdempotencyKeyQuery).WillReturnError(errors.New("connection refused"))
+
+	_, claimed, err := store.Begin(context.Background(), "key-1", "abc", time.Minute)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to claim idempotency key")
+	assert.False(t, claimed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestIdempotencyComplete tests that the response is stored for the key
+func TestIdempotencyComplete(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	mock.ExpectExec(`UPDATE idempotency_keys SET status_code = \$1, content_type = \$2, body = \$3 WHERE key = \$4`).
+		WithArgs(201, "application/json", []byte(`{}`), "key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := store.Complete(context.Background(), "key-1", 201, "application/json", []byte(`{}`))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestIdempotencyRelease tests that only claims without a response are deleted
+func TestIdempotencyRelease(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE key = \$1 AND status_code IS NULL`).
+		WithArgs("key-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := store.Release(context.Background(), "key-1")
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// TestIdempotencyPurgeExpired tests that expired records are deleted and counted
+func TestIdempotencyPurgeExpired(t *testing.T) {
+	db, mock, store := setupMockIdempotencyStore(t)
+	defer db.Close()
+
+	mock.ExpectExec(`DELETE FROM idempotency_keys WHERE expires_at <= NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 3))
+
+	purged, err := store.PurgeExpired(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
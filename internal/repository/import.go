@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Import row statuses
+const (
+	importStatusCreated     = "created"
+	importStatusUpdated     = "updated"
+	importStatusWouldCreate = "would_create"
+	importStatusWouldUpdate = "would_update"
+	importStatusFailed      = "failed"
+	importStatusRolledBack  = "rolled_back"
+)
+
+// ImportUsers writes import rows in a single transaction, creating users or, when upsert is set,
+// updating existing users matched by email, ignoring case. Each row runs under its own savepoint so
+// every row is reported; the transaction is only committed if all rows succeed and dryRun is false.
+func (r *postgresUserRepository) ImportUsers(ctx context.Context, rows []models.ImportRow, upsert bool, dryRun bool) (*models.ImportResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result := &models.ImportResult{Rows: make([]models.ImportRowResult, len(rows))}
+	failed := false
+
+	for i, row := range rows {
+		rowResult := models.ImportRowResult{Row: row.Row, Email: row.User.Email}
+
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT import_row"); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+
+		id, inserted, err := r.importRow(ctx, tx, &row.User, upsert)
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT import_row"); rbErr != nil {
+				return nil, fmt.Errorf("failed to roll back savepoint: %w", rbErr)
+			}
+			failed = true
+			rowResult.Status = importStatusFailed
+			rowResult.Errors = []string{importErrorMessage(err)}
+			result.Rows[i] = rowResult
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT import_row"); err != nil {
+			return nil, fmt.Errorf("failed to release savepoint: %w", err)
+		}
+
+		rowResult.UserID = &id
+		switch {
+		case dryRun && inserted:
+			rowResult.Status = importStatusWouldCreate
+		case dryRun:
+			rowResult.Status = importStatusWouldUpdate
+		case inserted:
+			rowResult.Status = importStatusCreated
+		default:
+			rowResult.Status = importStatusUpdated
+		}
+		result.Rows[i] = rowResult
+	}
+
+	if dryRun {
+		// IDs of rows that were never written would only confuse callers
+		for i := range result.Rows {
+			if result.Rows[i].Status == importStatusWouldCreate {
+				result.Rows[i].UserID = nil
+			}
+		}
+		return result, nil
+	}
+
+	if failed {
+		for i := range result.Rows {
+			if result.Rows[i].Status != importStatusFailed {
+				result.Rows[i].Status = importStatusRolledBack
+				result.Rows[i].UserID = nil
+			}
+		}
+		return result, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+	result.Committed = true
+
+	return result, nil
+}
+
+// importRow creates the user, or with upsert updates the user holding the email, and reports
+// whether the user was created. Blank optional cells keep the existing value rather than clearing it.
+func (r *postgresUserRepository) importRow(ctx context.Context, tx *sqlx.Tx, user *models.CreateUserRequest, upsert bool) (uuid.UUID, bool, error) {
+	if upsert {
+		var existing models.User
+		findQuery := "SELECT " + userColumns + " FROM users WHERE LOWER(email) = LOWER($1) ORDER BY email = $1 DESC, created_at ASC, id ASC LIMIT 1 FOR UPDATE"
+		err := tx.GetContext(ctx, &existing, findQuery, user.Email)
+		switch {
+		case err == nil:
+			return existing.ID, false, r.importUpdate(ctx, tx, &existing, user)
+		case !errors.Is(err, sql.ErrNoRows):
+			return uuid.Nil, false, fmt.Errorf("failed to find user: %w", err)
+		}
+	}
+
+	insertQuery := `
+		INSERT INTO users (id, email, full_name, phone, role, is_active, manager_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	id := uuid.New()
+	if _, err := tx.ExecContext(ctx, insertQuery, id, user.Email, user.FullName, user.Phone, user.Role, true, user.ManagerID); err != nil {
+		return uuid.Nil, false, err
+	}
+	return id, true, nil
+}
+
+// importUpdate applies an import row to an existing user, who must not have been merged or erased
+func (r *postgresUserRepository) importUpdate(ctx context.Context, tx *sqlx.Tx, existing *models.User, user *models.CreateUserRequest) error {
+	if existing.MergedInto != nil {
+		return fmt.Errorf("user has already been merged")
+	}
+	if existing.ErasedAt != nil {
+		return fmt.Errorf("user has already been erased")
+	}
+	if user.ManagerID != nil {
+		if err := r.checkManagerAssignment(ctx, tx, existing.ID, *user.ManagerID); err != nil {
+			return err
+		}
+	}
+
+	updateQuery := `
+		UPDATE users SET
+			full_name = $2,
+			phone = COALESCE($3, phone),
+			role = $4,
+			manager_id = COALESCE($5, manager_id),
+			updated_at = NOW()
+		WHERE id = $1
+	`
+	_, err := tx.ExecContext(ctx, updateQuery, existing.ID, user.FullName, user.Phone, user.Role, user.ManagerID)
+	return err
+}
+
+// importErrorMessage turns a database error for a single row into a report message
+func importErrorMessage(err error) string {
+	errMsg := err.Error()
+	switch {
+	case strings.Contains(errMsg, "duplicate key value"):
+		return "email already exists"
+	case strings.Contains(errMsg, "foreign key"), strings.Contains(errMsg, "manager not found"):
+		return "manager not found"
+	case strings.Contains(errMsg, "would create a cycle"):
+		return "manager assignment would create a cycle"
+	case strings.Contains(errMsg, "already been merged"), strings.Contains(errMsg, "already been erased"):
+		return errMsg
+	case strings.Contains(errMsg, "check constraint"):
+		return "row violates a database constraint"
+	}
+	return "failed to import row"
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func importRows() []models.ImportRow {
+	return []models.ImportRow{
+		{Row: 1, User: models.CreateUserRequest{Email: "new@example.com", FullName: "New", Role: "staff"}},
+		{Row: 2, User: models.CreateUserRequest{Email: "existing@example.com", FullName: "Existing", Role: "supplier"}},
+	}
+}
+
+var importLookupColumns = []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "merged_into", "erased_at"}
+
+// expectImportLookup expects an upsert row to look up the user holding email, returning row or nothing
+func expectImportLookup(mock sqlmock.Sqlmock, email string, row ...driver.Value) {
+	rows := sqlmock.NewRows(importLookupColumns)
+	if row != nil {
+		rows.AddRow(row...)
+	}
+	mock.ExpectQuery(`SELECT .* FROM users WHERE LOWER\(email\) = LOWER\(\$1\) .* FOR UPDATE`).
+		WithArgs(email).
+		WillReturnRows(rows)
+}
+
+// TestImportUsers_UpsertCommit tests creating and updating rows in one committed transaction, with
+// existing users matched by email ignoring case
+func TestImportUsers_UpsertCommit(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	existingID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectImportLookup(mock, "new@example.com")
+	mock.ExpectExec(`INSERT INTO users`).
+		WithArgs(sqlmock.AnyArg(), "new@example.com", "New", nil, "staff", true, nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectImportLookup(mock, "existing@example.com", existingID, "Existing@Example.com", "Old", nil, "staff", true, nil, nil, nil)
+	mock.ExpectExec(`UPDATE users SET .* phone = COALESCE\(\$3, phone\), .* manager_id = COALESCE\(\$5, manager_id\), .* WHERE id = \$1`).
+		WithArgs(existingID, "Existing", nil, "supplier", nil).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	result, err := repo.ImportUsers(ctx, importRows(), true, false)
+
+	require.NoError(t, err)
+	assert.True(t, result.Committed)
+	require.Len(t, result.Rows, 2)
+	assert.Equal(t, "created", result.Rows[0].Status)
+	assert.NotNil(t, result.Rows[0].UserID)
+	assert.Equal(t, "updated", result.Rows[1].Status)
+	assert.Equal(t, existingID, *result.Rows[1].UserID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestImportUsers_CreateConflictRollsBack tests that one failing row rolls back the whole import
+func TestImportUsers_CreateConflictRollsBack(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO users`).
+		WillReturnError(fmt.Errorf(`pq: duplicate key value violates unique constraint "users_email_key"`))
+	mock.ExpectExec(`ROLLBACK TO SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.ImportUsers(ctx, importRows(), false, false)
+
+	require.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.Equal(t, "rolled_back", result.Rows[0].Status)
+	assert.Nil(t, result.Rows[0].UserID)
+	assert.Equal(t, "failed", result.Rows[1].Status)
+	assert.Equal(t, []string{"email already exists"}, result.Rows[1].Errors)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestImportUsers_DryRun tests that a dry run reports outcomes and never commits
+func TestImportUsers_DryRun(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	existingID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectImportLookup(mock, "new@example.com")
+	mock.ExpectExec(`INSERT INTO users`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectImportLookup(mock, "existing@example.com", existingID, "existing@example.com", "Old", nil, "staff", true, nil, nil, nil)
+	mock.ExpectExec(`UPDATE users SET`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`RELEASE SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.ImportUsers(ctx, importRows(), true, true)
+
+	require.NoError(t, err)
+	assert.False(t, result.Committed)
+	assert.Equal(t, "would_create", result.Rows[0].Status)
+	assert.Nil(t, result.Rows[0].UserID)
+	assert.Equal(t, "would_update", result.Rows[1].Status)
+	assert.Equal(t, existingID, *result.Rows[1].UserID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestImportUsers_UpsertRejectedRows tests that upserts leave merged and erased users alone and do
+// not close a loop in the hierarchy
+func TestImportUsers_UpsertRejectedRows(t *testing.T) {
+	existingID := uuid.New()
+	managerID := uuid.New()
+	erasedAt := time.Now()
+
+	tests := []struct {
+		name    string
+		expect  func(mock sqlmock.Sqlmock)
+		wantErr string
+	}{
+		{
+			name: "Merged",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectImportLookup(mock, "existing@example.com", existingID, "existing@example.com", "Old", nil, "staff", false, nil, uuid.New(), nil)
+			},
+			wantErr: "user has already been merged",
+		},
+		{
+			name: "Erased",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectImportLookup(mock, "existing@example.com", existingID, "existing@example.com", "Old", nil, "staff", false, nil, nil, erasedAt)
+			},
+			wantErr: "user has already been erased",
+		},
+		{
+			name: "ManagerCycle",
+			expect: func(mock sqlmock.Sqlmock) {
+				expectImportLookup(mock, "existing@example.com", existingID, "existing@example.com", "Old", nil, "staff", true, nil, nil, nil)
+				mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+				mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+					WithArgs(managerID, existingID).
+					WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(2, true))
+			},
+			wantErr: "manager assignment would create a cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, repo := setupMockDB(t)
+			defer db.Close()
+
+			mock.ExpectBegin()
+			mock.ExpectExec(`SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+			tt.expect(mock)
+			mock.ExpectExec(`ROLLBACK TO SAVEPOINT import_row`).WillReturnResult(sqlmock.NewResult(0, 0))
+			mock.ExpectRollback()
+
+			rows := []models.ImportRow{
+				{Row: 1, User: models.CreateUserRequest{Email: "existing@example.com", FullName: "Existing", Role: "staff", ManagerID: &managerID}},
+			}
+			result, err := repo.ImportUsers(context.Background(), rows, true, false)
+
+			require.NoError(t, err)
+			assert.False(t, result.Committed)
+			assert.Equal(t, "failed", result.Rows[0].Status)
+			assert.Equal(t, []string{tt.wantErr}, result.Rows[0].Errors)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// GetUserByEmail retrieves a user by email, ignoring case and surrounding whitespace. If several
+// users' emails differ only in case, an exact match wins, then the oldest user.
+func (r *postgresUserRepository) GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error) {
+	columns, err := selectColumns(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	email = strings.TrimSpace(email)
+	query := "SELECT " + columns + " FROM users WHERE LOWER(email) = LOWER($1) ORDER BY email = $1 DESC, created_at ASC, id ASC LIMIT 1"
+
+	var user models.User
+	if err := r.db.GetContext(ctx, &user, query, email); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetUsersByIDs retrieves the users with the given IDs in a single query, in no particular order.
+// IDs without a user are skipped.
+func (r *postgresUserRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error) {
+	columns, err := selectColumns(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	users := []models.User{}
+	if len(ids) == 0 {
+		return users, nil
+	}
+
+	query := "SELECT " + columns + " FROM users WHERE id = ANY($1)"
+	if err := r.db.SelectContext(ctx, &users, query, pq.Array(uuidStrings(ids))); err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	return users, nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserByEmail_Success tests a case-insensitive exact lookup with trimmed input
+func TestGetUserByEmail_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	expectedTime := time.Now()
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
+
+	mock.ExpectQuery(`SELECT id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at FROM users WHERE LOWER\(email\) = LOWER\(\$1\) ORDER BY email = \$1 DESC, created_at ASC, id ASC LIMIT 1`).
+		WithArgs("John.Doe@Example.com").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(userID, "john.doe@example.com", "John Doe", nil, "staff", true, expectedTime, expectedTime))
+
+	ctx := context.Background()
+	user, err := repo.GetUserByEmail(ctx, "  John.Doe@Example.com ", nil)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+	assert.Equal(t, "john.doe@example.com", user.Email)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetUserByEmail_NotFound tests that an unknown email reports user not found
+func TestGetUserByEmail_NotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT id, email FROM users WHERE LOWER\(email\) = LOWER\(\$1\)`).
+		WithArgs("nobody@example.com").
+		WillReturnError(sql.ErrNoRows)
+
+	ctx := context.Background()
+	_, err := repo.GetUserByEmail(ctx, "nobody@example.com", []string{"id", "email"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "user not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetUsersByIDs_Success tests fetching several users in one query
+func TestGetUsersByIDs_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	firstID, secondID, missingID := uuid.New(), uuid.New(), uuid.New()
+	ids := []uuid.UUID{firstID, secondID, missingID}
+
+	mock.ExpectQuery(`SELECT id, full_name FROM users WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.Array([]string{firstID.String(), secondID.String(), missingID.String()})).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "full_name"}).
+			AddRow(secondID, "Second").
+			AddRow(firstID, "First"))
+
+	ctx := context.Background()
+	users, err := repo.GetUsersByIDs(ctx, ids, []string{"id", "full_name"})
+
+	require.NoError(t, err)
+	require.Len(t, users, 2)
+	assert.Equal(t, secondID, users[0].ID)
+	assert.Equal(t, "First", users[1].FullName)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetUsersByIDs_Errors tests invalid fields, empty input and database failures
+func TestGetUsersByIDs_Errors(t *testing.T) {
+	t.Run("InvalidField", func(t *testing.T) {
+		db, _, repo := setupMockDB(t)
+		defer db.Close()
+
+		_, err := repo.GetUsersByIDs(context.Background(), []uuid.UUID{uuid.New()}, []string{"password"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid field: "password"`)
+	})
+
+	t.Run("NoIDs", func(t *testing.T) {
+		db, mock, repo := setupMockDB(t)
+		defer db.Close()
+
+		users, err := repo.GetUsersByIDs(context.Background(), nil, nil)
+		require.NoError(t, err)
+		assert.Empty(t, users)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("DatabaseError", func(t *testing.T) {
+		db, mock, repo := setupMockDB(t)
+		defer db.Close()
+
+		mock.ExpectQuery(`FROM users WHERE id = ANY\(\$1\)`).
+			WillReturnError(fmt.Errorf("connection reset"))
+
+		_, err := repo.GetUsersByIDs(context.Background(), []uuid.UUID{uuid.New()}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to get users")
+	})
+}
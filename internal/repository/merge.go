@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+)
+
+// mergeReassignments move per-user records from a merge source ($1) to the merge target ($2).
+// Every table holding data that belongs to a user must have an entry here.
+var mergeReassignments = []string{
+	// The source's direct reports now report to the target
+	"UPDATE users SET manager_id = $2 WHERE manager_id = $1",
+	// Accounts merged into the source earlier redirect straight to the target, not through the source
+	"UPDATE users SET merged_into = $2 WHERE merged_into = $1",
+}
+
+// MergeUsers folds the source user into the target user and retires the source. The target may not
+// be one of the source's subordinates, so it never ends up reporting to itself.
+func (r *postgresUserRepository) MergeUsers(ctx context.Context, sourceID uuid.UUID, req *models.MergeUsersRequest) (*models.MergeUsersResponse, error) {
+	if sourceID == req.TargetID {
+		return nil, fmt.Errorf("cannot merge a user into itself")
+	}
+
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	lockQuery := "SELECT " + userColumns + " FROM users WHERE id = $1 FOR UPDATE"
+
+	var source models.User
+	if err := tx.GetContext(ctx, &source, lockQuery, sourceID); err != nil {
+		return nil, fmt.Errorf("user not found: %w", err)
+	}
+	var target models.User
+	if err := tx.GetContext(ctx, &target, lockQuery, req.TargetID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("merge target not found")
+		}
+		return nil, fmt.Errorf("failed to get merge target: %w", err)
+	}
+
+	if source.MergedInto != nil {
+		return nil, fmt.Errorf("user has already been merged")
+	}
+	if target.MergedInto != nil {
+		return nil, fmt.Errorf("merge target has already been merged")
+	}
+
+	// Moving the source's reports under a target that sits below the source would close a loop
+	if err := r.checkManagerAssignment(ctx, tx, sourceID, req.TargetID); err != nil {
+		if strings.Contains(err.Error(), "would create a cycle") {
+			return nil, fmt.Errorf("cannot merge a user into one of their subordinates: would create a cycle")
+		}
+		return nil, err
+	}
+
+	survivor := target
+	for field, side := range req.Keep {
+		if side != "source" {
+			continue
+		}
+		switch field {
+		case "email":
+			survivor.Email = source.Email
+		case "full_name":
+			survivor.FullName = source.FullName
+		case "phone":
+			survivor.Phone = source.Phone
+		case "role":
+			survivor.Role = source.Role
+		}
+	}
+
+	// Release the source's email first so the target can take it over without a unique violation
+	if survivor.Email == source.Email && source.Email != target.Email {
+		releaseQuery := "UPDATE users SET email = $1 WHERE id = $2"
+		if _, err := tx.ExecContext(ctx, releaseQuery, mergedEmailPlaceholder(sourceID), sourceID); err != nil {
+			return nil, fmt.Errorf("failed to release source email: %w", err)
+		}
+	}
+
+	updateTargetQuery := "UPDATE users SET email = $1, full_name = $2, phone = $3, role = $4, updated_at = NOW() WHERE id = $5"
+	if _, err := tx.ExecContext(ctx, updateTargetQuery, survivor.Email, survivor.FullName, survivor.Phone, survivor.Role, req.TargetID); err != nil {
+		return nil, fmt.Errorf("failed to update merge target: %w", err)
+	}
+
+	for _, statement := range mergeReassignments {
+		if _, err := tx.ExecContext(ctx, statement, sourceID, req.TargetID); err != nil {
+			return nil, fmt.Errorf("failed to reassign merged records: %w", err)
+		}
+	}
+
+	retireQuery := "UPDATE users SET is_active = FALSE, merged_into = $1, updated_at = NOW() WHERE id = $2"
+	if _, err := tx.ExecContext(ctx, retireQuery, req.TargetID, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to retire merged user: %w", err)
+	}
+
+	if err := recordHistory(ctx, tx, sourceID, historyActionMergedInto, map[string]interface{}{
+		"target_id": req.TargetID,
+		"keep":      req.Keep,
+		"previous":  source,
+	}); err != nil {
+		return nil, err
+	}
+	if err := recordHistory(ctx, tx, req.TargetID, historyActionMergedFrom, map[string]interface{}{
+		"source_id": sourceID,
+		"keep":      req.Keep,
+		"previous":  target,
+	}); err != nil {
+		return nil, err
+	}
+
+	selectQuery := "SELECT " + userColumns + " FROM users WHERE id = $1"
+	var result models.MergeUsersResponse
+	if err := tx.GetContext(ctx, &result.Target, selectQuery, req.TargetID); err != nil {
+		return nil, fmt.Errorf("failed to get merged target: %w", err)
+	}
+	if err := tx.GetContext(ctx, &result.Source, selectQuery, sourceID); err != nil {
+		return nil, fmt.Errorf("failed to get merged source: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return &result, nil
+}
+
+// mergedEmailPlaceholder returns the unique address a retired source is left with
+// when its email is carried over to the merge target
+func mergedEmailPlaceholder(id uuid.UUID) string {
+	return fmt.Sprintf("merged+%s@invalid", id)
+}
@@ -0,0 +1,279 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mergeColumns = []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "merged_into", "created_at", "updated_at"}
+
+// TestMergeUsers_Success tests folding a source user into a target
+func TestMergeUsers_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	expectedTime := time.Now()
+	sourcePhone := "1234567890"
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT .* FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "old@example.com", "Jane D", &sourcePhone, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`SELECT .* FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(targetID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(targetID, "new@example.com", "Jane Doe", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+		WithArgs(targetID, sourceID).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(1, false))
+	mock.ExpectExec(`UPDATE users SET email = \$1, full_name = \$2, phone = \$3, role = \$4, updated_at = NOW\(\) WHERE id = \$5`).
+		WithArgs("new@example.com", "Jane Doe", &sourcePhone, "staff", targetID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET manager_id = \$2 WHERE manager_id = \$1`).
+		WithArgs(sourceID, targetID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`UPDATE users SET merged_into = \$2 WHERE merged_into = \$1`).
+		WithArgs(sourceID, targetID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET is_active = FALSE, merged_into = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+		WithArgs(targetID, sourceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`INSERT INTO user_history \(user_id, action, details\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs(sourceID, "merged_into", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO user_history \(user_id, action, details\) VALUES \(\$1, \$2, \$3\)`).
+		WithArgs(targetID, "merged_from", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(2, 1))
+	mock.ExpectQuery(`SELECT .* FROM users WHERE id = \$1`).
+		WithArgs(targetID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(targetID, "new@example.com", "Jane Doe", &sourcePhone, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`SELECT .* FROM users WHERE id = \$1`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "old@example.com", "Jane D", &sourcePhone, "staff", false, nil, targetID, expectedTime, expectedTime))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, sourceID, &models.MergeUsersRequest{
+		TargetID: targetID,
+		Keep:     map[string]string{"phone": "source"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, &sourcePhone, result.Target.Phone)
+	assert.False(t, result.Source.IsActive)
+	require.NotNil(t, result.Source.MergedInto)
+	assert.Equal(t, targetID, *result.Source.MergedInto)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestMergeUsers_KeepSourceEmail tests that the source email is released before the target takes it
+func TestMergeUsers_KeepSourceEmail(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "keep@example.com", "Source", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(targetID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(targetID, "typo@example.com", "Target", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+		WithArgs(targetID, sourceID).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(1, false))
+	mock.ExpectExec(`UPDATE users SET email = \$1 WHERE id = \$2`).
+		WithArgs(mergedEmailPlaceholder(sourceID), sourceID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`UPDATE users SET email = \$1, full_name = \$2`).
+		WithArgs("keep@example.com", "Target", nil, "staff", targetID).
+		WillReturnError(sql.ErrConnDone)
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, sourceID, &models.MergeUsersRequest{
+		TargetID: targetID,
+		Keep:     map[string]string{"email": "source"},
+	})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "failed to update merge target")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestMergeUsers_IntoItself tests that a user cannot be merged into itself
+func TestMergeUsers_IntoItself(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, userID, &models.MergeUsersRequest{TargetID: userID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "cannot merge a user into itself")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestMergeUsers_TargetNotFound tests error when the target doesn't exist
+func TestMergeUsers_TargetNotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "source@example.com", "Source", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(targetID).
+		WillReturnError(sql.ErrNoRows)
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, sourceID, &models.MergeUsersRequest{TargetID: targetID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "merge target not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestMergeUsers_AlreadyMerged tests that a retired source cannot be merged again
+func TestMergeUsers_AlreadyMerged(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	otherID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "source@example.com", "Source", nil, "staff", false, nil, otherID, expectedTime, expectedTime))
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(targetID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(targetID, "target@example.com", "Target", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, sourceID, &models.MergeUsersRequest{TargetID: targetID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "already been merged")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestMergeUsers_TargetIsSubordinate tests that merging into a subordinate is rejected
+func TestMergeUsers_TargetIsSubordinate(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	sourceID := uuid.New()
+	targetID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "source@example.com", "Source", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(targetID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(targetID, "target@example.com", "Target", nil, "staff", true, sourceID, nil, expectedTime, expectedTime))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+		WithArgs(targetID, sourceID).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(2, true))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, sourceID, &models.MergeUsersRequest{TargetID: targetID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "would create a cycle")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestMergeUsers_TargetIsIndirectSubordinate tests that merging into a subordinate further down the
+// hierarchy is rejected before anything is written
+func TestMergeUsers_TargetIsIndirectSubordinate(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	sourceID := uuid.New()
+	middleID := uuid.New()
+	targetID := uuid.New()
+	expectedTime := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(sourceID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(sourceID, "source@example.com", "Source", nil, "staff", true, nil, nil, expectedTime, expectedTime))
+	mock.ExpectQuery(`FOR UPDATE`).
+		WithArgs(targetID).
+		WillReturnRows(sqlmock.NewRows(mergeColumns).
+			AddRow(targetID, "target@example.com", "Target", nil, "staff", true, middleID, nil, expectedTime, expectedTime))
+	mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`WITH RECURSIVE chain AS`).
+		WithArgs(targetID, sourceID).
+		WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(3, true))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	result, err := repo.MergeUsers(ctx, sourceID, &models.MergeUsersRequest{TargetID: targetID})
+
+	assert.Error(t, err)
+	assert.Nil(t, result)
+	assert.Contains(t, err.Error(), "cannot merge a user into one of their subordinates")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+)
+
+// ReplaceUser overwrites every client-changeable field of a user, so nil values clear the
+// corresponding columns. If unmodifiedSince is given, the user is only written if its
+// updated_at still equals it, guarding read-modify-write cycles against concurrent changes.
+func (r *postgresUserRepository) ReplaceUser(ctx context.Context, id uuid.UUID, replacement *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error) {
+	// The manager check and the update share a transaction, so the check still holds when the
+	// update is written
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserWritable(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	if replacement.ManagerID != nil {
+		if err := r.checkManagerAssignment(ctx, tx, id, *replacement.ManagerID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `UPDATE users
+		SET email = $1, full_name = $2, phone = $3, role = $4, is_active = $5, manager_id = $6, updated_at = NOW()
+		WHERE id = $7`
+	args := []interface{}{
+		replacement.Email,
+		replacement.FullName,
+		replacement.Phone,
+		replacement.Role,
+		*replacement.IsActive,
+		replacement.ManagerID,
+		id,
+	}
+	if unmodifiedSince != nil {
+		query += " AND updated_at = $8"
+		args = append(args, *unmodifiedSince)
+	}
+	query += " RETURNING " + userColumns
+
+	var user models.User
+	if err := tx.GetContext(ctx, &user, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			if unmodifiedSince != nil {
+				return nil, fmt.Errorf("user was modified concurrently")
+			}
+			return nil, fmt.Errorf("user not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to replace user: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &user, nil
+}
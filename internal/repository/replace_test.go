@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReplaceUser_ClearsOptionalFields tests that nil optional fields are written as NULL
+func TestReplaceUser_ClearsOptionalFields(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	expectedTime := time.Now()
+	isActive := true
+	replacement := &models.ReplaceUserRequest{
+		Email:    "jane@example.com",
+		FullName: "Jane Doe",
+		Role:     "staff",
+		IsActive: &isActive,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "manager_id", "created_at", "updated_at"}
+	mock.ExpectQuery(`UPDATE users SET email = \$1, full_name = \$2, phone = \$3, role = \$4, is_active = \$5, manager_id = \$6, updated_at = NOW\(\) WHERE id = \$7 RETURNING id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at`).
+		WithArgs("jane@example.com", "Jane Doe", nil, "staff", true, nil, userID).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(userID, "jane@example.com", "Jane Doe", nil, "staff", true, nil, expectedTime, expectedTime))
+
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	user, err := repo.ReplaceUser(ctx, userID, replacement, nil)
+
+	require.NoError(t, err)
+	assert.Nil(t, user.Phone)
+	assert.Nil(t, user.ManagerID)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestReplaceUser_UnmodifiedSince tests the optimistic concurrency guard
+func TestReplaceUser_UnmodifiedSince(t *testing.T) {
+	userID := uuid.New()
+	managerID := uuid.New()
+	lastUpdate := time.Date(2026, 3, 1, 9, 30, 0, 123456000, time.UTC)
+	phone := "555-0100"
+	isActive := false
+	replacement := &models.ReplaceUserRequest{
+		Email:     "jane@example.com",
+		FullName:  "Jane Doe",
+		Phone:     &phone,
+		Role:      "admin",
+		IsActive:  &isActive,
+		ManagerID: &managerID,
+	}
+
+	expect := func(mock sqlmock.Sqlmock) *sqlmock.ExpectedQuery {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
+		mock.ExpectExec(`SELECT pg_advisory_xact_lock\(\$1\)`).WithArgs(hierarchyLockKey).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`WITH RECURSIVE chain`).
+			WithArgs(managerID, userID).
+			WillReturnRows(sqlmock.NewRows([]string{"found", "cycle"}).AddRow(1, false))
+		return mock.ExpectQuery(`UPDATE users SET .* WHERE id = \$7 AND updated_at = \$8 RETURNING`).
+			WithArgs("jane@example.com", "Jane Doe", phone, "admin", false, managerID, userID, lastUpdate)
+	}
+
+	t.Run("Unchanged", func(t *testing.T) {
+		db, mock, repo := setupMockDB(t)
+		defer db.Close()
+
+		expect(mock).WillReturnRows(sqlmock.NewRows([]string{"id", "email", "phone"}).AddRow(userID, "jane@example.com", phone))
+		mock.ExpectCommit()
+
+		user, err := repo.ReplaceUser(context.Background(), userID, replacement, &lastUpdate)
+		require.NoError(t, err)
+		assert.Equal(t, &phone, user.Phone)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("ModifiedConcurrently", func(t *testing.T) {
+		db, mock, repo := setupMockDB(t)
+		defer db.Close()
+
+		expect(mock).WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.ReplaceUser(context.Background(), userID, replacement, &lastUpdate)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "user was modified concurrently")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+// TestReplaceUser_NotFound tests that a missing user is reported before anything is written
+func TestReplaceUser_NotFound(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	userID := uuid.New()
+	isActive := true
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+		WithArgs(userID).
+		WillReturnError(sql.ErrNoRows)
+
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	_, err := repo.ReplaceUser(ctx, userID, &models.ReplaceUserRequest{Email: "a@example.com", FullName: "A", Role: "staff", IsActive: &isActive}, nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "user not found")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
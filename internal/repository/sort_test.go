@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetAllUsers_MultiColumnSort tests multi-column ORDER BY clauses with the id tie-breaker
+func TestGetAllUsers_MultiColumnSort(t *testing.T) {
+	testCases := []struct {
+		name          string
+		sort          *models.SortParams
+		expectedOrder string
+	}{
+		{
+			name:          "Default",
+			sort:          nil,
+			expectedOrder: "ORDER BY created_at ASC, id ASC",
+		},
+		{
+			name: "RoleThenNameDesc",
+			sort: &models.SortParams{Fields: []models.SortField{
+				{Field: "role", Order: "asc"},
+				{Field: "full_name", Order: "desc"},
+				{Field: "created_at", Order: "asc"},
+			}},
+			expectedOrder: "ORDER BY role ASC, full_name DESC, created_at ASC, id ASC",
+		},
+		{
+			name: "NullsLast",
+			sort: &models.SortParams{Fields: []models.SortField{
+				{Field: "phone", Order: "desc", Nulls: "last"},
+				{Field: "manager_id", Order: "asc", Nulls: "first"},
+			}},
+			expectedOrder: "ORDER BY phone DESC NULLS LAST, manager_id ASC NULLS FIRST, id ASC",
+		},
+		{
+			name: "ExplicitIDIsNotRepeated",
+			sort: &models.SortParams{Fields: []models.SortField{
+				{Field: "role", Order: "asc"},
+				{Field: "id", Order: "desc"},
+			}},
+			expectedOrder: "ORDER BY role ASC, id DESC",
+		},
+		{
+			name:          "FieldsTakePrecedence",
+			sort:          &models.SortParams{Field: "email", Order: "asc", Fields: []models.SortField{{Field: "role", Order: "desc"}}},
+			expectedOrder: "ORDER BY role DESC, id ASC",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, repo := setupMockDB(t)
+			defer db.Close()
+
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+			mock.ExpectQuery(`SELECT `+userColumns+` FROM users `+tc.expectedOrder+` LIMIT \$1 OFFSET \$2`).
+				WithArgs(10, 0).
+				WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+			pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+			result, err := repo.GetAllUsers(context.Background(), nil, tc.sort, pagination, nil)
+
+			require.NoError(t, err)
+			assert.NotNil(t, result)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// TestGetAllUsers_InvalidSort tests that sort keys outside the whitelist never reach the database
+func TestGetAllUsers_InvalidSort(t *testing.T) {
+	testCases := []struct {
+		name   string
+		sort   *models.SortParams
+		errMsg string
+	}{
+		{"UnknownField", &models.SortParams{Fields: []models.SortField{{Field: "password", Order: "asc"}}}, "invalid sort field"},
+		{"UnknownLegacyField", &models.SortParams{Field: "created_at; DROP TABLE users", Order: "asc"}, "invalid sort field"},
+		{"UnknownOrder", &models.SortParams{Fields: []models.SortField{{Field: "email", Order: "sideways"}}}, "invalid sort order"},
+		{"NullsOnRequiredField", &models.SortParams{Fields: []models.SortField{{Field: "email", Order: "asc", Nulls: "last"}}}, "email is never null"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, repo := setupMockDB(t)
+			defer db.Close()
+
+			pagination := &models.PaginationParams{Page: 1, PageSize: 10, Offset: 0}
+			result, err := repo.GetAllUsers(context.Background(), nil, tc.sort, pagination, nil)
+
+			assert.Error(t, err)
+			assert.Nil(t, result)
+			assert.Contains(t, err.Error(), tc.errMsg)
+
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
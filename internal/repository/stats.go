@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+)
+
+// statsDomainLimit is the number of most common email domains reported in user statistics
+const statsDomainLimit = 20
+
+// statsIntervals lists the supported time series bucket sizes
+var statsIntervals = map[string]bool{
+	"day":   true,
+	"week":  true,
+	"month": true,
+}
+
+// GetUserStats aggregates the users matching the filters by role, status and email domain, and
+// buckets their signups and deactivations over time. All counts are read from one snapshot.
+func (r *postgresUserRepository) GetUserStats(ctx context.Context, filters *models.FilterParams, params *models.StatsParams) (*models.UserStats, error) {
+	if !statsIntervals[params.Interval] {
+		return nil, fmt.Errorf("invalid interval: %q", params.Interval)
+	}
+
+	whereClause, args, err := r.buildWhereClause(filters)
+	if err != nil {
+		return nil, err
+	}
+	where := func(extra string) string {
+		switch {
+		case whereClause == "" && extra == "":
+			return ""
+		case whereClause == "":
+			return " WHERE " + extra
+		case extra == "":
+			return " WHERE " + whereClause
+		}
+		return " WHERE " + whereClause + " AND " + extra
+	}
+
+	// Repeatable read gives every query below the same snapshot
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stats := &models.UserStats{
+		ByRole:        map[string]int{},
+		ByStatus:      map[string]int{"active": 0, "inactive": 0},
+		ByEmailDomain: []models.DomainCount{},
+		Interval:      params.Interval,
+		TimeZone:      params.TimeZone,
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	if err := tx.GetContext(ctx, &stats.Total, "SELECT COUNT(*) FROM users"+where(""), args...); err != nil {
+		return nil, fmt.Errorf("failed to count users: %w", err)
+	}
+
+	var roles []struct {
+		Role  string `db:"role"`
+		Count int    `db:"count"`
+	}
+	roleQuery := "SELECT role, COUNT(*) AS count FROM users" + where("") + " GROUP BY role"
+	if err := tx.SelectContext(ctx, &roles, roleQuery, args...); err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+	for _, role := range roles {
+		stats.ByRole[role.Role] = role.Count
+	}
+
+	var statuses []struct {
+		IsActive bool `db:"is_active"`
+		Count    int  `db:"count"`
+	}
+	statusQuery := "SELECT is_active, COUNT(*) AS count FROM users" + where("") + " GROUP BY is_active"
+	if err := tx.SelectContext(ctx, &statuses, statusQuery, args...); err != nil {
+		return nil, fmt.Errorf("failed to count users by status: %w", err)
+	}
+	for _, status := range statuses {
+		if status.IsActive {
+			stats.ByStatus["active"] = status.Count
+		} else {
+			stats.ByStatus["inactive"] = status.Count
+		}
+	}
+
+	domainQuery := fmt.Sprintf(`SELECT LOWER(SPLIT_PART(email, '@', 2)) AS domain, COUNT(*) AS count FROM users%s
+		GROUP BY domain ORDER BY count DESC, domain LIMIT %d`, where(""), statsDomainLimit)
+	if err := tx.SelectContext(ctx, &stats.ByEmailDomain, domainQuery, args...); err != nil {
+		return nil, fmt.Errorf("failed to count users by email domain: %w", err)
+	}
+
+	// Buckets start at midnight in the requested time zone
+	bucketArgs := append(append([]interface{}{}, args...), params.Interval, params.TimeZone)
+	bucket := func(column string) string {
+		return fmt.Sprintf("SELECT DATE_TRUNC($%d, %s, $%d) AS bucket, COUNT(*) AS count FROM users%s GROUP BY bucket ORDER BY bucket",
+			len(args)+1, column, len(args)+2, where(column+" IS NOT NULL"))
+	}
+
+	stats.Signups = []models.StatsBucket{}
+	if err := tx.SelectContext(ctx, &stats.Signups, bucket("created_at"), bucketArgs...); err != nil {
+		return nil, fmt.Errorf("failed to bucket signups: %w", err)
+	}
+
+	stats.Deactivations = []models.StatsBucket{}
+	if err := tx.SelectContext(ctx, &stats.Deactivations, bucket("deactivated_at"), bucketArgs...); err != nil {
+		return nil, fmt.Errorf("failed to bucket deactivations: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return stats, nil
+}
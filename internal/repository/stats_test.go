@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGetUserStats_Success tests that every aggregate is read with the filters applied
+func TestGetUserStats_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	role := "supplier"
+	week1 := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	week2 := time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE role = \$1$`).
+		WithArgs(role).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	mock.ExpectQuery(`SELECT role, COUNT\(\*\) AS count FROM users WHERE role = \$1 GROUP BY role`).
+		WithArgs(role).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "count"}).AddRow("supplier", 5))
+	mock.ExpectQuery(`SELECT is_active, COUNT\(\*\) AS count FROM users WHERE role = \$1 GROUP BY is_active`).
+		WithArgs(role).
+		WillReturnRows(sqlmock.NewRows([]string{"is_active", "count"}).AddRow(true, 4).AddRow(false, 1))
+	mock.ExpectQuery(`SELECT LOWER\(SPLIT_PART\(email, '@', 2\)\) AS domain, COUNT\(\*\) AS count FROM users WHERE role = \$1\s+GROUP BY domain ORDER BY count DESC, domain LIMIT 20`).
+		WithArgs(role).
+		WillReturnRows(sqlmock.NewRows([]string{"domain", "count"}).AddRow("acme.com", 3).AddRow("example.com", 2))
+	mock.ExpectQuery(`SELECT DATE_TRUNC\(\$2, created_at, \$3\) AS bucket, COUNT\(\*\) AS count FROM users WHERE role = \$1 AND created_at IS NOT NULL GROUP BY bucket ORDER BY bucket`).
+		WithArgs(role, "week", "Asia/Jakarta").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(week1, 2).AddRow(week2, 3))
+	mock.ExpectQuery(`SELECT DATE_TRUNC\(\$2, deactivated_at, \$3\) AS bucket, COUNT\(\*\) AS count FROM users WHERE role = \$1 AND deactivated_at IS NOT NULL GROUP BY bucket ORDER BY bucket`).
+		WithArgs(role, "week", "Asia/Jakarta").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}).AddRow(week2, 1))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	stats, err := repo.GetUserStats(ctx, &models.FilterParams{Role: &role}, &models.StatsParams{Interval: "week", TimeZone: "Asia/Jakarta"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.Total)
+	assert.Equal(t, map[string]int{"supplier": 5}, stats.ByRole)
+	assert.Equal(t, map[string]int{"active": 4, "inactive": 1}, stats.ByStatus)
+	assert.Equal(t, []models.DomainCount{{Domain: "acme.com", Count: 3}, {Domain: "example.com", Count: 2}}, stats.ByEmailDomain)
+	assert.Equal(t, []models.StatsBucket{{Start: week1, Count: 2}, {Start: week2, Count: 3}}, stats.Signups)
+	assert.Equal(t, []models.StatsBucket{{Start: week2, Count: 1}}, stats.Deactivations)
+	assert.Equal(t, "week", stats.Interval)
+	assert.Equal(t, "Asia/Jakarta", stats.TimeZone)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetUserStats_NoFilters tests that an empty result still reports zero counts and empty series
+func TestGetUserStats_NoFilters(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users$`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(`SELECT role, COUNT\(\*\) AS count FROM users GROUP BY role`).
+		WillReturnRows(sqlmock.NewRows([]string{"role", "count"}))
+	mock.ExpectQuery(`SELECT is_active, COUNT\(\*\) AS count FROM users GROUP BY is_active`).
+		WillReturnRows(sqlmock.NewRows([]string{"is_active", "count"}))
+	mock.ExpectQuery(`FROM users\s+GROUP BY domain`).
+		WillReturnRows(sqlmock.NewRows([]string{"domain", "count"}))
+	mock.ExpectQuery(`SELECT DATE_TRUNC\(\$1, created_at, \$2\) AS bucket, COUNT\(\*\) AS count FROM users WHERE created_at IS NOT NULL`).
+		WithArgs("day", "UTC").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}))
+	mock.ExpectQuery(`SELECT DATE_TRUNC\(\$1, deactivated_at, \$2\) AS bucket, COUNT\(\*\) AS count FROM users WHERE deactivated_at IS NOT NULL`).
+		WithArgs("day", "UTC").
+		WillReturnRows(sqlmock.NewRows([]string{"bucket", "count"}))
+	mock.ExpectCommit()
+
+	ctx := context.Background()
+	stats, err := repo.GetUserStats(ctx, nil, &models.StatsParams{Interval: "day", TimeZone: "UTC"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.Total)
+	assert.Empty(t, stats.ByRole)
+	assert.Equal(t, map[string]int{"active": 0, "inactive": 0}, stats.ByStatus)
+	assert.NotNil(t, stats.ByEmailDomain)
+	assert.NotNil(t, stats.Signups)
+	assert.NotNil(t, stats.Deactivations)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetUserStats_InvalidInterval tests that unsupported intervals are rejected before querying
+func TestGetUserStats_InvalidInterval(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	ctx := context.Background()
+	_, err := repo.GetUserStats(ctx, nil, &models.StatsParams{Interval: "hour", TimeZone: "UTC"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid interval: "hour"`)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestGetUserStats_QueryError tests that a failing aggregate rolls back the snapshot
+func TestGetUserStats_QueryError(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery(`SELECT role, COUNT\(\*\) AS count FROM users GROUP BY role`).
+		WillReturnError(fmt.Errorf("connection reset"))
+	mock.ExpectRollback()
+
+	ctx := context.Background()
+	_, err := repo.GetUserStats(ctx, nil, &models.StatsParams{Interval: "month", TimeZone: "UTC"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to count users by role")
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
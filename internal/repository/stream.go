@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/GoodsChain/user/internal/models"
+)
+
+// streamBatchSize is the number of rows fetched from the export cursor per round trip
+const streamBatchSize = 500
+
+// StreamUsers calls fn for every user matching the filters, in sort order, reading them from a
+// server-side cursor so the full result is never held in memory
+func (r *postgresUserRepository) StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error {
+	query := "SELECT " + userColumns + " FROM users"
+	whereClause, args, err := r.buildWhereClause(filters)
+	if err != nil {
+		return err
+	}
+	if whereClause != "" {
+		query += " WHERE " + whereClause
+	}
+	orderClause, err := r.buildOrderClause(sort)
+	if err != nil {
+		return err
+	}
+	query += " " + orderClause
+
+	// Cursors only live inside a transaction
+	tx, err := r.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE user_stream NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("failed to declare cursor: %w", err)
+	}
+
+	fetchQuery := fmt.Sprintf("FETCH FORWARD %d FROM user_stream", streamBatchSize)
+	for {
+		rows, err := tx.QueryxContext(ctx, fetchQuery)
+		if err != nil {
+			return fmt.Errorf("failed to fetch users: %w", err)
+		}
+
+		fetched := 0
+		for rows.Next() {
+			var user models.User
+			if err := rows.StructScan(&user); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan user: %w", err)
+			}
+			fetched++
+			if err := fn(&user); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		if err := rows.Err(); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to fetch users: %w", err)
+		}
+		rows.Close()
+
+		if fetched < streamBatchSize {
+			break
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "CLOSE user_stream"); err != nil {
+		return fmt.Errorf("failed to close cursor: %w", err)
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestStreamUsers_Success tests reading users from a server-side cursor in batches
+func TestStreamUsers_Success(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	role := "staff"
+	expectedTime := time.Now()
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
+
+	fullBatch := sqlmock.NewRows(columns)
+	for i := 0; i < streamBatchSize; i++ {
+		fullBatch.AddRow(uuid.New(), fmt.Sprintf("user%d@example.com", i), "User", nil, role, true, expectedTime, expectedTime)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE user_stream NO SCROLL CURSOR FOR SELECT .* FROM users WHERE role = \$1 ORDER BY email DESC, id ASC`).
+		WithArgs(role).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 500 FROM user_stream`).
+		WillReturnRows(fullBatch)
+	mock.ExpectQuery(`FETCH FORWARD 500 FROM user_stream`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), "last@example.com", "Last", nil, role, true, expectedTime, expectedTime))
+	mock.ExpectExec(`CLOSE user_stream`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	var emails []string
+	ctx := context.Background()
+	err := repo.StreamUsers(ctx, &models.FilterParams{Role: &role}, &models.SortParams{Field: "email", Order: "desc"}, func(user *models.User) error {
+		emails = append(emails, user.Email)
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Len(t, emails, streamBatchSize+1)
+	assert.Equal(t, "last@example.com", emails[len(emails)-1])
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
+
+// TestStreamUsers_CallbackError tests that a callback error stops the stream and rolls back
+func TestStreamUsers_CallbackError(t *testing.T) {
+	db, mock, repo := setupMockDB(t)
+	defer db.Close()
+
+	expectedTime := time.Now()
+	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DECLARE user_stream NO SCROLL CURSOR FOR SELECT .* FROM users ORDER BY created_at ASC, id ASC`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(`FETCH FORWARD 500 FROM user_stream`).
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow(uuid.New(), "a@example.com", "A", nil, "staff", true, expectedTime, expectedTime).
+			AddRow(uuid.New(), "b@example.com", "B", nil, "staff", true, expectedTime, expectedTime))
+	mock.ExpectRollback()
+
+	calls := 0
+	ctx := context.Background()
+	err := repo.StreamUsers(ctx, nil, nil, func(user *models.User) error {
+		calls++
+		return fmt.Errorf("client went away")
+	})
+
+	assert.EqualError(t, err, "client went away")
+	assert.Equal(t, 1, calls)
+
+	err = mock.ExpectationsWereMet()
+	assert.NoError(t, err)
+}
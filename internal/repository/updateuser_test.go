@@ -27,18 +27,21 @@ func TestUpdateUser_Success(t *testing.T) {
 	expectedTime := time.Now()
 	phone := "1234567890"
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 	// Expect update query
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = \$2 WHERE id = \$3 RETURNING id, email, full_name, phone, role, is_active, created_at, updated_at`).
-		WithArgs(newEmail, sqlmock.AnyArg(), userID).
+	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = NOW\(\) WHERE id = \$2 RETURNING id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at`).
+		WithArgs(newEmail, userID).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, newEmail, "John Doe", &phone, "admin", true, expectedTime, expectedTime))
 
+	mock.ExpectCommit()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -73,18 +76,21 @@ func TestUpdateUser_SuccessMultipleFields(t *testing.T) {
 
 	expectedTime := time.Now()
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 	// Expect update query with all fields
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`UPDATE users SET email = \$1, full_name = \$2, phone = \$3, role = \$4, is_active = \$5, updated_at = \$6 WHERE id = \$7 RETURNING id, email, full_name, phone, role, is_active, created_at, updated_at`).
-		WithArgs(newEmail, newFullName, newPhone, newRole, isActive, sqlmock.AnyArg(), userID).
+	mock.ExpectQuery(`UPDATE users SET email = \$1, full_name = \$2, phone = \$3, role = \$4, is_active = \$5, updated_at = NOW\(\) WHERE id = \$6 RETURNING id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at`).
+		WithArgs(newEmail, newFullName, newPhone, newRole, isActive, userID).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow(userID, newEmail, newFullName, &newPhone, newRole, isActive, expectedTime, expectedTime))
 
+	mock.ExpectCommit()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -112,11 +118,14 @@ func TestUpdateUser_UserNotFound(t *testing.T) {
 		Email: &newEmail,
 	}
 
+	mock.ExpectBegin()
 	// Expect user existence check to fail
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnError(sql.ErrNoRows)
 
+	mock.ExpectRollback()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -128,6 +137,46 @@ func TestUpdateUser_UserNotFound(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// TestUpdateUser_Tombstone tests that merged and erased users are not written to
+func TestUpdateUser_Tombstone(t *testing.T) {
+	userID := uuid.New()
+	fullName := "Jane Doe"
+
+	tests := []struct {
+		name       string
+		mergedInto interface{}
+		erasedAt   interface{}
+		expected   string
+	}{
+		{"Merged", uuid.New(), nil, "user has already been merged"},
+		{"Erased", nil, time.Now(), "user has already been erased"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, repo := setupMockDB(t)
+			defer db.Close()
+
+			for i := 0; i < 2; i++ {
+				mock.ExpectBegin()
+				mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
+					WithArgs(userID).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "merged_into", "erased_at"}).AddRow(userID, tt.mergedInto, tt.erasedAt))
+				mock.ExpectRollback()
+			}
+
+			_, err := repo.UpdateUser(context.Background(), userID, &models.UpdateUserRequest{FullName: &fullName})
+			require.Error(t, err)
+			assert.Equal(t, tt.expected, err.Error())
+
+			_, err = repo.ReplaceUser(context.Background(), userID, &models.ReplaceUserRequest{FullName: fullName}, nil)
+			require.Error(t, err)
+			assert.Equal(t, tt.expected, err.Error())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 // TestUpdateUser_NoFieldsToUpdate tests error when no fields are provided
 func TestUpdateUser_NoFieldsToUpdate(t *testing.T) {
 	db, mock, repo := setupMockDB(t)
@@ -138,11 +187,14 @@ func TestUpdateUser_NoFieldsToUpdate(t *testing.T) {
 		// No fields provided
 	}
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
+	mock.ExpectRollback()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -165,16 +217,19 @@ func TestUpdateUser_DatabaseError(t *testing.T) {
 		Email: &newEmail,
 	}
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 	// Expect update query to fail
-	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = \$2 WHERE id = \$3 RETURNING`).
-		WithArgs(newEmail, sqlmock.AnyArg(), userID).
+	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = NOW\(\) WHERE id = \$2 RETURNING`).
+		WithArgs(newEmail, userID).
 		WillReturnError(sql.ErrConnDone)
 
+	mock.ExpectRollback()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -197,16 +252,19 @@ func TestUpdateUser_DuplicateEmail(t *testing.T) {
 		Email: &duplicateEmail,
 	}
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 	// Simulate unique constraint violation
-	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = \$2 WHERE id = \$3 RETURNING`).
-		WithArgs(duplicateEmail, sqlmock.AnyArg(), userID).
+	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = NOW\(\) WHERE id = \$2 RETURNING`).
+		WithArgs(duplicateEmail, userID).
 		WillReturnError(sql.ErrNoRows) // Simulating constraint violation
 
+	mock.ExpectRollback()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -229,18 +287,21 @@ func TestUpdateUser_ScanError(t *testing.T) {
 		Email: &newEmail,
 	}
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 	// Return invalid data that will cause scanning to fail
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = \$2 WHERE id = \$3 RETURNING`).
-		WithArgs(newEmail, sqlmock.AnyArg(), userID).
+	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = NOW\(\) WHERE id = \$2 RETURNING`).
+		WithArgs(newEmail, userID).
 		WillReturnRows(sqlmock.NewRows(columns).
 			AddRow("invalid-uuid", newEmail, "Test User", nil, "admin", true, "invalid-time", "invalid-time"))
 
+	mock.ExpectRollback()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -263,17 +324,20 @@ func TestUpdateUser_NoRowsReturned(t *testing.T) {
 		Email: &newEmail,
 	}
 
+	mock.ExpectBegin()
 	// Expect user existence check
-	mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+	mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 		WithArgs(userID).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 	// Return empty result set
 	columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
-	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = \$2 WHERE id = \$3 RETURNING`).
-		WithArgs(newEmail, sqlmock.AnyArg(), userID).
+	mock.ExpectQuery(`UPDATE users SET email = \$1, updated_at = NOW\(\) WHERE id = \$2 RETURNING`).
+		WithArgs(newEmail, userID).
 		WillReturnRows(sqlmock.NewRows(columns)) // Empty rows
 
+	mock.ExpectRollback()
+
 	ctx := context.Background()
 	result, err := repo.UpdateUser(ctx, userID, updateReq)
 
@@ -334,18 +398,21 @@ func TestUpdateUser_AllFields(t *testing.T) {
 			expectedTime := time.Now()
 			phone := "1234567890"
 
+			mock.ExpectBegin()
 			// Expect user existence check
-			mock.ExpectQuery(`SELECT id FROM users WHERE id = \$1`).
+			mock.ExpectQuery(`SELECT id, merged_into, erased_at FROM users WHERE id = \$1 FOR UPDATE`).
 				WithArgs(userID).
 				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(userID))
 
 			// Expect update query (pattern matching since exact query varies by field)
 			columns := []string{"id", "email", "full_name", "phone", "role", "is_active", "created_at", "updated_at"}
 			mock.ExpectQuery(`UPDATE users SET .* WHERE id = .* RETURNING`).
-				WithArgs(tc.value, sqlmock.AnyArg(), userID).
+				WithArgs(tc.value, userID).
 				WillReturnRows(sqlmock.NewRows(columns).
 					AddRow(userID, "test@example.com", "Test User", &phone, "admin", true, expectedTime, expectedTime))
 
+			mock.ExpectCommit()
+
 			ctx := context.Background()
 			result, err := repo.UpdateUser(ctx, userID, tc.req)
 
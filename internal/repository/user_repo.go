@@ -3,22 +3,39 @@ package repository
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
+	"github.com/GoodsChain/user/internal/filter"
 	"github.com/GoodsChain/user/internal/models"
-	"github.com/jmoiron/sqlx"
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 )
 
 // UserRepository defines the interface for user data operations
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) (*models.User, error)
-	GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error)
+	GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error)
+	GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error)
+	GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error)
 	UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error)
+	ReplaceUser(ctx context.Context, id uuid.UUID, replacement *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error)
 	DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error)
-	GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams) (*models.GetUsersResponse, error)
+	GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error)
+	GetDirectReports(ctx context.Context, managerID uuid.UUID) ([]models.User, error)
+	GetSubordinates(ctx context.Context, managerID uuid.UUID, maxDepth int) ([]models.UserHierarchyEntry, error)
+	GetManagementChain(ctx context.Context, id uuid.UUID) ([]models.UserHierarchyEntry, error)
+	MergeUsers(ctx context.Context, sourceID uuid.UUID, req *models.MergeUsersRequest) (*models.MergeUsersResponse, error)
+	EraseUser(ctx context.Context, id uuid.UUID) (*models.ErasureResult, error)
+	ExportUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error)
+	StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error
+	ImportUsers(ctx context.Context, rows []models.ImportRow, upsert bool, dryRun bool) (*models.ImportResult, error)
+	GetUserStats(ctx context.Context, filters *models.FilterParams, params *models.StatsParams) (*models.UserStats, error)
 }
 
+// userColumns lists the columns selected for a full user record
+const userColumns = "id, email, full_name, phone, role, is_active, manager_id, merged_into, erased_at, created_at, updated_at"
+
 // postgresUserRepository implements UserRepository for PostgreSQL
 type postgresUserRepository struct {
 	db *sqlx.DB
@@ -32,14 +49,22 @@ func NewPostgresUserRepository(db *sqlx.DB) UserRepository {
 // CreateUser inserts a new user into the database
 func (r *postgresUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
 	user.ID = uuid.New()
-	user.CreatedAt = time.Now()
-	user.UpdatedAt = time.Now()
 	user.IsActive = true // Default to active
 
+	if user.ManagerID != nil {
+		exists, err := r.userExists(ctx, *user.ManagerID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check manager: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("manager not found")
+		}
+	}
+
 	query := `
-		INSERT INTO users (id, email, full_name, phone, role, is_active, created_at, updated_at)
-		VALUES (:id, :email, :full_name, :phone, :role, :is_active, :created_at, :updated_at)
-		RETURNING id, email, full_name, phone, role, is_active, created_at, updated_at
+		INSERT INTO users (id, email, full_name, phone, role, is_active, manager_id)
+		VALUES (:id, :email, :full_name, :phone, :role, :is_active, :manager_id)
+		RETURNING ` + userColumns + `
 	`
 
 	rows, err := r.db.NamedQueryContext(ctx, query, user)
@@ -59,34 +84,59 @@ func (r *postgresUserRepository) CreateUser(ctx context.Context, user *models.Us
 	return nil, fmt.Errorf("no user returned after insert")
 }
 
-// GetUserByID retrieves a user by their ID
-func (r *postgresUserRepository) GetUserByID(ctx context.Context, id uuid.UUID) (*models.User, error) {
+// GetUserByID retrieves a user by their ID, selecting only the given fields if any are provided
+func (r *postgresUserRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	columns, err := selectColumns(fields)
+	if err != nil {
+		return nil, err
+	}
+
 	var user models.User
-	query := "SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = $1"
-	
-	err := r.db.GetContext(ctx, &user, query, id)
+	query := "SELECT " + columns + " FROM users WHERE id = $1"
+
+	err = r.db.GetContext(ctx, &user, query, id)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
 	}
-	
+
 	return &user, nil
 }
 
+// checkUserWritable locks the user for the rest of tx and fails unless it exists and has neither
+// been merged nor erased. Merged and erased users are kept as tombstones and take no new data.
+func checkUserWritable(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	var existing models.User
+	checkQuery := "SELECT id, merged_into, erased_at FROM users WHERE id = $1 FOR UPDATE"
+	if err := tx.GetContext(ctx, &existing, checkQuery, id); err != nil {
+		return fmt.Errorf("user not found: %w", err)
+	}
+	if existing.MergedInto != nil {
+		return fmt.Errorf("user has already been merged")
+	}
+	if existing.ErasedAt != nil {
+		return fmt.Errorf("user has already been erased")
+	}
+	return nil
+}
+
 // UpdateUser updates an existing user in the database
 func (r *postgresUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
-	// First, check if the user exists
-	var existingUser models.User
-	checkQuery := "SELECT id FROM users WHERE id = $1"
-	err := r.db.GetContext(ctx, &existingUser, checkQuery, id)
+	// The manager check and the update share a transaction, so the check still holds when the
+	// update is written
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := checkUserWritable(ctx, tx, id); err != nil {
+		return nil, err
 	}
 
 	// Build dynamic query based on provided fields
 	setParts := []string{}
 	args := map[string]interface{}{
-		"id":         id,
-		"updated_at": time.Now(),
+		"id": id,
 	}
 
 	if updates.Email != nil {
@@ -109,9 +159,16 @@ func (r *postgresUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, u
 		setParts = append(setParts, "is_active = :is_active")
 		args["is_active"] = *updates.IsActive
 	}
+	if updates.ManagerID != nil {
+		if err := r.checkManagerAssignment(ctx, tx, id, *updates.ManagerID); err != nil {
+			return nil, err
+		}
+		setParts = append(setParts, "manager_id = :manager_id")
+		args["manager_id"] = *updates.ManagerID
+	}
 
-	// Always update the updated_at field
-	setParts = append(setParts, "updated_at = :updated_at")
+	// Always update the updated_at field, using the database clock
+	setParts = append(setParts, "updated_at = NOW()")
 
 	if len(setParts) == 1 { // Only updated_at was added
 		return nil, fmt.Errorf("no fields to update")
@@ -130,31 +187,36 @@ func (r *postgresUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, u
 		UPDATE users 
 		SET %s 
 		WHERE id = :id
-		RETURNING id, email, full_name, phone, role, is_active, created_at, updated_at
-	`, setClause)
+		RETURNING %s
+	`, setClause, userColumns)
 
-	rows, err := r.db.NamedQueryContext(ctx, query, args)
+	rows, err := sqlx.NamedQueryContext(ctx, tx, query, args)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update user: %w", err)
 	}
-	defer rows.Close()
 
-	if rows.Next() {
-		var updatedUser models.User
-		if err := rows.StructScan(&updatedUser); err != nil {
-			return nil, fmt.Errorf("failed to scan updated user: %w", err)
-		}
-		return &updatedUser, nil
+	if !rows.Next() {
+		rows.Close()
+		return nil, fmt.Errorf("no user returned after update")
+	}
+	var updatedUser models.User
+	err = rows.StructScan(&updatedUser)
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan updated user: %w", err)
 	}
 
-	return nil, fmt.Errorf("no user returned after update")
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return &updatedUser, nil
 }
 
 // DeleteUser deletes a user from the database
 func (r *postgresUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
 	// First, get the user data before deletion to return it
 	var userToDelete models.User
-	selectQuery := "SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users WHERE id = $1"
+	selectQuery := "SELECT " + userColumns + " FROM users WHERE id = $1"
 	err := r.db.GetContext(ctx, &userToDelete, selectQuery, id)
 	if err != nil {
 		return nil, fmt.Errorf("user not found: %w", err)
@@ -179,47 +241,58 @@ func (r *postgresUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) (
 	return &userToDelete, nil
 }
 
-// GetAllUsers retrieves users with filtering, sorting, and pagination
-func (r *postgresUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams) (*models.GetUsersResponse, error) {
+// GetAllUsers retrieves users with filtering, sorting, and pagination, selecting only the given fields if any are provided
+func (r *postgresUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	columns, err := selectColumns(fields)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build the base query
-	baseQuery := "SELECT id, email, full_name, phone, role, is_active, created_at, updated_at FROM users"
+	baseQuery := "SELECT " + columns + " FROM users"
 	countQuery := "SELECT COUNT(*) FROM users"
-	
+
 	// Build WHERE clause and arguments
-	whereClause, args := r.buildWhereClause(filters)
+	whereClause, args, err := r.buildWhereClause(filters)
+	if err != nil {
+		return nil, err
+	}
 	if whereClause != "" {
 		baseQuery += " WHERE " + whereClause
 		countQuery += " WHERE " + whereClause
 	}
-	
+
+	// Add ORDER BY clause
+	orderClause, err := r.buildOrderClause(sort)
+	if err != nil {
+		return nil, err
+	}
+	baseQuery += " " + orderClause
+
 	// Get total count for pagination
 	var total int
-	err := r.db.GetContext(ctx, &total, countQuery, args...)
+	err = r.db.GetContext(ctx, &total, countQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get total count: %w", err)
 	}
-	
-	// Add ORDER BY clause
-	orderClause := r.buildOrderClause(sort)
-	baseQuery += " " + orderClause
-	
+
 	// Add LIMIT and OFFSET for pagination
 	baseQuery += " LIMIT $" + fmt.Sprintf("%d", len(args)+1) + " OFFSET $" + fmt.Sprintf("%d", len(args)+2)
 	args = append(args, pagination.PageSize, pagination.Offset)
-	
+
 	// Execute query
 	var users []models.User
 	err = r.db.SelectContext(ctx, &users, baseQuery, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users: %w", err)
 	}
-	
+
 	// Calculate pagination metadata
 	totalPages := (total + pagination.PageSize - 1) / pagination.PageSize
 	if totalPages == 0 {
 		totalPages = 1
 	}
-	
+
 	paginationMeta := models.PaginationMetadata{
 		Page:       pagination.Page,
 		PageSize:   pagination.PageSize,
@@ -228,7 +301,7 @@ func (r *postgresUserRepository) GetAllUsers(ctx context.Context, filters *model
 		HasNext:    pagination.Page < totalPages,
 		HasPrev:    pagination.Page > 1,
 	}
-	
+
 	return &models.GetUsersResponse{
 		Data:       users,
 		Pagination: paginationMeta,
@@ -236,67 +309,116 @@ func (r *postgresUserRepository) GetAllUsers(ctx context.Context, filters *model
 }
 
 // buildWhereClause constructs the WHERE clause and returns the clause and arguments
-func (r *postgresUserRepository) buildWhereClause(filters *models.FilterParams) (string, []interface{}) {
+func (r *postgresUserRepository) buildWhereClause(filters *models.FilterParams) (string, []interface{}, error) {
 	var conditions []string
 	var args []interface{}
 	argCount := 0
-	
+
 	if filters == nil {
-		return "", args
+		return "", args, nil
 	}
-	
+
 	if filters.Role != nil {
 		argCount++
 		conditions = append(conditions, fmt.Sprintf("role = $%d", argCount))
 		args = append(args, *filters.Role)
 	}
-	
+
 	if filters.IsActive != nil {
 		argCount++
 		conditions = append(conditions, fmt.Sprintf("is_active = $%d", argCount))
 		args = append(args, *filters.IsActive)
 	}
-	
+
 	if filters.Search != nil && *filters.Search != "" {
 		argCount++
 		conditions = append(conditions, fmt.Sprintf("(LOWER(full_name) LIKE LOWER($%d) OR LOWER(email) LIKE LOWER($%d))", argCount, argCount))
 		args = append(args, "%"+*filters.Search+"%")
 	}
-	
+
 	if filters.EmailDomain != nil && *filters.EmailDomain != "" {
 		argCount++
 		conditions = append(conditions, fmt.Sprintf("email LIKE $%d", argCount))
 		args = append(args, "%@"+*filters.EmailDomain+"%")
 	}
-	
+
 	if filters.CreatedFrom != nil {
 		argCount++
 		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", argCount))
 		args = append(args, *filters.CreatedFrom)
 	}
-	
+
 	if filters.CreatedTo != nil {
 		argCount++
-		conditions = append(conditions, fmt.Sprintf("created_at <= $%d", argCount))
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", argCount))
 		args = append(args, *filters.CreatedTo)
 	}
-	
+
 	if filters.UpdatedFrom != nil {
 		argCount++
 		conditions = append(conditions, fmt.Sprintf("updated_at >= $%d", argCount))
 		args = append(args, *filters.UpdatedFrom)
 	}
-	
+
 	if filters.UpdatedTo != nil {
 		argCount++
-		conditions = append(conditions, fmt.Sprintf("updated_at <= $%d", argCount))
+		conditions = append(conditions, fmt.Sprintf("updated_at < $%d", argCount))
 		args = append(args, *filters.UpdatedTo)
 	}
-	
+
+	if filters.ManagerID != nil {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf("manager_id = $%d", argCount))
+		args = append(args, *filters.ManagerID)
+	}
+
+	if filters.UnderManager != nil {
+		argCount++
+		conditions = append(conditions, fmt.Sprintf(`id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id, 1 AS depth FROM users WHERE manager_id = $%d
+				UNION ALL
+				SELECT u.id, s.depth + 1 FROM users u JOIN subtree s ON u.manager_id = s.id WHERE s.depth < %d
+			)
+			SELECT id FROM subtree
+		)`, argCount, maxHierarchyDepth))
+		args = append(args, *filters.UnderManager)
+	}
+
+	for _, condition := range filters.Conditions {
+		clause, clauseArgs, err := buildCondition(condition, argCount)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, clause)
+		args = append(args, clauseArgs...)
+		argCount += len(clauseArgs)
+	}
+
+	for _, group := range filters.AnyOf {
+		clause, clauseArgs, err := buildAnyOf(group, argCount)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, clause)
+		args = append(args, clauseArgs...)
+		argCount += len(clauseArgs)
+	}
+
+	if filters.Expression != nil {
+		clause, clauseArgs, err := filter.ToSQL(filters.Expression, models.UserFilterSchema, argCount)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions = append(conditions, clause)
+		args = append(args, clauseArgs...)
+		argCount += len(clauseArgs)
+	}
+
 	if len(conditions) == 0 {
-		return "", args
+		return "", args, nil
 	}
-	
+
 	whereClause := ""
 	for i, condition := range conditions {
 		if i > 0 {
@@ -304,36 +426,68 @@ func (r *postgresUserRepository) buildWhereClause(filters *models.FilterParams)
 		}
 		whereClause += condition
 	}
-	
-	return whereClause, args
+
+	return whereClause, args, nil
 }
 
-// buildOrderClause constructs the ORDER BY clause
-func (r *postgresUserRepository) buildOrderClause(sort *models.SortParams) string {
-	if sort == nil {
-		return "ORDER BY created_at ASC" // Default sorting
-	}
-	
-	// Map field names to database column names
-	fieldMap := map[string]string{
-		"id":         "id",
-		"email":      "email",
-		"full_name":  "full_name",
-		"role":       "role",
-		"is_active":  "is_active",
-		"created_at": "created_at",
-		"updated_at": "updated_at",
-	}
-	
-	field, exists := fieldMap[sort.Field]
-	if !exists {
-		field = "created_at" // Default field
-	}
-	
-	order := "ASC"
-	if sort.Order == "desc" {
-		order = "DESC"
-	}
-	
-	return fmt.Sprintf("ORDER BY %s %s", field, order)
+// sortColumns lists the columns users can be sorted by, and whether each one is nullable
+var sortColumns = map[string]bool{
+	"id":         false,
+	"email":      false,
+	"full_name":  false,
+	"phone":      true,
+	"role":       false,
+	"is_active":  false,
+	"manager_id": true,
+	"created_at": false,
+	"updated_at": false,
+}
+
+// buildOrderClause constructs the ORDER BY clause, always ending with id as a tie-breaker
+// so that rows with equal sort keys keep a stable order across pages
+func (r *postgresUserRepository) buildOrderClause(sort *models.SortParams) (string, error) {
+	keys := []models.SortField{{Field: "created_at", Order: "asc"}} // Default sorting
+	if sort != nil && len(sort.Fields) > 0 {
+		keys = sort.Fields
+	} else if sort != nil && sort.Field != "" {
+		keys = []models.SortField{{Field: sort.Field, Order: sort.Order}}
+	}
+
+	var parts []string
+	hasID := false
+	for _, key := range keys {
+		nullable, ok := sortColumns[key.Field]
+		if !ok {
+			return "", fmt.Errorf("invalid sort field: %q", key.Field)
+		}
+
+		part := key.Field + " ASC"
+		switch key.Order {
+		case "", "asc":
+		case "desc":
+			part = key.Field + " DESC"
+		default:
+			return "", fmt.Errorf("invalid sort order: %q", key.Order)
+		}
+
+		switch key.Nulls {
+		case "":
+		case "first", "last":
+			if !nullable {
+				return "", fmt.Errorf("invalid sort: %s is never null", key.Field)
+			}
+			part += " NULLS " + strings.ToUpper(key.Nulls)
+		default:
+			return "", fmt.Errorf("invalid sort nulls ordering: %q", key.Nulls)
+		}
+
+		parts = append(parts, part)
+		hasID = hasID || key.Field == "id"
+	}
+
+	if !hasID {
+		parts = append(parts, "id ASC")
+	}
+
+	return "ORDER BY " + strings.Join(parts, ", "), nil
 }
@@ -1,24 +1,54 @@
 package router
 
 import (
+	"net/http"
+
 	"github.com/GoodsChain/user/internal/handler"
+	"github.com/GoodsChain/user/internal/openapi"
 	"github.com/gin-gonic/gin"
 )
 
-// SetupRouter sets up all the API routes
-func SetupRouter(userHandler *handler.UserHandler) *gin.Engine {
+// SetupRouter sets up all the API routes. apiMiddleware, such as authentication, runs before the
+// handlers of the REST and GraphQL APIs; the OpenAPI document and its UI stay public.
+func SetupRouter(userHandler *handler.UserHandler, apiKeyHandler *handler.APIKeyHandler, graphqlHandler http.Handler, apiMiddleware ...gin.HandlerFunc) *gin.Engine {
 	r := gin.Default()
+	r.Use(handler.RequestID())
+
+	r.GET("/openapi.json", openapi.ServeSpec)
+	r.GET("/docs", openapi.ServeDocs)
+
+	api := r.Group("/", apiMiddleware...)
+	api.POST("/graphql", gin.WrapH(graphqlHandler))
 
 	// API group for /api/v1
-	v1 := r.Group("/api/v1")
+	v1 := api.Group("/api/v1")
 	{
 		users := v1.Group("/users")
 		{
 			users.GET("/", userHandler.GetAllUsers)
-			users.POST("/", userHandler.CreateUser)
+			users.POST("/", userHandler.Idempotent(), userHandler.CreateUser)
+			users.GET("/export", userHandler.ExportUsers)
+			users.POST("/import", userHandler.Idempotent(), userHandler.ImportUsers)
+			users.GET("/stats", userHandler.GetUserStats)
+			users.GET("/by-email/:email", userHandler.GetUserByEmail)
+			users.POST("/batch-get", userHandler.BatchGetUsers)
 			users.GET("/:id", userHandler.GetUserByID)
-			users.PATCH("/:id", userHandler.UpdateUser)
-			users.DELETE("/:id", userHandler.DeleteUser)
+			users.PATCH("/:id", userHandler.Idempotent(), userHandler.UpdateUser)
+			users.PUT("/:id", userHandler.Idempotent(), userHandler.ReplaceUser)
+			users.DELETE("/:id", userHandler.Idempotent(), userHandler.DeleteUser)
+			users.GET("/:id/reports", userHandler.GetDirectReports)
+			users.GET("/:id/subordinates", userHandler.GetSubordinates)
+			users.GET("/:id/chain", userHandler.GetManagementChain)
+			users.POST("/:id/merge", userHandler.Idempotent(), userHandler.MergeUser)
+			users.POST("/:id/erase", userHandler.Idempotent(), userHandler.EraseUser)
+			users.GET("/:id/export", userHandler.ExportUserData)
+		}
+
+		apiKeys := v1.Group("/api-keys")
+		{
+			apiKeys.GET("/", apiKeyHandler.ListAPIKeys)
+			apiKeys.POST("/", apiKeyHandler.CreateAPIKey)
+			apiKeys.DELETE("/:id", apiKeyHandler.RevokeAPIKey)
 		}
 	}
 
@@ -0,0 +1,354 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/graphqlserver"
+	"github.com/GoodsChain/user/internal/handler"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/openapi"
+	"github.com/GoodsChain/user/internal/receipt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var pathParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// TestSetupRouter_MatchesOpenAPISpec tests that every API route is documented in openapi.json and
+// that the document describes no route that does not exist
+func TestSetupRouter_MatchesOpenAPISpec(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter(handler.NewUserHandler(nil), handler.NewAPIKeyHandler(nil), graphqlserver.NewHandler(nil))
+
+	var routes []string
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, "/api/") {
+			routes = append(routes, route.Method+" "+pathParam.ReplaceAllString(route.Path, "{$1}"))
+		}
+	}
+	sort.Strings(routes)
+
+	var document openapi.Document
+	require.NoError(t, json.Unmarshal(openapi.Spec, &document))
+	var documented []string
+	for path, item := range document.Paths {
+		for method := range item {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(documented)
+
+	assert.Equal(t, routes, documented, "routes and openapi.json differ; update internal/openapi and run `make openapi`")
+}
+
+// TestSetupRouter_ServesOpenAPI tests that the document and its Swagger UI page are served
+func TestSetupRouter_ServesOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := SetupRouter(handler.NewUserHandler(nil), handler.NewAPIKeyHandler(nil), graphqlserver.NewHandler(nil))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, openapi.Spec, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+}
+
+// TestSetupRouter_APIMiddleware tests that the API middleware guards the REST and GraphQL APIs but
+// not the OpenAPI document
+func TestSetupRouter_APIMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	deny := func(c *gin.Context) { c.AbortWithStatus(http.StatusUnauthorized) }
+	r := SetupRouter(handler.NewUserHandler(nil), handler.NewAPIKeyHandler(nil), graphqlserver.NewHandler(nil), deny)
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/api/v1/users/", nil),
+		httptest.NewRequest("DELETE", "/api/v1/users/"+uuid.NewString(), nil),
+		httptest.NewRequest("POST", "/graphql", strings.NewReader(`{"query": "{ users { totalCount } }"}`)),
+	} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusUnauthorized, w.Code, "%s %s", req.Method, req.URL.Path)
+	}
+
+	for _, path := range []string{"/openapi.json", "/docs"} {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+		assert.Equal(t, http.StatusOK, w.Code, path)
+	}
+}
+
+// stubUserRepository finds the users it holds and fails everything else, so that a request the
+// policy allows gets past it without changing anything
+type stubUserRepository struct {
+	users []*models.User
+}
+
+var errStub = errors.New("not implemented by the stub")
+
+func (r *stubUserRepository) find(match func(*models.User) bool) (*models.User, error) {
+	for _, user := range r.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return nil, fmt.Errorf("user not found")
+}
+
+func (r *stubUserRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return user.ID == id })
+}
+
+func (r *stubUserRepository) GetUserByEmail(ctx context.Context, email string, fields []string) (*models.User, error) {
+	return r.find(func(user *models.User) bool { return strings.EqualFold(user.Email, email) })
+}
+
+func (r *stubUserRepository) GetUsersByIDs(ctx context.Context, ids []uuid.UUID, fields []string) ([]models.User, error) {
+	var users []models.User
+	for _, id := range ids {
+		if user, err := r.GetUserByID(ctx, id, fields); err == nil {
+			users = append(users, *user)
+		}
+	}
+	return users, nil
+}
+
+func (r *stubUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) ReplaceUser(ctx context.Context, id uuid.UUID, replacement *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) DeleteUser(ctx context.Context, id uuid.UUID) (*models.User, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) GetDirectReports(ctx context.Context, managerID uuid.UUID) ([]models.User, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) GetSubordinates(ctx context.Context, managerID uuid.UUID, maxDepth int) ([]models.UserHierarchyEntry, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) GetManagementChain(ctx context.Context, id uuid.UUID) ([]models.UserHierarchyEntry, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) MergeUsers(ctx context.Context, sourceID uuid.UUID, req *models.MergeUsersRequest) (*models.MergeUsersResponse, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) EraseUser(ctx context.Context, id uuid.UUID) (*models.ErasureResult, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) ExportUserData(ctx context.Context, id uuid.UUID) (*models.UserDataExport, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error {
+	return errStub
+}
+
+func (r *stubUserRepository) ImportUsers(ctx context.Context, rows []models.ImportRow, upsert bool, dryRun bool) (*models.ImportResult, error) {
+	return nil, errStub
+}
+
+func (r *stubUserRepository) GetUserStats(ctx context.Context, filters *models.FilterParams, params *models.StatsParams) (*models.UserStats, error) {
+	return nil, errStub
+}
+
+// stubAPIKeyRepository lists no keys and fails everything else
+type stubAPIKeyRepository struct{}
+
+func (r *stubAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	return nil, errStub
+}
+
+func (r *stubAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	return nil, errStub
+}
+
+func (r *stubAPIKeyRepository) ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error) {
+	return []models.APIKey{}, nil
+}
+
+func (r *stubAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	return nil, errStub
+}
+
+func (r *stubAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	return errStub
+}
+
+// TestSetupRouter_Authorization tests which roles may use each API route. Every route of the REST
+// and GraphQL APIs must have a case.
+func TestSetupRouter_Authorization(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	newUser := func(role string) *models.User {
+		id := uuid.New()
+		return &models.User{ID: id, Email: id.String() + "@example.com", FullName: role, Role: role, IsActive: true}
+	}
+	admin, staff, supplier, other := newUser(models.RoleAdmin), newUser(models.RoleStaff), newUser(models.RoleSupplier), newUser(models.RoleSupplier)
+	callers := map[string]*models.User{"admin": admin, "staff": staff, "supplier": supplier}
+
+	// The caller is named by a header rather than a token
+	identify := func(c *gin.Context) {
+		caller := callers[c.GetHeader("X-Caller")]
+		c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), &auth.Principal{Subject: caller.ID.String()}))
+	}
+	users := &stubUserRepository{users: []*models.User{admin, staff, supplier, other}}
+	userRepo := authz.NewUserRepository(users)
+	userHandler := handler.NewUserHandler(userRepo, handler.WithReceiptSigner(receipt.NewSigner([]byte("secret"))))
+	apiKeyHandler := handler.NewAPIKeyHandler(authz.NewAPIKeyRepository(&stubAPIKeyRepository{}, users))
+	r := SetupRouter(userHandler, apiKeyHandler, graphqlserver.NewHandler(userRepo), identify)
+
+	everyone := []string{"admin", "staff", "supplier"}
+	readers := []string{"admin", "staff"}
+	admins := []string{"admin"}
+	replacement := func(user *models.User, role string, active bool) string {
+		return fmt.Sprintf(`{"email": %q, "full_name": "Renamed", "role": %q, "is_active": %t}`, user.Email, role, active)
+	}
+	query := func(user *models.User) string {
+		return fmt.Sprintf(`{"query": "{ user(id: \"%s\") { id } }"}`, user.ID)
+	}
+
+	tests := []struct {
+		name    string
+		method  string
+		route   string
+		path    string
+		body    string
+		allowed []string
+	}{
+		{"list users", "GET", "/api/v1/users/", "/api/v1/users/", "", readers},
+		{"create user", "POST", "/api/v1/users/", "/api/v1/users/", `{"email": "new@example.com", "full_name": "New", "role": "supplier"}`, admins},
+		{"export users", "GET", "/api/v1/users/export", "/api/v1/users/export", "", readers},
+		{"import users", "POST", "/api/v1/users/import", "/api/v1/users/import", "email,full_name,role\nnew@example.com,New,supplier\n", admins},
+		{"user statistics", "GET", "/api/v1/users/stats", "/api/v1/users/stats", "", readers},
+		{"look up own email", "GET", "/api/v1/users/by-email/:email", "/api/v1/users/by-email/" + supplier.Email, "", everyone},
+		{"look up other email", "GET", "/api/v1/users/by-email/:email", "/api/v1/users/by-email/" + other.Email, "", readers},
+		{"batch get own record", "POST", "/api/v1/users/batch-get", "/api/v1/users/batch-get", fmt.Sprintf(`{"ids": [%q]}`, supplier.ID), everyone},
+		{"batch get own and other records", "POST", "/api/v1/users/batch-get", "/api/v1/users/batch-get", fmt.Sprintf(`{"ids": [%q, %q]}`, supplier.ID, other.ID), everyone},
+		{"batch get other users", "POST", "/api/v1/users/batch-get", "/api/v1/users/batch-get", fmt.Sprintf(`{"ids": [%q]}`, other.ID), readers},
+		{"get own record", "GET", "/api/v1/users/:id", "/api/v1/users/" + supplier.ID.String(), "", everyone},
+		{"get other user", "GET", "/api/v1/users/:id", "/api/v1/users/" + other.ID.String(), "", readers},
+		{"update own record", "PATCH", "/api/v1/users/:id", "/api/v1/users/" + supplier.ID.String(), `{"full_name": "Renamed"}`, everyone},
+		{"change own role", "PATCH", "/api/v1/users/:id", "/api/v1/users/" + supplier.ID.String(), `{"role": "staff"}`, readers},
+		{"deactivate own record", "PATCH", "/api/v1/users/:id", "/api/v1/users/" + supplier.ID.String(), `{"is_active": false}`, readers},
+		{"update other user", "PATCH", "/api/v1/users/:id", "/api/v1/users/" + other.ID.String(), `{"full_name": "Renamed"}`, readers},
+		{"promote to admin", "PATCH", "/api/v1/users/:id", "/api/v1/users/" + other.ID.String(), `{"role": "admin"}`, admins},
+		{"update admin", "PATCH", "/api/v1/users/:id", "/api/v1/users/" + admin.ID.String(), `{"full_name": "Renamed"}`, admins},
+		{"replace own record", "PUT", "/api/v1/users/:id", "/api/v1/users/" + supplier.ID.String(), replacement(supplier, "supplier", true), everyone},
+		{"replace own activity", "PUT", "/api/v1/users/:id", "/api/v1/users/" + supplier.ID.String(), replacement(supplier, "supplier", false), readers},
+		{"replace other user", "PUT", "/api/v1/users/:id", "/api/v1/users/" + other.ID.String(), replacement(other, "staff", true), readers},
+		{"replace admin", "PUT", "/api/v1/users/:id", "/api/v1/users/" + admin.ID.String(), replacement(admin, "admin", true), admins},
+		{"delete user", "DELETE", "/api/v1/users/:id", "/api/v1/users/" + other.ID.String(), "", admins},
+		{"direct reports", "GET", "/api/v1/users/:id/reports", "/api/v1/users/" + supplier.ID.String() + "/reports", "", readers},
+		{"subordinates", "GET", "/api/v1/users/:id/subordinates", "/api/v1/users/" + supplier.ID.String() + "/subordinates", "", readers},
+		{"management chain", "GET", "/api/v1/users/:id/chain", "/api/v1/users/" + supplier.ID.String() + "/chain", "", readers},
+		{"merge user", "POST", "/api/v1/users/:id/merge", "/api/v1/users/" + other.ID.String() + "/merge", fmt.Sprintf(`{"target_id": %q}`, supplier.ID), admins},
+		{"erase user", "POST", "/api/v1/users/:id/erase", "/api/v1/users/" + other.ID.String() + "/erase", "", admins},
+		{"export own data", "GET", "/api/v1/users/:id/export", "/api/v1/users/" + supplier.ID.String() + "/export", "", everyone},
+		{"export other user's data", "GET", "/api/v1/users/:id/export", "/api/v1/users/" + other.ID.String() + "/export", "", readers},
+		{"query own record", "POST", "/graphql", "/graphql", query(supplier), everyone},
+		{"query other user", "POST", "/graphql", "/graphql", query(other), readers},
+		{"query users", "POST", "/graphql", "/graphql", `{"query": "{ users { totalCount } }"}`, readers},
+		{"list api keys", "GET", "/api/v1/api-keys/", "/api/v1/api-keys/", "", admins},
+		{"create api key", "POST", "/api/v1/api-keys/", "/api/v1/api-keys/", `{"name": "nightly sync", "service": "erp-sync", "scopes": ["users:read"]}`, admins},
+		{"revoke api key", "DELETE", "/api/v1/api-keys/:id", "/api/v1/api-keys/" + uuid.NewString(), "", admins},
+	}
+
+	covered := map[string]bool{}
+	for _, tt := range tests {
+		covered[tt.method+" "+tt.route] = true
+		for _, caller := range everyone {
+			t.Run(tt.name+"/"+caller, func(t *testing.T) {
+				req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+				req.Header.Set("X-Caller", caller)
+				if tt.method != "GET" && tt.method != "DELETE" {
+					req.Header.Set("Content-Type", "application/json")
+				}
+				if strings.HasSuffix(tt.path, "/import") {
+					req.Header.Set("Content-Type", "text/csv")
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				status := w.Code
+				if tt.route == "/graphql" {
+					status = graphqlStatus(t, w)
+				}
+
+				allowed := false
+				for _, role := range tt.allowed {
+					allowed = allowed || role == caller
+				}
+				if allowed {
+					assert.NotEqual(t, http.StatusForbidden, status, w.Body.String())
+					assert.NotEqual(t, http.StatusBadRequest, status, "the request must reach the policy: %s", w.Body.String())
+				} else {
+					assert.Equal(t, http.StatusForbidden, status, w.Body.String())
+				}
+			})
+		}
+	}
+
+	for _, route := range r.Routes() {
+		if strings.HasPrefix(route.Path, "/api/") || route.Path == "/graphql" {
+			assert.True(t, covered[route.Method+" "+route.Path], "no authorization case for %s %s", route.Method, route.Path)
+		}
+	}
+}
+
+// graphqlStatus returns the status of the REST problem equivalent to a GraphQL response, which is
+// always answered with 200 and reports denials and invalid queries in its errors
+func graphqlStatus(t *testing.T, w *httptest.ResponseRecorder) int {
+	t.Helper()
+	var response struct {
+		Errors []struct {
+			Extensions struct {
+				Code string `json:"code"`
+			} `json:"extensions"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response), w.Body.String())
+	for _, e := range response.Errors {
+		switch e.Extensions.Code {
+		case models.CodeForbidden:
+			return http.StatusForbidden
+		case "":
+			return http.StatusBadRequest
+		}
+	}
+	return w.Code
+}
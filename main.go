@@ -1,14 +1,26 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"log"
+	"net"
 	"net/http"
+	"time"
+	_ "time/tzdata" // the tz query parameter must not depend on the host's zoneinfo
 
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/authz"
 	"github.com/GoodsChain/user/internal/config"
 	"github.com/GoodsChain/user/internal/db"
+	"github.com/GoodsChain/user/internal/graphqlserver"
+	"github.com/GoodsChain/user/internal/grpcserver"
 	"github.com/GoodsChain/user/internal/handler"
+	"github.com/GoodsChain/user/internal/receipt"
 	"github.com/GoodsChain/user/internal/repository"
 	"github.com/GoodsChain/user/internal/router"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/grpc"
 )
 
 func main() {
@@ -24,14 +36,82 @@ func main() {
 	}
 	defer db.Close()
 
-	// Initialize repository and handler
-	userRepo := repository.NewPostgresUserRepository(db)
-	userHandler := handler.NewUserHandler(userRepo)
+	// Initialize repository and handler. Every API reads and writes users through the
+	// authorization policy.
+	users := repository.NewPostgresUserRepository(db)
+	userRepo := authz.NewUserRepository(users)
+	receiptSecret := []byte(cfg.ErasureReceiptSecret)
+	if len(receiptSecret) == 0 {
+		log.Printf("Warning: ERASURE_RECEIPT_SECRET is not set, erasure receipts will not verify after a restart")
+		receiptSecret = make([]byte, 32)
+		if _, err := rand.Read(receiptSecret); err != nil {
+			log.Fatalf("Error generating erasure receipt secret: %v", err)
+		}
+	}
+	idempotencyStore := repository.NewPostgresIdempotencyStore(db)
+	go purgeIdempotencyKeys(idempotencyStore, cfg.IdempotencyKeyTTL)
+
+	receiptSigner := receipt.NewSigner(receiptSecret)
+	userHandler := handler.NewUserHandler(userRepo,
+		handler.WithReceiptSigner(receiptSigner),
+		handler.WithStatsCacheTTL(cfg.StatsCacheTTL),
+		handler.WithIdempotencyStore(idempotencyStore, cfg.IdempotencyKeyTTL),
+	)
+
+	apiKeyRepo := repository.NewPostgresAPIKeyRepository(db)
+	apiKeyHandler := handler.NewAPIKeyHandler(authz.NewAPIKeyRepository(apiKeyRepo, users))
+
+	// Authenticate callers with bearer tokens from the configured issuer or with API keys
+	var authenticators []auth.Authenticator
+	if cfg.AuthDisabled {
+		log.Printf("Warning: AUTH_DISABLED is set, the API accepts unauthenticated requests")
+	} else {
+		keySet, err := auth.NewKeySet(context.Background(), cfg.AuthJWKS, auth.WithRefreshInterval(cfg.AuthJWKSRefreshInterval))
+		if err != nil {
+			log.Fatalf("Error loading JWKS: %v", err)
+		}
+		authenticators = append(authenticators,
+			auth.NewJWTVerifier(keySet, cfg.AuthIssuer, cfg.AuthAudience),
+			auth.NewAPIKeyAuthenticator(apiKeyRepo),
+		)
+	}
+
+	// Start the gRPC server, which shares the repository with the REST API
+	grpcListener, err := net.Listen("tcp", ":"+cfg.GRPCPort)
+	if err != nil {
+		log.Fatalf("Error listening on gRPC port: %v", err)
+	}
+	var grpcOpts []grpc.ServerOption
+	if len(authenticators) > 0 {
+		grpcOpts = append(grpcOpts, grpc.UnaryInterceptor(grpcserver.AuthInterceptor(authenticators...)))
+	}
+	go func() {
+		log.Printf("gRPC server starting on port %s", cfg.GRPCPort)
+		log.Fatal(grpcserver.NewServer(userRepo, grpcOpts...).Serve(grpcListener))
+	}()
+
+	graphqlHandler := graphqlserver.NewHandler(userRepo, graphqlserver.WithReceiptSigner(receiptSigner))
 
 	// Setup router
-	r := router.SetupRouter(userHandler)
+	var apiMiddleware []gin.HandlerFunc
+	if len(authenticators) > 0 {
+		apiMiddleware = append(apiMiddleware, handler.Authenticate(authenticators...))
+	}
+	r := router.SetupRouter(userHandler, apiKeyHandler, graphqlHandler, apiMiddleware...)
 
 	// Start the server
 	log.Printf("Server starting on port %s", cfg.Port)
 	log.Fatal(http.ListenAndServe(":"+cfg.Port, r))
 }
+
+// purgeIdempotencyKeys periodically deletes expired idempotency keys
+func purgeIdempotencyKeys(store repository.IdempotencyStore, ttl time.Duration) {
+	ticker := time.NewTicker(min(ttl, time.Hour))
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if _, err := store.PurgeExpired(context.Background()); err != nil {
+			log.Printf("Error purging idempotency keys: %v", err)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package userclient
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+)
+
+// Request and response types of API key management
+type (
+	APIKey               = models.APIKey
+	CreateAPIKeyRequest  = models.CreateAPIKeyRequest
+	CreateAPIKeyResponse = models.CreateAPIKeyResponse
+	ListAPIKeysRequest   = models.ListAPIKeysRequest
+	ListAPIKeysResponse  = models.ListAPIKeysResponse
+)
+
+const apiKeysPath = "/api/v1/api-keys"
+
+// CreateAPIKey creates an API key for a service. The secret key is only returned here.
+func (c *Client) CreateAPIKey(ctx context.Context, req *CreateAPIKeyRequest) (*CreateAPIKeyResponse, error) {
+	r, err := jsonRequest(http.MethodPost, apiKeysPath+"/", req)
+	if err != nil {
+		return nil, err
+	}
+	var response CreateAPIKeyResponse
+	if err := c.do(ctx, r, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListAPIKeys returns the API keys, without their secrets
+func (c *Client) ListAPIKeys(ctx context.Context, req *ListAPIKeysRequest) ([]APIKey, error) {
+	var response ListAPIKeysResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: apiKeysPath + "/", query: encodeQuery(req)}, &response); err != nil {
+		return nil, err
+	}
+	return response.APIKeys, nil
+}
+
+// RevokeAPIKey revokes an API key
+func (c *Client) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*APIKey, error) {
+	var key APIKey
+	if err := c.do(ctx, &request{method: http.MethodDelete, path: apiKeysPath + "/" + id.String()}, &key); err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
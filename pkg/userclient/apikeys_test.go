@@ -0,0 +1,144 @@
+package userclient
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/auth"
+	"github.com/GoodsChain/user/internal/authz"
+	"github.com/GoodsChain/user/internal/graphqlserver"
+	"github.com/GoodsChain/user/internal/handler"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/router"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAPIKeyRepository keeps API keys in memory
+type memoryAPIKeyRepository struct {
+	mu   sync.Mutex
+	keys []*models.APIKey
+}
+
+func (r *memoryAPIKeyRepository) CreateAPIKey(ctx context.Context, key *models.APIKey) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored := *key
+	stored.ID, stored.CreatedAt = uuid.New(), time.Now()
+	r.keys = append(r.keys, &stored)
+	return &stored, nil
+}
+
+func (r *memoryAPIKeyRepository) GetAPIKeyByPrefix(ctx context.Context, prefix string) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			stored := *key
+			return &stored, nil
+		}
+	}
+	return nil, fmt.Errorf("api key not found")
+}
+
+func (r *memoryAPIKeyRepository) ListAPIKeys(ctx context.Context, req *models.ListAPIKeysRequest) ([]models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	keys := []models.APIKey{}
+	for _, key := range r.keys {
+		if (req.Service == nil || key.Service == *req.Service) && (req.IncludeRevoked || key.RevokedAt == nil) {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *memoryAPIKeyRepository) RevokeAPIKey(ctx context.Context, id uuid.UUID) (*models.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.ID == id {
+			if key.RevokedAt == nil {
+				now := time.Now()
+				key.RevokedAt = &now
+			}
+			stored := *key
+			return &stored, nil
+		}
+	}
+	return nil, fmt.Errorf("api key not found")
+}
+
+func (r *memoryAPIKeyRepository) TouchAPIKey(ctx context.Context, id uuid.UUID, usedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.ID == id {
+			key.LastUsedAt = &usedAt
+		}
+	}
+	return nil
+}
+
+// TestAPIKeys tests managing API keys with a client that authenticates with one
+func TestAPIKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	repo := &memoryAPIKeyRepository{}
+	server := httptest.NewServer(router.SetupRouter(
+		handler.NewUserHandler(&mockUserRepository{}),
+		handler.NewAPIKeyHandler(authz.NewAPIKeyRepository(repo, &mockUserRepository{})),
+		graphqlserver.NewHandler(nil),
+		handler.Authenticate(auth.NewAPIKeyAuthenticator(repo)),
+	))
+	t.Cleanup(server.Close)
+
+	adminKey, prefix, hash, err := auth.GenerateAPIKey()
+	require.NoError(t, err)
+	_, err = repo.CreateAPIKey(context.Background(), &models.APIKey{
+		Name: "bootstrap", Service: "ops", Prefix: prefix, KeyHash: hash, Scopes: []string{auth.ScopeAPIKeysManage},
+	})
+	require.NoError(t, err)
+
+	anonymous, err := New(server.URL, WithRetries(0))
+	require.NoError(t, err)
+	_, err = anonymous.ListAPIKeys(context.Background(), nil)
+	assert.Equal(t, CodeUnauthenticated, ErrorCode(err))
+
+	admin, err := New(server.URL, WithAPIKey(adminKey))
+	require.NoError(t, err)
+
+	created, err := admin.CreateAPIKey(context.Background(), &CreateAPIKeyRequest{
+		Name: "nightly sync", Service: "erp-sync", Scopes: []string{"users:read"},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, created.Key)
+	assert.Equal(t, []string{"users:read"}, created.Scopes)
+
+	keys, err := admin.ListAPIKeys(context.Background(), &ListAPIKeysRequest{Service: &created.Service})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, created.Prefix, keys[0].Prefix)
+
+	// The new key authenticates, but lacks the scope to manage keys
+	service, err := New(server.URL, WithAPIKey(created.Key))
+	require.NoError(t, err)
+	_, err = service.ListAPIKeys(context.Background(), nil)
+	assert.Equal(t, CodeForbidden, ErrorCode(err))
+
+	revoked, err := admin.RevokeAPIKey(context.Background(), created.ID)
+	require.NoError(t, err)
+	assert.NotNil(t, revoked.RevokedAt)
+
+	_, err = service.ListAPIKeys(context.Background(), nil)
+	assert.Equal(t, CodeUnauthenticated, ErrorCode(err), "revoked keys are rejected")
+
+	_, err = admin.RevokeAPIKey(context.Background(), uuid.New())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, CodeAPIKeyNotFound, ErrorCode(err))
+}
@@ -0,0 +1,213 @@
+// Package userclient is the Go client of the user service's REST API.
+//
+// It sends the service's own request and response types, which this package re-exports, so
+// callers and the server cannot drift apart. Error responses are returned as *Error, which matches
+// ErrNotFound, ErrConflict and ErrValidation with errors.Is. Requests answered with 429 or a 5xx
+// status are retried with exponential backoff. Reads are always retried; changes to users carry a
+// generated Idempotency-Key, which the server honours, so a retry never applies a change twice.
+// Other changes, like creating API keys, are sent once.
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults of the retry policy
+const (
+	defaultMaxRetries = 3
+	defaultMinBackoff = 100 * time.Millisecond
+	defaultMaxBackoff = 5 * time.Second
+)
+
+// Client calls the user service's REST API
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+	apiKey     string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sets the HTTP client requests are sent with, e.g. to add authentication or timeouts
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey authenticates every request with a service API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithRetries sets how often a request answered with 429 or a 5xx status is retried; zero disables retries
+func WithRetries(maxRetries int) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the delay before the first retry, which doubles with every further retry up to maxBackoff
+func WithBackoff(minBackoff, maxBackoff time.Duration) Option {
+	return func(c *Client) {
+		c.minBackoff = minBackoff
+		c.maxBackoff = maxBackoff
+	}
+}
+
+// New creates a Client for the service at baseURL, e.g. http://user-service:3000
+func New(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL: scheme must be http or https")
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+
+	c := &Client{
+		baseURL:    u,
+		httpClient: http.DefaultClient,
+		maxRetries: defaultMaxRetries,
+		minBackoff: defaultMinBackoff,
+		maxBackoff: defaultMaxBackoff,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
+
+// request describes one API call
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	body        []byte
+	contentType string
+	allowStatus int  // An error status whose response is decoded like a successful one
+	retry       bool // The request only reads, or its route honours Idempotency-Key; implied for GET
+}
+
+// jsonRequest creates a request whose body is v encoded as JSON
+func jsonRequest(method, path string, v interface{}) (*request, error) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	return &request{method: method, path: path, body: body, contentType: "application/json"}, nil
+}
+
+// do sends a request, retrying it on 429 and 5xx responses, and decodes a successful JSON
+// response into out unless out is nil
+func (c *Client) do(ctx context.Context, req *request, out interface{}) error {
+	resp, err := c.send(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer drain(resp.Body)
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// send sends a request, retrying it on 429 and 5xx responses if that is safe, and returns the
+// successful response, whose body the caller must close
+func (c *Client) send(ctx context.Context, req *request) (*http.Response, error) {
+	u := *c.baseURL
+	u.Path += req.path
+	u.RawQuery = req.query.Encode()
+
+	// Every attempt carries the same key, so the server applies the change at most once
+	var idempotencyKey string
+	if req.method != http.MethodGet {
+		idempotencyKey = uuid.NewString()
+	}
+
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u.String(), bytes.NewReader(req.body))
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		httpReq.Header.Set("Accept", "application/json")
+		if req.contentType != "" {
+			httpReq.Header.Set("Content-Type", req.contentType)
+		}
+		if idempotencyKey != "" {
+			httpReq.Header.Set("Idempotency-Key", idempotencyKey)
+		}
+		if c.apiKey != "" {
+			httpReq.Header.Set("Authorization", "ApiKey "+c.apiKey)
+		}
+
+		resp, err := c.httpClient.Do(httpReq)
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %w", req.method, req.path, err)
+		}
+		if resp.StatusCode < http.StatusBadRequest || resp.StatusCode == req.allowStatus {
+			return resp, nil
+		}
+
+		apiErr := newError(resp)
+		resp.Body.Close()
+		if attempt >= c.maxRetries || !retryable(resp.StatusCode) || (req.method != http.MethodGet && !req.retry) {
+			return nil, apiErr
+		}
+
+		select {
+		case <-time.After(c.backoff(attempt, resp.Header.Get("Retry-After"))):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether a request answered with status may succeed when sent again
+func retryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before retry number attempt+1. A Retry-After header in seconds is
+// honoured up to maxBackoff; otherwise the delay doubles from minBackoff, with jitter so that
+// clients failing together do not retry together.
+func (c *Client) backoff(attempt int, retryAfter string) time.Duration {
+	if seconds, err := strconv.Atoi(retryAfter); err == nil && seconds >= 0 {
+		return min(time.Duration(seconds)*time.Second, c.maxBackoff)
+	}
+
+	delay := c.minBackoff << attempt
+	if delay <= 0 || delay > c.maxBackoff {
+		delay = c.maxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// drain discards the rest of a body so that its connection can be reused
+func drain(body io.ReadCloser) {
+	io.Copy(io.Discard, body)
+	body.Close()
+}
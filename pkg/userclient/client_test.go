@@ -0,0 +1,318 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/graphqlserver"
+	"github.com/GoodsChain/user/internal/handler"
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/repository"
+	"github.com/GoodsChain/user/internal/router"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// mockUserRepository mocks the repository methods the tests exercise; the embedded interface
+// panics if any other method is called
+type mockUserRepository struct {
+	mock.Mock
+	repository.UserRepository
+}
+
+func (m *mockUserRepository) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	args := m.Called(ctx, user)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetUserByID(ctx context.Context, id uuid.UUID, fields []string) (*models.User, error) {
+	args := m.Called(ctx, id, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) UpdateUser(ctx context.Context, id uuid.UUID, updates *models.UpdateUserRequest) (*models.User, error) {
+	args := m.Called(ctx, id, updates)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) ReplaceUser(ctx context.Context, id uuid.UUID, req *models.ReplaceUserRequest, unmodifiedSince *time.Time) (*models.User, error) {
+	args := m.Called(ctx, id, req, unmodifiedSince)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.User), args.Error(1)
+}
+
+func (m *mockUserRepository) GetAllUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, pagination *models.PaginationParams, fields []string) (*models.GetUsersResponse, error) {
+	args := m.Called(ctx, filters, sort, pagination, fields)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GetUsersResponse), args.Error(1)
+}
+
+func (m *mockUserRepository) GetSubordinates(ctx context.Context, managerID uuid.UUID, maxDepth int) ([]models.UserHierarchyEntry, error) {
+	args := m.Called(ctx, managerID, maxDepth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.UserHierarchyEntry), args.Error(1)
+}
+
+func (m *mockUserRepository) StreamUsers(ctx context.Context, filters *models.FilterParams, sort *models.SortParams, fn func(*models.User) error) error {
+	args := m.Called(ctx, filters, sort, fn)
+	if users, ok := args.Get(0).([]models.User); ok {
+		for i := range users {
+			if err := fn(&users[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return args.Error(1)
+}
+
+// setupTestClient serves the real router over a mocked repository and returns a client for it
+// that retries without noticeable delays
+func setupTestClient(t *testing.T) (*Client, *mockUserRepository) {
+	gin.SetMode(gin.TestMode)
+	mockRepo := &mockUserRepository{}
+	server := httptest.NewServer(router.SetupRouter(handler.NewUserHandler(mockRepo), handler.NewAPIKeyHandler(nil), graphqlserver.NewHandler(mockRepo)))
+	t.Cleanup(server.Close)
+
+	client, err := New(server.URL, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+	return client, mockRepo
+}
+
+func testUser(name string) models.User {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	return models.User{
+		ID:        uuid.New(),
+		Email:     name + "@example.com",
+		FullName:  name,
+		Role:      "staff",
+		IsActive:  true,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func TestNew_InvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"user-service:3000", "ftp://user-service", "http://[::1"} {
+		_, err := New(baseURL)
+		assert.Error(t, err, baseURL)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	created := testUser("alice")
+	mockRepo.On("CreateUser", mock.Anything, mock.MatchedBy(func(user *models.User) bool {
+		return user.Email == "alice@example.com" && user.Role == "staff"
+	})).Return(&created, nil)
+
+	user, err := client.CreateUser(context.Background(), &CreateUserRequest{Email: "alice@example.com", FullName: "alice", Role: "staff"})
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, user.ID)
+	assert.Equal(t, created.CreatedAt, user.CreatedAt)
+}
+
+func TestCreateUser_ValidationError(t *testing.T) {
+	client, _ := setupTestClient(t)
+
+	_, err := client.CreateUser(context.Background(), &CreateUserRequest{Email: "not-an-email", FullName: "alice", Role: "staff"})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrValidation)
+	assert.NotErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, CodeValidationFailed, ErrorCode(err))
+
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, 400, apiErr.StatusCode)
+	require.Len(t, apiErr.FieldErrors(), 1)
+	assert.Equal(t, "email", apiErr.FieldErrors()[0].Field)
+	assert.Equal(t, "email", apiErr.FieldErrors()[0].Rule)
+}
+
+func TestGetUser_NotFound(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	id := uuid.New()
+	mockRepo.On("GetUserByID", mock.Anything, id, []string(nil)).Return(nil, errors.New("user not found"))
+
+	_, err := client.GetUser(context.Background(), id)
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, CodeUserNotFound, ErrorCode(err))
+	assert.Contains(t, err.Error(), "404")
+	mockRepo.AssertNumberOfCalls(t, "GetUserByID", 1)
+}
+
+func TestUpdateUser_Conflict(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	id := uuid.New()
+	email := "taken@example.com"
+	mockRepo.On("UpdateUser", mock.Anything, id, &models.UpdateUserRequest{Email: &email}).
+		Return(nil, errors.New(`pq: duplicate key value violates unique constraint "users_email_key"`))
+
+	_, err := client.UpdateUser(context.Background(), id, &UpdateUserRequest{Email: &email})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, CodeEmailConflict, ErrorCode(err))
+}
+
+func TestMergePatchUser(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	user := testUser("alice")
+	phone := "+62811"
+	user.Phone = &phone
+	patched := user
+	patched.Phone = nil
+
+	mockRepo.On("GetUserByID", mock.Anything, user.ID, []string(nil)).Return(&user, nil)
+	mockRepo.On("ReplaceUser", mock.Anything, user.ID, mock.MatchedBy(func(req *models.ReplaceUserRequest) bool {
+		return req.Phone == nil && req.Email == user.Email
+	}), &user.UpdatedAt).Return(&patched, nil)
+
+	result, err := client.MergePatchUser(context.Background(), user.ID, []byte(`{"phone": null}`))
+	require.NoError(t, err)
+	assert.Nil(t, result.Phone)
+}
+
+func TestListUsers_EncodesQuery(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	isActive := true
+	sort := "-full_name"
+	page, pageSize := 2, 5
+	mockRepo.On("GetAllUsers", mock.Anything, mock.MatchedBy(func(filters *models.FilterParams) bool {
+		return *filters.IsActive && len(filters.Conditions) == 1 &&
+			assert.ElementsMatch(t, []string{"admin", "staff"}, filters.Conditions[0].Values)
+	}), &models.SortParams{Fields: []models.SortField{{Field: "full_name", Order: "desc"}}},
+		&models.PaginationParams{Page: 2, PageSize: 5, Offset: 5}, []string(nil)).
+		Return(&models.GetUsersResponse{Data: []models.User{testUser("alice")}, Pagination: models.PaginationMetadata{Page: 2, PageSize: 5, Total: 6}}, nil)
+
+	response, err := client.ListUsers(context.Background(), &GetUsersRequest{
+		Role:     []string{"admin", "staff"},
+		IsActive: &isActive,
+		Sort:     &sort,
+		Page:     &page,
+		PageSize: &pageSize,
+	})
+	require.NoError(t, err)
+	assert.Len(t, response.Data, 1)
+	assert.Equal(t, 6, response.Pagination.Total)
+}
+
+func TestUsers_IteratesOverPages(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	pages := [][]models.User{
+		{testUser("a"), testUser("b")},
+		{testUser("c"), testUser("d")},
+		{testUser("e")},
+	}
+	for i, data := range pages {
+		mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything,
+			&models.PaginationParams{Page: i + 1, PageSize: 2, Offset: i * 2}, mock.Anything).
+			Return(&models.GetUsersResponse{Data: data, Pagination: models.PaginationMetadata{
+				Page: i + 1, PageSize: 2, Total: 5, TotalPages: 3, HasNext: i < 2, HasPrev: i > 0,
+			}}, nil).Once()
+	}
+
+	pageSize := 2
+	var names []string
+	for user, err := range client.Users(context.Background(), &GetUsersRequest{PageSize: &pageSize}) {
+		require.NoError(t, err)
+		names = append(names, user.FullName)
+	}
+	assert.Equal(t, []string{"a", "b", "c", "d", "e"}, names)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUsers_StopsEarly(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	mockRepo.On("GetAllUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(&models.GetUsersResponse{Data: []models.User{testUser("a"), testUser("b")}, Pagination: models.PaginationMetadata{HasNext: true}}, nil)
+
+	for user, err := range client.Users(context.Background(), nil) {
+		require.NoError(t, err)
+		assert.Equal(t, "a", user.FullName)
+		break
+	}
+	mockRepo.AssertNumberOfCalls(t, "GetAllUsers", 1)
+}
+
+func TestUsers_YieldsError(t *testing.T) {
+	client, _ := setupTestClient(t)
+
+	page := 0
+	var errs []error
+	for _, err := range client.Users(context.Background(), &GetUsersRequest{Page: &page}) {
+		errs = append(errs, err)
+	}
+	require.Len(t, errs, 1)
+	assert.ErrorIs(t, errs[0], ErrValidation)
+}
+
+func TestGetSubordinates(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	id := uuid.New()
+	mockRepo.On("GetSubordinates", mock.Anything, id, 3).
+		Return([]models.UserHierarchyEntry{{User: testUser("report"), Depth: 1}}, nil)
+
+	subordinates, err := client.GetSubordinates(context.Background(), id, 3)
+	require.NoError(t, err)
+	require.Len(t, subordinates, 1)
+	assert.Equal(t, "report", subordinates[0].FullName)
+	assert.Equal(t, 1, subordinates[0].Depth)
+}
+
+func TestExportUsers(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	mockRepo.On("StreamUsers", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.User{testUser("a"), testUser("b")}, nil)
+
+	format := "ndjson"
+	body, err := client.ExportUsers(context.Background(), &ExportUsersRequest{Format: &format})
+	require.NoError(t, err)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"a@example.com"`)
+}
+
+func TestImportUsers_NotCommitted(t *testing.T) {
+	client, _ := setupTestClient(t)
+
+	csv := "email,full_name,role\nalice@example.com,Alice,staff\nnot-an-email,Bob,staff\n"
+	response, err := client.ImportUsers(context.Background(), &ImportUsersRequest{}, strings.NewReader(csv))
+	require.NoError(t, err)
+	assert.False(t, response.Committed)
+	assert.Equal(t, 1, response.Summary["invalid"])
+	assert.Equal(t, 1, response.Summary["skipped"])
+}
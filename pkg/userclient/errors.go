@@ -0,0 +1,118 @@
+package userclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/GoodsChain/user/internal/models"
+)
+
+// maxErrorBody bounds how much of an error response is read
+const maxErrorBody = 1 << 20
+
+// Kinds of error responses, matched with errors.Is against an *Error
+var (
+	ErrNotFound   = errors.New("not found")        // 404, e.g. an unknown user
+	ErrConflict   = errors.New("conflict")         // 409, e.g. an email that is taken or a manager cycle
+	ErrValidation = errors.New("validation error") // 400, a request the service rejected as invalid
+)
+
+// Error is an error response of the service. Problem holds the problem document of the
+// response; responses that are not problem documents, e.g. from a proxy, only have a status.
+type Error struct {
+	StatusCode int
+	Problem    Problem
+}
+
+// newError reads the error response resp
+func newError(resp *http.Response) *Error {
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+	if strings.Contains(resp.Header.Get("Content-Type"), "json") {
+		json.Unmarshal(body, &apiErr.Problem)
+	}
+	if apiErr.Problem.Status == 0 {
+		apiErr.Problem.Status = resp.StatusCode
+	}
+	if apiErr.Problem.Detail == "" && apiErr.Problem.Code == "" {
+		apiErr.Problem.Detail = strings.TrimSpace(string(body))
+	}
+	return apiErr
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("user service: %d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Problem.Code != "" {
+		msg += " (" + e.Problem.Code + ")"
+	}
+	if e.Problem.Detail != "" {
+		msg += ": " + e.Problem.Detail
+	}
+	return msg
+}
+
+// Is matches ErrNotFound, ErrConflict and ErrValidation by status
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case ErrValidation:
+		return e.StatusCode == http.StatusBadRequest
+	}
+	return false
+}
+
+// Code returns the stable error code of the response, e.g. CodeEmailConflict
+func (e *Error) Code() string {
+	return e.Problem.Code
+}
+
+// FieldErrors returns the fields that failed validation, if any
+func (e *Error) FieldErrors() []FieldError {
+	return e.Problem.Errors
+}
+
+// ErrorCode returns the error code of err if it is an *Error, and an empty string otherwise
+func ErrorCode(err error) string {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.Code()
+	}
+	return ""
+}
+
+// Problem and its field errors, as returned by the service
+type (
+	Problem    = models.Problem
+	FieldError = models.FieldError
+)
+
+// Error codes of problem documents
+const (
+	CodeInvalidRequest           = models.CodeInvalidRequest
+	CodeUnauthenticated          = models.CodeUnauthenticated
+	CodeForbidden                = models.CodeForbidden
+	CodeValidationFailed         = models.CodeValidationFailed
+	CodeInvalidUserID            = models.CodeInvalidUserID
+	CodeUserNotFound             = models.CodeUserNotFound
+	CodeManagerNotFound          = models.CodeManagerNotFound
+	CodeManagerCycle             = models.CodeManagerCycle
+	CodeEmailConflict            = models.CodeEmailConflict
+	CodeConcurrentModification   = models.CodeConcurrentModification
+	CodePatchTestFailed          = models.CodePatchTestFailed
+	CodeInvalidMerge             = models.CodeInvalidMerge
+	CodeMergeTargetNotFound      = models.CodeMergeTargetNotFound
+	CodeUserAlreadyErased        = models.CodeUserAlreadyErased
+	CodeUserMerged               = models.CodeUserMerged
+	CodeAPIKeyNotFound           = models.CodeAPIKeyNotFound
+	CodeIdempotencyKeyReused     = models.CodeIdempotencyKeyReused
+	CodeIdempotencyKeyInProgress = models.CodeIdempotencyKeyInProgress
+	CodeInternal                 = models.CodeInternal
+)
@@ -0,0 +1,54 @@
+package userclient
+
+import (
+	"context"
+	"iter"
+)
+
+// Pages iterates over the pages of the users matching req, starting at req.Page, until the last
+// page or the first error. Pages are requested by number, so users created or deleted during the
+// iteration can shift others between pages; sort by a stable key such as created_at to keep this
+// to the end of the list.
+func (c *Client) Pages(ctx context.Context, req *GetUsersRequest) iter.Seq2[*GetUsersResponse, error] {
+	return func(yield func(*GetUsersResponse, error) bool) {
+		pageReq := GetUsersRequest{}
+		if req != nil {
+			pageReq = *req
+		}
+		page := 1
+		if pageReq.Page != nil {
+			page = *pageReq.Page
+		}
+
+		for {
+			pageReq.Page = &page
+			response, err := c.ListUsers(ctx, &pageReq)
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+			if !yield(response, nil) || !response.Pagination.HasNext {
+				return
+			}
+			page++
+		}
+	}
+}
+
+// Users iterates over every user matching req, fetching the pages as they are reached. It stops
+// at the first error, which it yields with a zero User.
+func (c *Client) Users(ctx context.Context, req *GetUsersRequest) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		for response, err := range c.Pages(ctx, req) {
+			if err != nil {
+				yield(User{}, err)
+				return
+			}
+			for _, user := range response.Data {
+				if !yield(user, nil) {
+					return
+				}
+			}
+		}
+	}
+}
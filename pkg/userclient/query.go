@@ -0,0 +1,66 @@
+package userclient
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+)
+
+// encodeQuery encodes the fields of a query parameter struct, such as GetUsersRequest, by their
+// form tags. Nil pointers and empty slices are omitted, slices become repeated parameters and
+// embedded structs are flattened.
+func encodeQuery(v interface{}) url.Values {
+	values := url.Values{}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return values
+		}
+		rv = rv.Elem()
+	}
+	addQueryFields(values, rv)
+	return values
+}
+
+func addQueryFields(values url.Values, rv reflect.Value) {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field, value := rt.Field(i), rv.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addQueryFields(values, value)
+			continue
+		}
+
+		name := field.Tag.Get("form")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		switch value.Kind() {
+		case reflect.Pointer:
+			if !value.IsNil() {
+				values.Add(name, formatQueryValue(value.Elem()))
+			}
+		case reflect.Slice:
+			for j := 0; j < value.Len(); j++ {
+				values.Add(name, formatQueryValue(value.Index(j)))
+			}
+		default:
+			values.Add(name, formatQueryValue(value))
+		}
+	}
+}
+
+func formatQueryValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,152 @@
+package userclient
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetry_ServerError(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	user := testUser("alice")
+	mockRepo.On("GetUserByID", mock.Anything, user.ID, []string(nil)).Return(nil, errors.New("connection reset")).Twice()
+	mockRepo.On("GetUserByID", mock.Anything, user.ID, []string(nil)).Return(&user, nil).Once()
+
+	result, err := client.GetUser(context.Background(), user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, user.ID, result.ID)
+	mockRepo.AssertNumberOfCalls(t, "GetUserByID", 3)
+}
+
+func TestRetry_GivesUp(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	id := uuid.New()
+	mockRepo.On("GetUserByID", mock.Anything, id, []string(nil)).Return(nil, errors.New("connection reset"))
+
+	_, err := client.GetUser(context.Background(), id)
+	assert.Equal(t, CodeInternal, ErrorCode(err))
+	mockRepo.AssertNumberOfCalls(t, "GetUserByID", 1+defaultMaxRetries)
+}
+
+func TestRetry_NotOnClientErrors(t *testing.T) {
+	client, mockRepo := setupTestClient(t)
+
+	id := uuid.New()
+	mockRepo.On("UpdateUser", mock.Anything, id, mock.Anything).Return(nil, errors.New("manager assignment would create a cycle"))
+
+	_, err := client.UpdateUser(context.Background(), id, &UpdateUserRequest{ManagerID: &id})
+	assert.ErrorIs(t, err, ErrConflict)
+	assert.Equal(t, models.CodeManagerCycle, ErrorCode(err))
+	mockRepo.AssertNumberOfCalls(t, "UpdateUser", 1)
+}
+
+// recordingServer answers with the given statuses in turn and records the requests' Idempotency-Keys
+func recordingServer(t *testing.T, statuses ...int) (*httptest.Server, *[]string) {
+	var mu sync.Mutex
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+
+		status := statuses[min(len(keys), len(statuses))-1]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"id": "` + uuid.NewString() + `"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &keys
+}
+
+func TestRetry_TooManyRequests(t *testing.T) {
+	server, keys := recordingServer(t, http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusCreated)
+	client, err := New(server.URL, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = client.CreateUser(context.Background(), &CreateUserRequest{Email: "alice@example.com", FullName: "alice", Role: "staff"})
+	require.NoError(t, err)
+
+	// Every attempt carries the same key, so the server creates the user once
+	require.Len(t, *keys, 3)
+	assert.NotEmpty(t, (*keys)[0])
+	assert.Equal(t, (*keys)[0], (*keys)[1])
+	assert.Equal(t, (*keys)[0], (*keys)[2])
+}
+
+func TestRetry_NotForRoutesWithoutIdempotencyKeys(t *testing.T) {
+	server, keys := recordingServer(t, http.StatusServiceUnavailable, http.StatusCreated)
+	client, err := New(server.URL, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+
+	// The API key routes ignore Idempotency-Key, so a retry could create a second key
+	_, err = client.CreateAPIKey(context.Background(), &CreateAPIKeyRequest{Name: "erp-sync"})
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Len(t, *keys, 1)
+}
+
+func TestRetry_PostThatReads(t *testing.T) {
+	server, keys := recordingServer(t, http.StatusServiceUnavailable, http.StatusOK)
+	client, err := New(server.URL, WithBackoff(time.Millisecond, 10*time.Millisecond))
+	require.NoError(t, err)
+
+	_, err = client.BatchGetUsers(context.Background(), []uuid.UUID{uuid.New()})
+	require.NoError(t, err)
+	assert.Len(t, *keys, 2)
+}
+
+func TestRetry_Disabled(t *testing.T) {
+	server, keys := recordingServer(t, http.StatusBadGateway)
+	client, err := New(server.URL, WithRetries(0))
+	require.NoError(t, err)
+
+	_, err = client.GetUser(context.Background(), uuid.New())
+	var apiErr *Error
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Len(t, *keys, 1)
+	assert.Empty(t, (*keys)[0], "GET requests carry no Idempotency-Key")
+}
+
+func TestRetry_ContextCanceled(t *testing.T) {
+	server, keys := recordingServer(t, http.StatusInternalServerError)
+	client, err := New(server.URL, WithBackoff(time.Hour, time.Hour))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = client.GetUser(ctx, uuid.New())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Len(t, *keys, 1)
+}
+
+func TestBackoff(t *testing.T) {
+	client, err := New("http://localhost", WithBackoff(100*time.Millisecond, time.Second))
+	require.NoError(t, err)
+
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second} {
+		delay := client.backoff(attempt, "")
+		assert.GreaterOrEqual(t, delay, want/2, "attempt %d", attempt)
+		assert.LessOrEqual(t, delay, want, "attempt %d", attempt)
+	}
+
+	assert.Equal(t, 0*time.Second, client.backoff(0, "0"))
+	assert.Equal(t, time.Second, client.backoff(0, "120"), "Retry-After is capped")
+	assert.GreaterOrEqual(t, client.backoff(100, ""), time.Second/2, "shifts do not overflow")
+}
@@ -0,0 +1,266 @@
+package userclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/GoodsChain/user/internal/models"
+	"github.com/GoodsChain/user/internal/patch"
+	"github.com/google/uuid"
+)
+
+// Request and response types of the API
+type (
+	User                     = models.User
+	CreateUserRequest        = models.CreateUserRequest
+	UpdateUserRequest        = models.UpdateUserRequest
+	ReplaceUserRequest       = models.ReplaceUserRequest
+	GetUsersRequest          = models.GetUsersRequest
+	GetUsersResponse         = models.GetUsersResponse
+	PaginationMetadata       = models.PaginationMetadata
+	BatchGetUsersRequest     = models.BatchGetUsersRequest
+	BatchGetUsersResponse    = models.BatchGetUsersResponse
+	UserListResponse         = models.UserListResponse
+	UserHierarchyEntry       = models.UserHierarchyEntry
+	GetUserHierarchyResponse = models.GetUserHierarchyResponse
+	MergeUsersRequest        = models.MergeUsersRequest
+	MergeUsersResponse       = models.MergeUsersResponse
+	ErasureResult            = models.ErasureResult
+	ErasureReceipt           = models.ErasureReceipt
+	UserDataExport           = models.UserDataExport
+	ExportUsersRequest       = models.ExportUsersRequest
+	ImportUsersRequest       = models.ImportUsersRequest
+	ImportUsersResponse      = models.ImportUsersResponse
+	ImportRowResult          = models.ImportRowResult
+	GetUserStatsRequest      = models.GetUserStatsRequest
+	UserStats                = models.UserStats
+	DomainCount              = models.DomainCount
+	StatsBucket              = models.StatsBucket
+)
+
+const usersPath = "/api/v1/users"
+
+func userPath(id uuid.UUID, suffix string) string {
+	return usersPath + "/" + id.String() + suffix
+}
+
+// CreateUser creates a user
+func (c *Client) CreateUser(ctx context.Context, req *CreateUserRequest) (*User, error) {
+	r, err := jsonRequest(http.MethodPost, usersPath+"/", req)
+	if err != nil {
+		return nil, err
+	}
+	r.retry = true
+	var user User
+	if err := c.do(ctx, r, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUser returns a user by ID. A user that has been merged is resolved to the user it was merged into.
+func (c *Client) GetUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	var user User
+	if err := c.do(ctx, &request{method: http.MethodGet, path: userPath(id, "")}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetUserByEmail returns a user by exact, case-insensitive email
+func (c *Client) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	var user User
+	if err := c.do(ctx, &request{method: http.MethodGet, path: usersPath + "/by-email/" + email}, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// BatchGetUsers returns up to 500 users by ID, in request order, and the IDs with no user
+func (c *Client) BatchGetUsers(ctx context.Context, ids []uuid.UUID) (*BatchGetUsersResponse, error) {
+	r, err := jsonRequest(http.MethodPost, usersPath+"/batch-get", &BatchGetUsersRequest{IDs: ids})
+	if err != nil {
+		return nil, err
+	}
+	r.retry = true
+	var response BatchGetUsersResponse
+	if err := c.do(ctx, r, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// ListUsers returns one page of the users matching req; see Users to iterate over every page
+func (c *Client) ListUsers(ctx context.Context, req *GetUsersRequest) (*GetUsersResponse, error) {
+	var response GetUsersResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: usersPath + "/", query: encodeQuery(req)}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// UpdateUser updates the fields set in req
+func (c *Client) UpdateUser(ctx context.Context, id uuid.UUID, req *UpdateUserRequest) (*User, error) {
+	r, err := jsonRequest(http.MethodPatch, userPath(id, ""), req)
+	if err != nil {
+		return nil, err
+	}
+	return c.writeUser(ctx, r)
+}
+
+// MergePatchUser applies an RFC 7386 JSON merge patch to a user, which can also clear fields
+func (c *Client) MergePatchUser(ctx context.Context, id uuid.UUID, mergePatch []byte) (*User, error) {
+	return c.writeUser(ctx, &request{
+		method:      http.MethodPatch,
+		path:        userPath(id, ""),
+		body:        mergePatch,
+		contentType: patch.MergePatchContentType,
+	})
+}
+
+// JSONPatchUser applies an RFC 6902 JSON patch to a user. A failed test operation is a conflict
+// with code CodePatchTestFailed.
+func (c *Client) JSONPatchUser(ctx context.Context, id uuid.UUID, jsonPatch []byte) (*User, error) {
+	return c.writeUser(ctx, &request{
+		method:      http.MethodPatch,
+		path:        userPath(id, ""),
+		body:        jsonPatch,
+		contentType: patch.JSONPatchContentType,
+	})
+}
+
+// ReplaceUser replaces every changeable field of a user; optional fields left nil are cleared
+func (c *Client) ReplaceUser(ctx context.Context, id uuid.UUID, req *ReplaceUserRequest) (*User, error) {
+	r, err := jsonRequest(http.MethodPut, userPath(id, ""), req)
+	if err != nil {
+		return nil, err
+	}
+	return c.writeUser(ctx, r)
+}
+
+// DeleteUser deletes a user and returns it as it was
+func (c *Client) DeleteUser(ctx context.Context, id uuid.UUID) (*User, error) {
+	return c.writeUser(ctx, &request{method: http.MethodDelete, path: userPath(id, "")})
+}
+
+func (c *Client) writeUser(ctx context.Context, r *request) (*User, error) {
+	r.retry = true
+	var user User
+	if err := c.do(ctx, r, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// GetDirectReports returns the users who report directly to a user
+func (c *Client) GetDirectReports(ctx context.Context, id uuid.UUID) ([]User, error) {
+	var response UserListResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: userPath(id, "/reports")}, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetSubordinates returns a user's reporting subtree down to maxDepth levels, or the service's
+// default of 20 if maxDepth is zero
+func (c *Client) GetSubordinates(ctx context.Context, id uuid.UUID, maxDepth int) ([]UserHierarchyEntry, error) {
+	query := url.Values{}
+	if maxDepth != 0 {
+		query.Set("max_depth", strconv.Itoa(maxDepth))
+	}
+	var response GetUserHierarchyResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: userPath(id, "/subordinates"), query: query}, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// GetManagementChain returns a user's managers up to the root of the hierarchy, nearest first
+func (c *Client) GetManagementChain(ctx context.Context, id uuid.UUID) ([]UserHierarchyEntry, error) {
+	var response GetUserHierarchyResponse
+	if err := c.do(ctx, &request{method: http.MethodGet, path: userPath(id, "/chain")}, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// MergeUser merges the duplicate user sourceID into req.TargetID, which survives
+func (c *Client) MergeUser(ctx context.Context, sourceID uuid.UUID, req *MergeUsersRequest) (*MergeUsersResponse, error) {
+	r, err := jsonRequest(http.MethodPost, userPath(sourceID, "/merge"), req)
+	if err != nil {
+		return nil, err
+	}
+	r.retry = true
+	var response MergeUsersResponse
+	if err := c.do(ctx, r, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// EraseUser erases a user's personal data and returns the signed erasure receipt
+func (c *Client) EraseUser(ctx context.Context, id uuid.UUID) (*ErasureReceipt, error) {
+	var receipt ErasureReceipt
+	if err := c.do(ctx, &request{method: http.MethodPost, path: userPath(id, "/erase"), retry: true}, &receipt); err != nil {
+		return nil, err
+	}
+	return &receipt, nil
+}
+
+// ExportUserData returns everything the service stores about a user
+func (c *Client) ExportUserData(ctx context.Context, id uuid.UUID) (*UserDataExport, error) {
+	var export UserDataExport
+	if err := c.do(ctx, &request{method: http.MethodGet, path: userPath(id, "/export")}, &export); err != nil {
+		return nil, err
+	}
+	return &export, nil
+}
+
+// ExportUsers streams the users matching req as CSV or NDJSON. The caller must close the
+// returned body; an error while reading it means the export was cut short.
+func (c *Client) ExportUsers(ctx context.Context, req *ExportUsersRequest) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, &request{method: http.MethodGet, path: usersPath + "/export", query: encodeQuery(req)})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// ImportUsers imports users from CSV and returns the per-row report. Unless it is a dry run,
+// nothing is written if any row fails, which the report shows with Committed false. The CSV is
+// read completely before it is sent, so that the request can be retried.
+func (c *Client) ImportUsers(ctx context.Context, req *ImportUsersRequest, csv io.Reader) (*ImportUsersResponse, error) {
+	var body bytes.Buffer
+	if _, err := body.ReadFrom(csv); err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+
+	r := &request{
+		method:      http.MethodPost,
+		path:        usersPath + "/import",
+		query:       encodeQuery(req),
+		body:        body.Bytes(),
+		contentType: "text/csv",
+		allowStatus: http.StatusUnprocessableEntity,
+		retry:       true,
+	}
+	var response ImportUsersResponse
+	if err := c.do(ctx, r, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetUserStats returns aggregated counts over the users matching req
+func (c *Client) GetUserStats(ctx context.Context, req *GetUserStatsRequest) (*UserStats, error) {
+	var stats UserStats
+	if err := c.do(ctx, &request{method: http.MethodGet, path: usersPath + "/stats", query: encodeQuery(req)}, &stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}